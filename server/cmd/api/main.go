@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -18,8 +17,11 @@ import (
 	"github.com/joho/godotenv"
 
 	"yourownboss/internal/auth"
+	"yourownboss/internal/catalog"
 	"yourownboss/internal/db"
+	"yourownboss/internal/events"
 	httpHandlers "yourownboss/internal/http"
+	"yourownboss/internal/jobs"
 	"yourownboss/internal/repository"
 	"yourownboss/internal/service"
 )
@@ -32,15 +34,42 @@ func main() {
 
 	// Parse flags
 	var (
-		port          = flag.String("port", "8080", "Server port")
-		dbPath        = flag.String("db", "yourownboss.db", "Database file path")
-		jwtSecret     = flag.String("jwt-secret", "", "JWT secret key (if empty, uses default)")
-		staticDir     = flag.String("static", "../public", "Static files directory")
-		resourcesFile = flag.String("resources", "data/resources.json", "Resources JSON file")
-		buildingsFile = flag.String("production-buildings", "data/production_buildings.json", "Production buildings JSON file")
+		port             = flag.String("port", "8080", "Server port")
+		dbPath           = flag.String("db", "yourownboss.db", "Database file path")
+		jwtSecret        = flag.String("jwt-secret", "", "JWT secret key (if empty, uses default)")
+		staticDir        = flag.String("static", "../public", "Static files directory")
+		resourcesFile    = flag.String("resources", "data/resources.json", "Resources JSON file")
+		buildingsFile    = flag.String("production-buildings", "data/production_buildings.json", "Production buildings JSON file")
+		researchFile     = flag.String("research", "data/research.json", "Research tree JSON file")
+		contractsFile    = flag.String("contracts", "data/contracts.json", "Contract templates JSON file")
+		achievementsFile = flag.String("achievements", "data/achievements.json", "Achievements JSON file")
+		strictCatalog    = flag.Bool("strict-catalog", false, "Refuse to start if the catalog files have errors")
+		upkeepEvery      = flag.Duration("upkeep-interval", 0, "How often building upkeep is charged (default 1h)")
+		storageLimits    = flag.Bool("storage-limits", false, "Limit company storage to its capacity (default unlimited)")
+		baseStorage      = flag.Int64("base-storage", -1, "Storage capacity of a company without warehouses (default 1000)")
+		workerWage       = flag.Int64("worker-wage", -1, "Wage per worker and upkeep period in thousandths (default 10000)")
+		rushCost         = flag.Int64("rush-cost-per-minute", -1, "Price of rushing a minute of work in thousandths (default 20000)")
+		rushExponent     = flag.Float64("rush-cost-exponent", 0, "Exponent applied to the minutes rushed (default 1, linear)")
+		contractEvery    = flag.Duration("contract-interval", 0, "How often companies are offered new contracts (default 1h)")
+		loanInterest     = flag.Float64("loan-interest", -1, "Loan interest per upkeep period in percent (default 0.5)")
+		creditLimit      = flag.Float64("loan-credit-limit", -1, "Credit limit in percent of net worth (default 50)")
+		valuationTick    = flag.Duration("valuation-interval", 0, "How often net worth snapshots are taken (default 1h)")
+		rankingTick      = flag.Duration("leaderboard-interval", 0, "How often the leaderboards are recomputed (default 5m)")
+		notificationKeep = flag.Duration("notification-retention", 0, "How long notifications are kept (default 168h)")
 	)
 	flag.Parse()
 
+	// Subcommands (e.g. "catalog lint") run and exit without starting the server
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args(), *resourcesFile, *buildingsFile, *researchFile, *contractsFile, *achievementsFile))
+	}
+
+	if envStrict := os.Getenv("STRICT_CATALOG"); envStrict != "" {
+		if parsed, err := strconv.ParseBool(envStrict); err == nil {
+			*strictCatalog = *strictCatalog || parsed
+		}
+	}
+
 	// Set JWT secret if provided
 	if *jwtSecret != "" {
 		auth.SetJWTSecret(*jwtSecret)
@@ -61,6 +90,160 @@ func main() {
 		}
 	}
 
+	// Get upkeep interval from environment unless set by flag
+	upkeepInterval := *upkeepEvery
+	if envUpkeep := os.Getenv("UPKEEP_INTERVAL"); envUpkeep != "" && upkeepInterval == 0 {
+		if parsed, err := time.ParseDuration(envUpkeep); err == nil {
+			upkeepInterval = parsed
+		} else {
+			log.Printf("WARNING: Invalid UPKEEP_INTERVAL value, using default: %s", service.DefaultUpkeepInterval)
+		}
+	}
+	if upkeepInterval <= 0 {
+		upkeepInterval = service.DefaultUpkeepInterval
+	}
+
+	// Get worker wage from environment unless set by flag
+	wage := *workerWage
+	if envWage := os.Getenv("WORKER_WAGE"); envWage != "" && wage < 0 {
+		if parsed, err := strconv.ParseInt(envWage, 10, 64); err == nil && parsed >= 0 {
+			wage = parsed
+		} else {
+			log.Printf("WARNING: Invalid WORKER_WAGE value, using default: %d", service.DefaultWorkerWage)
+		}
+	}
+	if wage < 0 {
+		wage = service.DefaultWorkerWage
+	}
+
+	// Get rush pricing from environment unless set by flag
+	rushPricing := service.RushPricing{CostPerMinute: *rushCost, Exponent: *rushExponent}
+	if envCost := os.Getenv("RUSH_COST_PER_MINUTE"); envCost != "" && rushPricing.CostPerMinute < 0 {
+		if parsed, err := strconv.ParseInt(envCost, 10, 64); err == nil && parsed >= 0 {
+			rushPricing.CostPerMinute = parsed
+		} else {
+			log.Printf("WARNING: Invalid RUSH_COST_PER_MINUTE value, using default: %d", service.DefaultRushPricing.CostPerMinute)
+		}
+	}
+	if envExponent := os.Getenv("RUSH_COST_EXPONENT"); envExponent != "" && rushPricing.Exponent <= 0 {
+		if parsed, err := strconv.ParseFloat(envExponent, 64); err == nil && parsed > 0 {
+			rushPricing.Exponent = parsed
+		} else {
+			log.Printf("WARNING: Invalid RUSH_COST_EXPONENT value, using default: %g", service.DefaultRushPricing.Exponent)
+		}
+	}
+	if rushPricing.CostPerMinute < 0 {
+		rushPricing.CostPerMinute = service.DefaultRushPricing.CostPerMinute
+	}
+	if rushPricing.Exponent <= 0 {
+		rushPricing.Exponent = service.DefaultRushPricing.Exponent
+	}
+
+	// Get contract offer interval from environment unless set by flag
+	contractInterval := *contractEvery
+	if envContracts := os.Getenv("CONTRACT_INTERVAL"); envContracts != "" && contractInterval == 0 {
+		if parsed, err := time.ParseDuration(envContracts); err == nil {
+			contractInterval = parsed
+		} else {
+			log.Printf("WARNING: Invalid CONTRACT_INTERVAL value, using default: %s", service.DefaultContractInterval)
+		}
+	}
+	if contractInterval <= 0 {
+		contractInterval = service.DefaultContractInterval
+	}
+
+	// Get loan terms from environment unless set by flag
+	loanTerms := service.LoanTerms{InterestPercent: *loanInterest, CreditLimitPercent: *creditLimit}
+	if envInterest := os.Getenv("LOAN_INTEREST_PERCENT"); envInterest != "" && loanTerms.InterestPercent < 0 {
+		if parsed, err := strconv.ParseFloat(envInterest, 64); err == nil && parsed >= 0 {
+			loanTerms.InterestPercent = parsed
+		} else {
+			log.Printf("WARNING: Invalid LOAN_INTEREST_PERCENT value, using default: %g", service.DefaultLoanTerms.InterestPercent)
+		}
+	}
+	if envLimit := os.Getenv("LOAN_CREDIT_LIMIT_PERCENT"); envLimit != "" && loanTerms.CreditLimitPercent < 0 {
+		if parsed, err := strconv.ParseFloat(envLimit, 64); err == nil && parsed >= 0 {
+			loanTerms.CreditLimitPercent = parsed
+		} else {
+			log.Printf("WARNING: Invalid LOAN_CREDIT_LIMIT_PERCENT value, using default: %g", service.DefaultLoanTerms.CreditLimitPercent)
+		}
+	}
+	if loanTerms.InterestPercent < 0 {
+		loanTerms.InterestPercent = service.DefaultLoanTerms.InterestPercent
+	}
+	if loanTerms.CreditLimitPercent < 0 {
+		loanTerms.CreditLimitPercent = service.DefaultLoanTerms.CreditLimitPercent
+	}
+
+	// Get valuation snapshot interval from environment unless set by flag
+	valuationInterval := *valuationTick
+	if envValuation := os.Getenv("VALUATION_INTERVAL"); envValuation != "" && valuationInterval == 0 {
+		if parsed, err := time.ParseDuration(envValuation); err == nil {
+			valuationInterval = parsed
+		} else {
+			log.Printf("WARNING: Invalid VALUATION_INTERVAL value, using default: %s", service.DefaultValuationInterval)
+		}
+	}
+	if valuationInterval <= 0 {
+		valuationInterval = service.DefaultValuationInterval
+	}
+
+	// Get leaderboard refresh interval from environment unless set by flag
+	leaderboardInterval := *rankingTick
+	if envLeaderboard := os.Getenv("LEADERBOARD_INTERVAL"); envLeaderboard != "" && leaderboardInterval == 0 {
+		if parsed, err := time.ParseDuration(envLeaderboard); err == nil {
+			leaderboardInterval = parsed
+		} else {
+			log.Printf("WARNING: Invalid LEADERBOARD_INTERVAL value, using default: %s", service.DefaultLeaderboardInterval)
+		}
+	}
+	if leaderboardInterval <= 0 {
+		leaderboardInterval = service.DefaultLeaderboardInterval
+	}
+
+	// Get notification retention from environment unless set by flag
+	notificationRetention := *notificationKeep
+	if envRetention := os.Getenv("NOTIFICATION_RETENTION"); envRetention != "" && notificationRetention == 0 {
+		if parsed, err := time.ParseDuration(envRetention); err == nil {
+			notificationRetention = parsed
+		} else {
+			log.Printf("WARNING: Invalid NOTIFICATION_RETENTION value, using default: %s", service.DefaultNotificationRetention)
+		}
+	}
+	if notificationRetention <= 0 {
+		notificationRetention = service.DefaultNotificationRetention
+	}
+
+	// Storage is unlimited unless enabled by flag or environment. A bool
+	// flag has no unset value, so check whether it was given at all
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	storage := repository.StorageConfig{
+		Limited:      *storageLimits,
+		BaseCapacity: *baseStorage,
+	}
+	if envLimits := os.Getenv("STORAGE_LIMITS"); envLimits != "" && !setFlags["storage-limits"] {
+		if parsed, err := strconv.ParseBool(envLimits); err == nil {
+			storage.Limited = parsed
+		} else {
+			log.Printf("WARNING: Invalid STORAGE_LIMITS value, using default: %t", storage.Limited)
+		}
+	}
+	if envBase := os.Getenv("BASE_STORAGE_CAPACITY"); envBase != "" && storage.BaseCapacity < 0 {
+		if parsed, err := strconv.ParseInt(envBase, 10, 64); err == nil && parsed >= 0 {
+			storage.BaseCapacity = parsed
+		} else {
+			log.Printf("WARNING: Invalid BASE_STORAGE_CAPACITY value, using default: %d", repository.DefaultBaseStorageCapacity)
+		}
+	}
+	if storage.BaseCapacity < 0 {
+		storage.BaseCapacity = repository.DefaultBaseStorageCapacity
+	}
+	if storage.Limited {
+		log.Printf("Storage limits enabled (base capacity: %d)", storage.BaseCapacity)
+	}
+
 	// Open database
 	database, err := db.Open(*dbPath)
 	if err != nil {
@@ -76,10 +259,30 @@ func main() {
 	tokenRepo := repository.NewTokenRepository(database)
 	companyRepo := repository.NewCompanyRepository(database)
 	resourceRepo := repository.NewResourceRepository(database)
-	inventoryRepo := repository.NewInventoryRepository(database)
+	inventoryRepo := repository.NewInventoryRepository(database, storage)
 	productionBuildingRepo := repository.NewProductionBuildingRepository(database)
 	productionProcessRepo := repository.NewProductionProcessRepository(database)
 	processResourceRepo := repository.NewProductionProcessResourceRepository(database)
+	buildingLevelRepo := repository.NewProductionBuildingLevelRepository(database)
+	companyBuildingRepo := repository.NewCompanyBuildingRepository(database)
+	productionRunRepo := repository.NewProductionRunRepository(database)
+	ledgerRepo := repository.NewLedgerRepository(database)
+	supplyLinkRepo := repository.NewSupplyLinkRepository(database)
+	bufferRepo := repository.NewBuildingBufferRepository(database)
+	researchRepo := repository.NewResearchRepository(database)
+	companyResearchRepo := repository.NewCompanyResearchRepository(database)
+	contractTemplateRepo := repository.NewContractTemplateRepository(database)
+	contractRepo := repository.NewContractRepository(database)
+	loanRepo := repository.NewLoanRepository(database)
+	valuationRepo := repository.NewValuationRepository(database)
+	achievementRepo := repository.NewAchievementRepository(database)
+	companyAchievementRepo := repository.NewCompanyAchievementRepository(database)
+	productionOutputRepo := repository.NewProductionOutputRepository(database)
+	leaderboardRepo := repository.NewLeaderboardRepository(database)
+	notificationRepo := repository.NewNotificationRepository(database)
+	transactor := repository.NewTransactor(database)
+
+	checkCatalog(*resourcesFile, *buildingsFile, *researchFile, *contractsFile, *achievementsFile, *strictCatalog)
 
 	if err := loadResourcesFromFile(context.Background(), resourceRepo, *resourcesFile); err != nil {
 		log.Printf("Warning: failed to load resources: %v", err)
@@ -90,30 +293,209 @@ func main() {
 		productionBuildingRepo,
 		productionProcessRepo,
 		processResourceRepo,
+		buildingLevelRepo,
 		resourceRepo,
 		*buildingsFile,
 	); err != nil {
 		log.Printf("Warning: failed to load production buildings: %v", err)
 	}
 
+	if err := loadResearchFromFile(context.Background(), researchRepo, resourceRepo, *researchFile); err != nil {
+		log.Printf("Warning: failed to load research: %v", err)
+	}
+
+	if err := loadContractsFromFile(context.Background(), contractTemplateRepo, resourceRepo, *contractsFile); err != nil {
+		log.Printf("Warning: failed to load contracts: %v", err)
+	}
+
+	if err := loadAchievementsFromFile(context.Background(), achievementRepo, resourceRepo, *achievementsFile); err != nil {
+		log.Printf("Warning: failed to load achievements: %v", err)
+	}
+
+	// Event bus, subscribers are registered once the services exist
+	bus := events.NewBus()
+	defer bus.Close()
+
 	// Service layer
 	authService := service.NewAuthService(userRepo, tokenRepo)
-	companyService := service.NewCompanyService(companyRepo, initialMoney)
+	ledgerService := service.NewLedgerService(companyRepo, ledgerRepo)
+	upkeepService := service.NewUpkeepService(
+		companyRepo,
+		productionBuildingRepo,
+		companyBuildingRepo,
+		ledgerService,
+		transactor,
+		upkeepInterval,
+		wage,
+	)
+	companyService := service.NewCompanyService(companyRepo, upkeepService, bus, initialMoney)
 	inventoryService := service.NewInventoryService(resourceRepo, inventoryRepo)
-	marketService := service.NewMarketService(resourceRepo, companyRepo, inventoryRepo)
+	achievementService := service.NewAchievementService(
+		achievementRepo,
+		companyAchievementRepo,
+		resourceRepo,
+		inventoryRepo,
+		ledgerService,
+	)
+	researchService := service.NewResearchService(
+		researchRepo,
+		companyResearchRepo,
+		resourceRepo,
+		inventoryRepo,
+		ledgerService,
+		transactor,
+	)
+	marketService := service.NewMarketService(resourceRepo, inventoryRepo, ledgerService, bus, transactor)
 	productionService := service.NewProductionService(
 		productionBuildingRepo,
 		productionProcessRepo,
 		processResourceRepo,
 		resourceRepo,
+		buildingLevelRepo,
+		companyBuildingRepo,
+		productionRunRepo,
+		inventoryRepo,
+		supplyLinkRepo,
+		bufferRepo,
+		upkeepService,
+		researchService,
+		bus,
+		transactor,
+	)
+	workforceService := service.NewWorkforceService(companyRepo, productionRunRepo, upkeepService)
+	buildingService := service.NewBuildingService(
+		productionBuildingRepo,
+		buildingLevelRepo,
+		companyBuildingRepo,
+		productionRunRepo,
+		inventoryRepo,
+		resourceRepo,
+		bufferRepo,
+		ledgerService,
+		upkeepService,
+		researchService,
+		bus,
+		transactor,
+	)
+	rushService := service.NewRushService(
+		productionBuildingRepo,
+		productionProcessRepo,
+		companyBuildingRepo,
+		productionRunRepo,
+		ledgerService,
+		rushPricing,
+	)
+	contractService := service.NewContractService(
+		contractTemplateRepo,
+		contractRepo,
+		companyRepo,
+		companyBuildingRepo,
+		resourceRepo,
+		inventoryRepo,
+		ledgerService,
+		upkeepService,
+		bus,
+		contractInterval,
+	)
+	valuationService := service.NewValuationService(
+		valuationRepo,
+		companyRepo,
+		resourceRepo,
+		inventoryRepo,
+		productionBuildingRepo,
+		buildingLevelRepo,
+		companyBuildingRepo,
+		loanRepo,
+	)
+	loanService := service.NewLoanService(
+		loanRepo,
+		companyRepo,
+		productionBuildingRepo,
+		buildingLevelRepo,
+		companyBuildingRepo,
+		ledgerService,
+		upkeepService,
+		valuationService,
+		transactor,
+		loanTerms,
+		upkeepInterval,
+	)
+	leaderboardService := service.NewLeaderboardService(
+		leaderboardRepo,
+		companyRepo,
+		resourceRepo,
+		ledgerRepo,
+		productionOutputRepo,
+		valuationService,
 	)
+	notificationService := service.NewNotificationService(
+		notificationRepo,
+		resourceRepo,
+		productionBuildingRepo,
+		productionProcessRepo,
+		companyBuildingRepo,
+		productionRunRepo,
+		contractRepo,
+		notificationRetention,
+	)
+	supplyLinkService := service.NewSupplyLinkService(
+		companyBuildingRepo,
+		productionProcessRepo,
+		processResourceRepo,
+		resourceRepo,
+		inventoryRepo,
+		supplyLinkRepo,
+		bufferRepo,
+	)
+
+	registerSubscribers(bus, achievementService, leaderboardService, notificationService)
+
+	// Background jobs
+	scheduler := jobs.NewScheduler()
+	scheduler.Every("upkeep", upkeepInterval, upkeepService.SettleAll)
+	scheduler.Every("expire-inventory", time.Minute, func(ctx context.Context) error {
+		expired, err := inventoryService.RemoveExpired(ctx)
+		if expired > 0 {
+			log.Printf("Expired inventory removed: %d units", expired)
+		}
+		return err
+	})
+	scheduler.Every("contract-offers", contractInterval, contractService.OfferAll)
+	scheduler.Every("contract-deadlines", time.Minute, contractService.FailOverdue)
+	scheduler.Every("loans", time.Minute, loanService.SettleAll)
+	scheduler.Every("valuation-snapshots", valuationInterval, valuationService.SnapshotAll)
+	scheduler.Every("leaderboards", leaderboardInterval, leaderboardService.Refresh)
+	scheduler.Every("notifications", time.Minute, notificationService.NotifyDue)
+	scheduler.Every("prune-notifications", time.Hour, notificationService.Prune)
+	scheduler.Start(context.Background())
+	defer scheduler.Stop()
+	log.Printf("Building upkeep and wages (%d per worker) charged every %s", wage, upkeepInterval)
+	log.Printf("Contracts offered every %s", contractInterval)
+
+	// Scheduled jobs first run after one interval, so the leaderboards are
+	// computed once at startup to be readable right away
+	if err := leaderboardService.Refresh(context.Background()); err != nil {
+		log.Printf("Warning: failed to compute leaderboards: %v", err)
+	}
 
 	// Handler/Controller layer
 	authHandler := httpHandlers.NewAuthHandler(authService)
 	companyHandler := httpHandlers.NewCompanyHandler(companyService)
 	inventoryHandler := httpHandlers.NewInventoryHandler(inventoryService, companyRepo)
 	marketHandler := httpHandlers.NewMarketHandler(marketService, companyRepo)
-	productionHandler := httpHandlers.NewProductionHandler(productionService)
+	productionHandler := httpHandlers.NewProductionHandler(productionService, companyRepo)
+	buildingHandler := httpHandlers.NewBuildingHandler(buildingService, companyRepo)
+	ledgerHandler := httpHandlers.NewLedgerHandler(ledgerService, companyRepo)
+	workforceHandler := httpHandlers.NewWorkforceHandler(workforceService, companyRepo)
+	supplyLinkHandler := httpHandlers.NewSupplyLinkHandler(supplyLinkService, companyRepo)
+	researchHandler := httpHandlers.NewResearchHandler(researchService, companyRepo)
+	rushHandler := httpHandlers.NewRushHandler(rushService, companyRepo)
+	contractHandler := httpHandlers.NewContractHandler(contractService, companyRepo)
+	loanHandler := httpHandlers.NewLoanHandler(loanService, companyRepo)
+	valuationHandler := httpHandlers.NewValuationHandler(valuationService, companyRepo)
+	leaderboardHandler := httpHandlers.NewLeaderboardHandler(leaderboardService, companyRepo)
+	achievementHandler := httpHandlers.NewAchievementHandler(achievementService, companyRepo)
+	notificationHandler := httpHandlers.NewNotificationHandler(notificationService, companyRepo)
 
 	// Setup router
 	r := chi.NewRouter()
@@ -144,7 +526,9 @@ func main() {
 
 		// Public inventory routes
 		r.Get("/resources", inventoryHandler.GetResources)
-		r.Get("/production-buildings", productionHandler.GetProductionBuildings)
+		r.With(auth.OptionalAuth()).Get("/production-buildings", productionHandler.GetProductionBuildings)
+		r.Get("/production/processes/analytics", productionHandler.GetProcessAnalytics)
+		r.With(auth.OptionalAuth()).Get("/leaderboards/{board}", leaderboardHandler.GetLeaderboard)
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
@@ -155,13 +539,65 @@ func main() {
 			// Company routes
 			r.Post("/companies", companyHandler.CreateCompany)
 			r.Get("/companies/me", companyHandler.GetMyCompany)
+			r.Get("/companies/me/ledger", ledgerHandler.GetMyLedger)
+			r.Get("/companies/me/valuation", valuationHandler.GetMyValuation)
+			r.Get("/companies/me/valuation/history", valuationHandler.GetMyValuationHistory)
+			r.Get("/companies/me/achievements", achievementHandler.GetMyAchievements)
+			r.Get("/companies/me/grid", productionHandler.GetMyGrid)
+
+			// Workforce routes
+			r.Get("/companies/me/workers", workforceHandler.GetMyWorkers)
+			r.Post("/companies/me/workers/hire", workforceHandler.HireWorkers)
+			r.Post("/companies/me/workers/fire", workforceHandler.FireWorkers)
+
+			// Company building routes
+			r.Get("/companies/me/buildings", buildingHandler.GetMyBuildings)
+			r.Post("/companies/me/buildings", buildingHandler.BuyBuilding)
+			r.Get("/companies/me/buildings/{id}", buildingHandler.GetMyBuilding)
+			r.Delete("/companies/me/buildings/{id}", buildingHandler.SellBuilding)
+			r.Post("/companies/me/buildings/{id}/upgrade", buildingHandler.UpgradeBuilding)
+			r.Post("/companies/me/buildings/{id}/repair", buildingHandler.RepairBuilding)
+			r.Get("/companies/me/buildings/{id}/rush", rushHandler.QuoteConstruction)
+			r.Post("/companies/me/buildings/{id}/rush", rushHandler.RushConstruction)
+
+			// Production run routes
+			r.Get("/companies/me/buildings/{id}/runs", productionHandler.GetRuns)
+			r.Post("/companies/me/buildings/{id}/runs", productionHandler.StartRun)
+			r.Post("/companies/me/buildings/{id}/runs/{runID}/collect", productionHandler.CollectRun)
+			r.Get("/companies/me/buildings/{id}/runs/{runID}/rush", rushHandler.QuoteRun)
+			r.Post("/companies/me/buildings/{id}/runs/{runID}/rush", rushHandler.RushRun)
+
+			// Supply link routes
+			r.Get("/companies/me/links", supplyLinkHandler.GetMyLinks)
+			r.Post("/companies/me/links", supplyLinkHandler.CreateLink)
+			r.Delete("/companies/me/links/{id}", supplyLinkHandler.DeleteLink)
+
+			// Research routes
+			r.Get("/companies/me/research", researchHandler.GetMyResearch)
+			r.Post("/companies/me/research/{id}/start", researchHandler.StartResearch)
+
+			// NPC contracts
+			r.Get("/companies/me/contracts", contractHandler.GetMyContracts)
+			r.Post("/companies/me/contracts/{id}/accept", contractHandler.AcceptContract)
+			r.Post("/companies/me/contracts/{id}/deliver", contractHandler.DeliverContract)
+
+			// Bank loans
+			r.Get("/companies/me/loans", loanHandler.GetMyLoans)
+			r.Post("/companies/me/loans", loanHandler.TakeLoan)
+			r.Post("/companies/me/loans/{id}/repay", loanHandler.RepayLoan)
 
 			// Inventory routes
 			r.Get("/inventory", inventoryHandler.GetInventory)
+			r.Get("/inventory/storage", inventoryHandler.GetStorage)
 
 			// Market routes
 			r.Post("/market/buy", marketHandler.BuyResource)
 			r.Post("/market/sell", marketHandler.SellResource)
+
+			// Notification routes
+			r.Get("/notifications", notificationHandler.GetNotifications)
+			r.Post("/notifications/read-all", notificationHandler.MarkAllRead)
+			r.Post("/notifications/{id}/read", notificationHandler.MarkRead)
 		})
 	})
 
@@ -197,50 +633,20 @@ func main() {
 	}
 }
 
-type productionBuildingSeed struct {
-	ID        int64                   `json:"id"`
-	Name      string                  `json:"name"`
-	Cost      int64                   `json:"cost"`
-	Processes []productionProcessSeed `json:"processes"`
-}
-
-type productionProcessSeed struct {
-	ID               int64                     `json:"id"`
-	Name             string                    `json:"name"`
-	ProcessingTimeMs int64                     `json:"processing_time_ms"`
-	TimeWindow       *productionTimeWindowSeed `json:"time_window"`
-	Resources        []processResourceSeed     `json:"resources"`
-}
-
-type processResourceSeed struct {
-	ResourceID int64  `json:"resource_id"`
-	Direction  string `json:"direction"`
-	Quantity   int64  `json:"quantity"`
-}
-
-type productionTimeWindowSeed struct {
-	StartHour int64 `json:"start_hour"`
-	EndHour   int64 `json:"end_hour"`
-}
-
 func loadProductionBuildingsFromFile(
 	ctx context.Context,
 	buildingRepo repository.ProductionBuildingRepository,
 	processRepo repository.ProductionProcessRepository,
 	processResourceRepo repository.ProductionProcessResourceRepository,
+	levelRepo repository.ProductionBuildingLevelRepository,
 	resourceRepo repository.ResourceRepository,
 	path string,
 ) error {
-	data, err := os.ReadFile(path)
+	seeds, err := catalog.LoadBuildings(path)
 	if err != nil {
 		return err
 	}
 
-	var seeds []productionBuildingSeed
-	if err := json.Unmarshal(data, &seeds); err != nil {
-		return err
-	}
-
 	created := 0
 	updated := 0
 	processesCreated := 0
@@ -248,15 +654,47 @@ func loadProductionBuildingsFromFile(
 	processResourcesCreated := 0
 	processResourcesUpdated := 0
 	processResourcesDeleted := 0
+	levelsLoaded := 0
+	levelsDeleted := 0
 	for _, seed := range seeds {
 		if seed.ID <= 0 || seed.Name == "" {
 			continue
 		}
 
-		existing, err := buildingRepo.GetByID(ctx, seed.ID)
+		depreciation := seed.DepreciationOrDefault()
+		building := &db.ProductionBuilding{
+			ID:                         seed.ID,
+			Name:                       seed.Name,
+			Cost:                       seed.Cost,
+			Upkeep:                     seed.Upkeep,
+			StorageCapacity:            seed.StorageCapacity,
+			Slots:                      max(seed.SlotsOrDefault(), 1),
+			DepreciationCurve:          depreciation.Curve,
+			DepreciationInitialPercent: depreciation.InitialPercent,
+			DepreciationPercentPerDay:  depreciation.PercentPerDay,
+			DepreciationFloorPercent:   depreciation.FloorPercent,
+			ConstructionTimeMs:         max(seed.ConstructionTimeMs, 0),
+		}
+		var err error
+		building.ConstructionResources, err = seedBuildingResources(ctx, resourceRepo, seed.ConstructionResources)
+		if err != nil {
+			return err
+		}
+		if durability := seed.Durability; durability != nil && durability.Max > 0 {
+			building.MaxDurability = durability.Max
+			building.WearPerBatch = max(durability.WearPerBatchOrDefault(), 0)
+			building.BreakdownBelowPercent = min(max(durability.BreakdownBelowPercentOrDefault(), 0), 100)
+			building.RepairCost = max(durability.RepairCost, 0)
+			building.RepairResources, err = seedBuildingResources(ctx, resourceRepo, durability.RepairResources)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = buildingRepo.GetByID(ctx, seed.ID)
 		if err != nil {
 			if err == repository.ErrProductionBuildingNotFound {
-				if _, err := buildingRepo.Create(ctx, seed.ID, seed.Name, seed.Cost); err != nil {
+				if _, err := buildingRepo.Create(ctx, building); err != nil {
 					return err
 				}
 				created++
@@ -264,45 +702,48 @@ func loadProductionBuildingsFromFile(
 				return err
 			}
 		} else {
-			if _, err := buildingRepo.Update(ctx, existing.ID, seed.Name, seed.Cost); err != nil {
+			if _, err := buildingRepo.Update(ctx, building); err != nil {
 				return err
 			}
 			updated++
 		}
 
+		loaded, deleted, err := loadBuildingLevels(ctx, levelRepo, resourceRepo, seed)
+		if err != nil {
+			return err
+		}
+		levelsLoaded += loaded
+		levelsDeleted += deleted
+
 		for _, processSeed := range seed.Processes {
-			if processSeed.ID <= 0 || processSeed.Name == "" || processSeed.ProcessingTimeMs <= 0 {
+			if processSeed.ID <= 0 || processSeed.Name == "" || processSeed.ProcessingTimeMs <= 0 || processSeed.Workers < 0 {
 				continue
 			}
 
 			var windowStartHour *int64
 			var windowEndHour *int64
 			if processSeed.TimeWindow != nil {
-				if processSeed.TimeWindow.StartHour < 0 || processSeed.TimeWindow.StartHour > 23 {
-					continue
-				}
-				if processSeed.TimeWindow.EndHour < 0 || processSeed.TimeWindow.EndHour > 23 {
-					continue
-				}
-				if processSeed.TimeWindow.StartHour >= processSeed.TimeWindow.EndHour {
+				if !processSeed.TimeWindow.Valid() {
 					continue
 				}
 				windowStartHour = &processSeed.TimeWindow.StartHour
 				windowEndHour = &processSeed.TimeWindow.EndHour
 			}
 
-			existingProcess, err := processRepo.GetByID(ctx, processSeed.ID)
+			process := &db.ProductionProcess{
+				ID:               processSeed.ID,
+				Name:             processSeed.Name,
+				ProcessingTimeMs: processSeed.ProcessingTimeMs,
+				BuildingID:       seed.ID,
+				WindowStartHour:  windowStartHour,
+				WindowEndHour:    windowEndHour,
+				Workers:          processSeed.Workers,
+			}
+
+			_, err := processRepo.GetByID(ctx, processSeed.ID)
 			if err != nil {
 				if err == repository.ErrProductionProcessNotFound {
-					if _, err := processRepo.Create(
-						ctx,
-						processSeed.ID,
-						processSeed.Name,
-						processSeed.ProcessingTimeMs,
-						seed.ID,
-						windowStartHour,
-						windowEndHour,
-					); err != nil {
+					if _, err := processRepo.Create(ctx, process); err != nil {
 						return err
 					}
 					processesCreated++
@@ -310,15 +751,7 @@ func loadProductionBuildingsFromFile(
 					return err
 				}
 			} else {
-				if _, err := processRepo.Update(
-					ctx,
-					existingProcess.ID,
-					processSeed.Name,
-					processSeed.ProcessingTimeMs,
-					seed.ID,
-					windowStartHour,
-					windowEndHour,
-				); err != nil {
+				if _, err := processRepo.Update(ctx, process); err != nil {
 					return err
 				}
 				processesUpdated++
@@ -340,7 +773,7 @@ func loadProductionBuildingsFromFile(
 				if resourceSeed.ResourceID <= 0 || resourceSeed.Quantity <= 0 {
 					continue
 				}
-				if resourceSeed.Direction != "input" && resourceSeed.Direction != "output" {
+				if resourceSeed.Direction != catalog.DirectionInput && resourceSeed.Direction != catalog.DirectionOutput {
 					continue
 				}
 
@@ -351,22 +784,26 @@ func loadProductionBuildingsFromFile(
 					return err
 				}
 
+				processResource := &db.ProductionProcessResource{
+					ProcessID:   processSeed.ID,
+					ResourceID:  resourceSeed.ResourceID,
+					Direction:   resourceSeed.Direction,
+					Quantity:    resourceSeed.Quantity,
+					Group:       resourceSeed.Group,
+					MaxQuantity: resourceSeed.MaxQuantity,
+					Chance:      resourceSeed.ChanceOrDefault(),
+				}
+
 				key := fmt.Sprintf("%d|%s", resourceSeed.ResourceID, resourceSeed.Direction)
 				if existing, ok := existingByKey[key]; ok {
-					if existing.Quantity != resourceSeed.Quantity {
+					if existing != *processResource {
 						processResourcesUpdated++
 					}
 				} else {
 					processResourcesCreated++
 				}
 
-				if err := processResourceRepo.Upsert(
-					ctx,
-					processSeed.ID,
-					resourceSeed.ResourceID,
-					resourceSeed.Direction,
-					resourceSeed.Quantity,
-				); err != nil {
+				if err := processResourceRepo.Upsert(ctx, processResource); err != nil {
 					return err
 				}
 				seen[key] = struct{}{}
@@ -412,25 +849,439 @@ func loadProductionBuildingsFromFile(
 	if processResourcesDeleted > 0 {
 		log.Printf("Production process resources removed: %d", processResourcesDeleted)
 	}
+	if levelsLoaded > 0 {
+		log.Printf("Production building levels loaded: %d", levelsLoaded)
+	}
+	if levelsDeleted > 0 {
+		log.Printf("Production building levels removed: %d", levelsDeleted)
+	}
 
 	return nil
 }
 
-type resourceSeed struct {
-	ID       int64  `json:"id"`
-	Name     string `json:"name"`
-	Price    int64  `json:"price"`
-	PackSize int64  `json:"pack_size"`
+// loadBuildingLevels upserts the levels of a building and removes levels
+// that are no longer in the seed file.
+func loadBuildingLevels(
+	ctx context.Context,
+	levelRepo repository.ProductionBuildingLevelRepository,
+	resourceRepo repository.ResourceRepository,
+	seed catalog.Building,
+) (int, int, error) {
+	existingLevels, err := levelRepo.GetAllByBuilding(ctx, seed.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	loaded := 0
+	seen := make(map[int64]struct{}, len(seed.Levels))
+	for _, levelSeed := range seed.Levels {
+		if levelSeed.Level <= 0 || levelSeed.UpgradeCost < 0 || levelSeed.UpgradeTimeMs < 0 {
+			continue
+		}
+
+		level := db.ProductionBuildingLevel{
+			BuildingID:    seed.ID,
+			Level:         levelSeed.Level,
+			UpgradeCost:   levelSeed.UpgradeCost,
+			UpgradeTimeMs: levelSeed.UpgradeTimeMs,
+			SpeedPercent:  levelSeed.SpeedPercent,
+			OutputPercent: levelSeed.OutputPercent,
+			MaxBatches:    levelSeed.MaxBatches,
+			Slots:         max(levelSeed.Slots, 0),
+		}
+		if level.SpeedPercent <= 0 {
+			level.SpeedPercent = 100
+		}
+		if level.OutputPercent <= 0 {
+			level.OutputPercent = 100
+		}
+		if level.MaxBatches < 0 {
+			level.MaxBatches = 0
+		}
+
+		for _, resourceSeed := range levelSeed.UpgradeResources {
+			if resourceSeed.Quantity <= 0 {
+				continue
+			}
+			if _, err := resourceRepo.GetByID(ctx, resourceSeed.ResourceID); err != nil {
+				if err == repository.ErrResourceNotFound {
+					continue
+				}
+				return 0, 0, err
+			}
+			level.Resources = append(level.Resources, db.ProductionBuildingLevelResource{
+				ResourceID: resourceSeed.ResourceID,
+				Quantity:   resourceSeed.Quantity,
+			})
+		}
+
+		if err := levelRepo.Upsert(ctx, level); err != nil {
+			return 0, 0, err
+		}
+		seen[level.Level] = struct{}{}
+		loaded++
+	}
+
+	deleted := 0
+	for _, existing := range existingLevels {
+		if _, ok := seen[existing.Level]; ok {
+			continue
+		}
+		if err := levelRepo.Delete(ctx, existing.BuildingID, existing.Level); err != nil {
+			return 0, 0, err
+		}
+		deleted++
+	}
+
+	return loaded, deleted, nil
 }
 
-func loadResourcesFromFile(ctx context.Context, repo repository.ResourceRepository, path string) error {
-	data, err := os.ReadFile(path)
+// seedBuildingResources converts the construction or repair resources of a
+// building seed, skipping empty entries and unknown resources.
+func seedBuildingResources(
+	ctx context.Context,
+	resourceRepo repository.ResourceRepository,
+	seeds []catalog.LevelResource,
+) ([]db.ResourceQuantity, error) {
+	var resources []db.ResourceQuantity
+	for _, resourceSeed := range seeds {
+		if resourceSeed.Quantity <= 0 {
+			continue
+		}
+		if _, err := resourceRepo.GetByID(ctx, resourceSeed.ResourceID); err != nil {
+			if err == repository.ErrResourceNotFound {
+				continue
+			}
+			return nil, err
+		}
+		resources = append(resources, db.ResourceQuantity{
+			ResourceID: resourceSeed.ResourceID,
+			Quantity:   resourceSeed.Quantity,
+		})
+	}
+	return resources, nil
+}
+
+// loadResearchFromFile upserts the research nodes of the seed file and
+// deletes the ones that are no longer in it.
+func loadResearchFromFile(
+	ctx context.Context,
+	researchRepo repository.ResearchRepository,
+	resourceRepo repository.ResourceRepository,
+	path string,
+) error {
+	seeds, err := catalog.LoadResearch(path)
+	if err != nil {
+		return err
+	}
+
+	existing, err := researchRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := 0
+	seen := make(map[int64]struct{}, len(seeds))
+	for _, seed := range seeds {
+		if seed.ID <= 0 || seed.Name == "" || seed.Cost < 0 || seed.DurationMs < 0 {
+			continue
+		}
+
+		node := db.ResearchNode{
+			ID:              seed.ID,
+			Name:            seed.Name,
+			Cost:            seed.Cost,
+			DurationMs:      seed.DurationMs,
+			Prerequisites:   seed.Prerequisites,
+			UnlockBuildings: seed.Unlocks.Buildings,
+			UnlockProcesses: seed.Unlocks.Processes,
+		}
+
+		for _, resourceSeed := range seed.Resources {
+			if resourceSeed.Quantity <= 0 {
+				continue
+			}
+			if _, err := resourceRepo.GetByID(ctx, resourceSeed.ResourceID); err != nil {
+				if err == repository.ErrResourceNotFound {
+					continue
+				}
+				return err
+			}
+			node.Resources = append(node.Resources, db.ResourceQuantity{
+				ResourceID: resourceSeed.ResourceID,
+				Quantity:   resourceSeed.Quantity,
+			})
+		}
+
+		if err := researchRepo.Upsert(ctx, node); err != nil {
+			return err
+		}
+		seen[node.ID] = struct{}{}
+		loaded++
+	}
+
+	deleted := 0
+	for _, node := range existing {
+		if _, ok := seen[node.ID]; ok {
+			continue
+		}
+		if err := researchRepo.Delete(ctx, node.ID); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	if loaded > 0 {
+		log.Printf("Research nodes loaded: %d", loaded)
+	}
+	if deleted > 0 {
+		log.Printf("Research nodes removed: %d", deleted)
+	}
+
+	return nil
+}
+
+func loadContractsFromFile(
+	ctx context.Context,
+	templateRepo repository.ContractTemplateRepository,
+	resourceRepo repository.ResourceRepository,
+	path string,
+) error {
+	seeds, err := catalog.LoadContracts(path)
+	if err != nil {
+		return err
+	}
+
+	existing, err := templateRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := 0
+	seen := make(map[int64]struct{}, len(seeds))
+	for _, seed := range seeds {
+		if seed.ID <= 0 || seed.Client == "" || seed.Quantity <= 0 || seed.DurationMs <= 0 {
+			continue
+		}
+		if seed.Reward < 0 || seed.Penalty < 0 || seed.OfferDurationMs < 0 || seed.MinBuildings < 0 || seed.ScalePerBuilding < 0 {
+			continue
+		}
+
+		resource, err := resourceRepo.GetByID(ctx, seed.ResourceID)
+		if err != nil {
+			if err == repository.ErrResourceNotFound {
+				continue
+			}
+			return err
+		}
+		if resource.Type == db.ResourceTypeFlow {
+			continue
+		}
+
+		template := db.ContractTemplate{
+			ID:               seed.ID,
+			Client:           seed.Client,
+			ResourceID:       seed.ResourceID,
+			Quantity:         seed.Quantity,
+			Reward:           seed.Reward,
+			Penalty:          seed.Penalty,
+			DurationMs:       seed.DurationMs,
+			OfferDurationMs:  seed.OfferDurationOrDefault(),
+			MinBuildings:     seed.MinBuildings,
+			ScalePerBuilding: seed.ScalePerBuilding,
+		}
+		if err := templateRepo.Upsert(ctx, template); err != nil {
+			return err
+		}
+		seen[template.ID] = struct{}{}
+		loaded++
+	}
+
+	deleted := 0
+	for _, template := range existing {
+		if _, ok := seen[template.ID]; ok {
+			continue
+		}
+		if err := templateRepo.Delete(ctx, template.ID); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	if loaded > 0 {
+		log.Printf("Contract templates loaded: %d", loaded)
+	}
+	if deleted > 0 {
+		log.Printf("Contract templates removed: %d", deleted)
+	}
+
+	return nil
+}
+
+// registerSubscribers connects the services that react to game events.
+// Achievements, statistics and notifications don't need to be up to date
+// when the request that caused them returns, so they are delivered
+// asynchronously.
+func registerSubscribers(
+	bus *events.Bus,
+	achievementService service.AchievementService,
+	leaderboardService service.LeaderboardService,
+	notificationService service.NotificationService,
+) {
+	events.Subscribe(bus, "achievements", events.Async, func(ctx context.Context, e events.BuildingPurchased) error {
+		return achievementService.Record(ctx, e.CompanyID, service.AchievementEvent{Type: db.EventBuildingPurchased, Amount: 1})
+	})
+	events.Subscribe(bus, "achievements", events.Async, func(ctx context.Context, e events.RunCollected) error {
+		for _, output := range e.Outputs {
+			event := service.AchievementEvent{Type: db.EventResourceProduced, ResourceID: output.ResourceID, Amount: output.Quantity}
+			if err := achievementService.Record(ctx, e.CompanyID, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	events.Subscribe(bus, "achievements", events.Async, func(ctx context.Context, e events.TradeExecuted) error {
+		if e.Side != events.SideSell {
+			return nil
+		}
+		sold := service.AchievementEvent{Type: db.EventResourceSold, ResourceID: e.ResourceID, Amount: e.Units}
+		if err := achievementService.Record(ctx, e.CompanyID, sold); err != nil {
+			return err
+		}
+		return achievementService.Record(ctx, e.CompanyID, service.AchievementEvent{Type: db.EventMoneyEarned, Amount: e.Amount})
+	})
+	events.Subscribe(bus, "achievements", events.Async, func(ctx context.Context, e events.ContractCompleted) error {
+		completed := service.AchievementEvent{Type: db.EventContractCompleted, Amount: 1}
+		if err := achievementService.Record(ctx, e.CompanyID, completed); err != nil {
+			return err
+		}
+		return achievementService.Record(ctx, e.CompanyID, service.AchievementEvent{Type: db.EventMoneyEarned, Amount: e.Reward})
+	})
+
+	events.Subscribe(bus, "leaderboards", events.Async, func(ctx context.Context, e events.RunCollected) error {
+		outputs := make([]db.ResourceQuantity, 0, len(e.Outputs))
+		for _, output := range e.Outputs {
+			outputs = append(outputs, db.ResourceQuantity{ResourceID: output.ResourceID, Quality: output.Quality, Quantity: output.Quantity})
+		}
+		return leaderboardService.RecordProduction(ctx, e.CompanyID, outputs, e.FinishedAt)
+	})
+
+	events.Subscribe(bus, "notifications", events.Async, func(ctx context.Context, e events.TradeExecuted) error {
+		return notificationService.NotifyOrderFilled(ctx, e.CompanyID, e.Side, e.ResourceID, e.Packs, e.Amount)
+	})
+	events.Subscribe(bus, "notifications", events.Async, func(ctx context.Context, e events.RunCollected) error {
+		if !e.BrokeDown {
+			return nil
+		}
+		return notificationService.NotifyBuildingBroken(ctx, e.CompanyID, e.CompanyBuildingID)
+	})
+}
+
+func loadAchievementsFromFile(
+	ctx context.Context,
+	achievementRepo repository.AchievementRepository,
+	resourceRepo repository.ResourceRepository,
+	path string,
+) error {
+	seeds, err := catalog.LoadAchievements(path)
 	if err != nil {
 		return err
 	}
 
-	var seeds []resourceSeed
-	if err := json.Unmarshal(data, &seeds); err != nil {
+	existing, err := achievementRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := 0
+	seen := make(map[int64]struct{}, len(seeds))
+	for _, seed := range seeds {
+		if seed.ID <= 0 || seed.Name == "" || seed.Threshold <= 0 || seed.Reward.Money < 0 {
+			continue
+		}
+		switch seed.Condition {
+		case db.ConditionCount, db.ConditionSum, db.ConditionMax:
+		default:
+			continue
+		}
+
+		achievement := db.Achievement{
+			ID:          seed.ID,
+			Name:        seed.Name,
+			Description: seed.Description,
+			Event:       seed.Event,
+			Condition:   seed.Condition,
+			Threshold:   seed.Threshold,
+			RewardMoney: seed.Reward.Money,
+		}
+
+		switch seed.Event {
+		case db.EventResourceProduced, db.EventResourceSold:
+			if seed.ResourceID != 0 {
+				if _, err := resourceRepo.GetByID(ctx, seed.ResourceID); err != nil {
+					if err == repository.ErrResourceNotFound {
+						continue
+					}
+					return err
+				}
+				achievement.ResourceID = seed.ResourceID
+			}
+		case db.EventBuildingPurchased, db.EventMoneyEarned, db.EventContractCompleted:
+		default:
+			continue
+		}
+
+		for _, resourceSeed := range seed.Reward.Resources {
+			if resourceSeed.Quantity <= 0 {
+				continue
+			}
+			resource, err := resourceRepo.GetByID(ctx, resourceSeed.ResourceID)
+			if err != nil {
+				if err == repository.ErrResourceNotFound {
+					continue
+				}
+				return err
+			}
+			if resource.Type == db.ResourceTypeFlow {
+				continue
+			}
+			achievement.RewardResources = append(achievement.RewardResources, db.ResourceQuantity{
+				ResourceID: resourceSeed.ResourceID,
+				Quantity:   resourceSeed.Quantity,
+			})
+		}
+
+		if err := achievementRepo.Upsert(ctx, achievement); err != nil {
+			return err
+		}
+		seen[achievement.ID] = struct{}{}
+		loaded++
+	}
+
+	deleted := 0
+	for _, achievement := range existing {
+		if _, ok := seen[achievement.ID]; ok {
+			continue
+		}
+		if err := achievementRepo.Delete(ctx, achievement.ID); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	if loaded > 0 {
+		log.Printf("Achievements loaded: %d", loaded)
+	}
+	if deleted > 0 {
+		log.Printf("Achievements removed: %d", deleted)
+	}
+
+	return nil
+}
+
+func loadResourcesFromFile(ctx context.Context, repo repository.ResourceRepository, path string) error {
+	seeds, err := catalog.LoadResources(path)
+	if err != nil {
 		return err
 	}
 
@@ -444,10 +1295,20 @@ func loadResourcesFromFile(ctx context.Context, repo repository.ResourceReposito
 			seed.PackSize = 1
 		}
 
-		existing, err := repo.GetByID(ctx, seed.ID)
+		resource := &db.Resource{
+			ID:          seed.ID,
+			Name:        seed.Name,
+			Type:        seed.TypeOrDefault(),
+			Price:       seed.Price,
+			PackSize:    seed.PackSize,
+			ShelfLifeMs: seed.ShelfLifeMs,
+			Volume:      seed.VolumeOrDefault(),
+		}
+
+		_, err := repo.GetByID(ctx, seed.ID)
 		if err != nil {
 			if err == repository.ErrResourceNotFound {
-				if _, err := repo.Create(ctx, seed.ID, seed.Name, seed.Price, seed.PackSize); err != nil {
+				if _, err := repo.Create(ctx, resource); err != nil {
 					return err
 				}
 				created++
@@ -456,7 +1317,7 @@ func loadResourcesFromFile(ctx context.Context, repo repository.ResourceReposito
 			return err
 		}
 
-		if _, err := repo.Update(ctx, existing.ID, seed.Name, seed.Price, seed.PackSize); err != nil {
+		if _, err := repo.Update(ctx, resource); err != nil {
 			return err
 		}
 		updated++
@@ -471,3 +1332,64 @@ func loadResourcesFromFile(ctx context.Context, repo repository.ResourceReposito
 
 	return nil
 }
+
+// runCommand executes a CLI subcommand and returns the process exit code.
+func runCommand(args []string, resourcesFile, buildingsFile, researchFile, contractsFile, achievementsFile string) int {
+	if len(args) == 2 && args[0] == "catalog" && args[1] == "lint" {
+		return runCatalogLint(resourcesFile, buildingsFile, researchFile, contractsFile, achievementsFile)
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command: %v\n", args)
+	fmt.Fprintln(os.Stderr, "available commands:")
+	fmt.Fprintln(os.Stderr, "  catalog lint    validate the resources, production buildings, research, contract and achievement files")
+	return 2
+}
+
+// runCatalogLint prints every catalog issue and fails if there are errors.
+func runCatalogLint(resourcesFile, buildingsFile, researchFile, contractsFile, achievementsFile string) int {
+	c, err := catalog.Load(resourcesFile, buildingsFile, researchFile, contractsFile, achievementsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load catalog: %v\n", err)
+		return 1
+	}
+
+	report := catalog.Validate(c)
+	for _, msg := range report.Errors {
+		fmt.Printf("error: %s\n", msg)
+	}
+	for _, msg := range report.Warnings {
+		fmt.Printf("warning: %s\n", msg)
+	}
+	fmt.Printf("%d error(s), %d warning(s)\n", len(report.Errors), len(report.Warnings))
+
+	if report.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+// checkCatalog validates the catalog before seeding. Issues are logged so
+// rows skipped by the seed loaders don't go unnoticed; in strict mode any
+// error stops the server.
+func checkCatalog(resourcesFile, buildingsFile, researchFile, contractsFile, achievementsFile string, strict bool) {
+	c, err := catalog.Load(resourcesFile, buildingsFile, researchFile, contractsFile, achievementsFile)
+	if err != nil {
+		if strict {
+			log.Fatalf("Failed to load catalog: %v", err)
+		}
+		log.Printf("Warning: failed to load catalog for validation: %v", err)
+		return
+	}
+
+	report := catalog.Validate(c)
+	for _, msg := range report.Errors {
+		log.Printf("Catalog error: %s", msg)
+	}
+	for _, msg := range report.Warnings {
+		log.Printf("Catalog warning: %s", msg)
+	}
+
+	if strict && report.HasErrors() {
+		log.Fatalf("Catalog has %d error(s), refusing to start (strict catalog mode)", len(report.Errors))
+	}
+}
@@ -0,0 +1,430 @@
+package catalog
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Direction values for process resources.
+const (
+	DirectionInput  = "input"
+	DirectionOutput = "output"
+)
+
+// Resource types.
+const (
+	ResourceTypeItem = "item"
+	ResourceTypeFlow = "flow"
+)
+
+// Depreciation curves for building resale values.
+const (
+	CurveLinear      = "linear"
+	CurveExponential = "exponential"
+)
+
+// Achievement event types.
+const (
+	EventBuildingPurchased = "building_purchased"
+	EventResourceProduced  = "resource_produced"
+	EventResourceSold      = "resource_sold"
+	EventMoneyEarned       = "money_earned"
+	EventContractCompleted = "contract_completed"
+)
+
+// Achievement conditions, how the events of an achievement add up.
+const (
+	ConditionCount = "count"
+	ConditionSum   = "sum"
+	ConditionMax   = "max"
+)
+
+// Catalog holds the game data loaded from the JSON seed files.
+type Catalog struct {
+	Resources    []Resource
+	Buildings    []Building
+	Research     []ResearchNode
+	Contracts    []ContractTemplate
+	Achievements []Achievement
+}
+
+// Resource is a resource entry from resources.json.
+type Resource struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"` // "item" when omitted, or "flow"
+	Price       int64  `json:"price"`
+	PackSize    int64  `json:"pack_size"`
+	ShelfLifeMs int64  `json:"shelf_life_ms"` // Omitted or 0 = never expires
+	Volume      *int64 `json:"volume"`        // Storage used per unit, 1 when omitted
+}
+
+// Building is a production building entry from production_buildings.json.
+// Bought buildings can't be used until their construction time has passed,
+// and then run as many processes at once as they have slots.
+type Building struct {
+	ID                    int64           `json:"id"`
+	Name                  string          `json:"name"`
+	Cost                  int64           `json:"cost"`
+	Upkeep                int64           `json:"upkeep"`           // Charged every upkeep period
+	StorageCapacity       int64           `json:"storage_capacity"` // Storage added to the owner (warehouses)
+	Slots                 int64           `json:"slots"`            // Concurrent runs, 1 when omitted
+	ConstructionTimeMs    int64           `json:"construction_time_ms"`
+	ConstructionResources []LevelResource `json:"construction_resources"` // Consumed when buying
+	Durability            *Durability     `json:"durability"`             // Omitted = never wears
+	Depreciation          *Depreciation   `json:"depreciation"`
+	Levels                []Level         `json:"levels"`
+	Processes             []Process       `json:"processes"`
+}
+
+// Durability describes how a building wears down with use. Every batch run
+// costs WearPerBatch points; below BreakdownBelowPercent of Max each run may
+// break the building down, more likely the lower it gets, until repaired.
+// Repairs cost RepairCost per point and the RepairResources of a full
+// repair, scaled to the points repaired.
+type Durability struct {
+	Max                   int64           `json:"max"`
+	WearPerBatch          int64           `json:"wear_per_batch"`          // 1 when omitted
+	BreakdownBelowPercent *int64          `json:"breakdown_below_percent"` // 50 when omitted
+	RepairCost            int64           `json:"repair_cost"`             // Per point
+	RepairResources       []LevelResource `json:"repair_resources"`
+}
+
+// SlotsOrDefault returns the concurrent runs of the building, 1 when not set.
+func (b Building) SlotsOrDefault() int64 {
+	if b.Slots == 0 {
+		return 1
+	}
+	return b.Slots
+}
+
+// WearPerBatchOrDefault returns the points lost per batch, 1 when not set.
+func (d Durability) WearPerBatchOrDefault() int64 {
+	if d.WearPerBatch == 0 {
+		return 1
+	}
+	return d.WearPerBatch
+}
+
+// BreakdownBelowPercentOrDefault returns the durability percent under which
+// runs may break the building down, 50 when not set.
+func (d Durability) BreakdownBelowPercentOrDefault() int64 {
+	if d.BreakdownBelowPercent == nil {
+		return 50
+	}
+	return *d.BreakdownBelowPercent
+}
+
+// Depreciation describes how much of its cost a building returns when sold.
+// The value starts at InitialPercent and loses PercentPerDay each day of age,
+// either as a flat amount (linear) or compounded (exponential), never going
+// below FloorPercent.
+type Depreciation struct {
+	Curve          string  `json:"curve"`
+	InitialPercent float64 `json:"initial_percent"`
+	PercentPerDay  float64 `json:"percent_per_day"`
+	FloorPercent   float64 `json:"floor_percent"`
+}
+
+// DefaultDepreciation applies to buildings without a depreciation entry.
+var DefaultDepreciation = Depreciation{
+	Curve:          CurveLinear,
+	InitialPercent: 80,
+	PercentPerDay:  2,
+	FloorPercent:   20,
+}
+
+// DepreciationOrDefault returns the building depreciation, filling an empty
+// curve with linear and a zero initial percent with 100.
+func (b Building) DepreciationOrDefault() Depreciation {
+	if b.Depreciation == nil {
+		return DefaultDepreciation
+	}
+
+	depreciation := *b.Depreciation
+	if depreciation.Curve == "" {
+		depreciation.Curve = CurveLinear
+	}
+	if depreciation.InitialPercent == 0 {
+		depreciation.InitialPercent = 100
+	}
+	return depreciation
+}
+
+// Level describes the upgrade to a building level and the bonuses it gives.
+// Level 1 is the purchased building and may be listed to set its base
+// batch capacity. Percentages default to 100 when omitted.
+type Level struct {
+	Level            int64           `json:"level"`
+	UpgradeCost      int64           `json:"upgrade_cost"`
+	UpgradeTimeMs    int64           `json:"upgrade_time_ms"`
+	UpgradeResources []LevelResource `json:"upgrade_resources"`
+	SpeedPercent     int64           `json:"speed_percent"`
+	OutputPercent    int64           `json:"output_percent"`
+	MaxBatches       int64           `json:"max_batches"` // 0 = unlimited
+	Slots            int64           `json:"slots"`       // 0 = the building's slots
+}
+
+// LevelResource is a resource consumed when upgrading to a level.
+type LevelResource struct {
+	ResourceID int64 `json:"resource_id"`
+	Quantity   int64 `json:"quantity"`
+}
+
+// Process is a production process declared inside a building.
+type Process struct {
+	ID               int64             `json:"id"`
+	Name             string            `json:"name"`
+	ProcessingTimeMs int64             `json:"processing_time_ms"`
+	TimeWindow       *TimeWindow       `json:"time_window"`
+	Workers          int64             `json:"workers"` // Needed to run at full speed
+	Resources        []ProcessResource `json:"resources"`
+}
+
+// ProcessResource is an input or output of a process. Item inputs sharing a
+// group are alternatives and a run takes only one of them. Item outputs may
+// be random: each batch yields between Quantity and MaxQuantity units with
+// the given percent chance.
+type ProcessResource struct {
+	ResourceID  int64  `json:"resource_id"`
+	Direction   string `json:"direction"`
+	Quantity    int64  `json:"quantity"`
+	Group       int64  `json:"group"`        // Omitted or 0 = always needed
+	MaxQuantity int64  `json:"max_quantity"` // Omitted or 0 = always Quantity
+	Chance      *int64 `json:"chance"`       // Percent, 100 when omitted
+}
+
+// TimeWindow limits the hours of the day in which a process runs.
+type TimeWindow struct {
+	StartHour int64 `json:"start_hour"`
+	EndHour   int64 `json:"end_hour"`
+}
+
+// ResearchNode is a tech node from research.json. Buildings and processes
+// listed in any node's unlocks stay locked for a company until it completes
+// one of the nodes that unlock them.
+type ResearchNode struct {
+	ID            int64              `json:"id"`
+	Name          string             `json:"name"`
+	Prerequisites []int64            `json:"prerequisites"` // Nodes that must be completed first
+	Cost          int64              `json:"cost"`
+	Resources     []ResearchResource `json:"resources"`
+	DurationMs    int64              `json:"duration_ms"`
+	Unlocks       ResearchUnlocks    `json:"unlocks"`
+}
+
+// ResearchResource is a resource consumed when starting a research node.
+type ResearchResource struct {
+	ResourceID int64 `json:"resource_id"`
+	Quantity   int64 `json:"quantity"`
+}
+
+// ResearchUnlocks lists the production buildings and processes a research
+// node unlocks.
+type ResearchUnlocks struct {
+	Buildings []int64 `json:"buildings"`
+	Processes []int64 `json:"processes"`
+}
+
+// ContractTemplate is an NPC order from contracts.json. Offers made from it
+// ask for Quantity units of the resource, delivered within DurationMs of
+// accepting, for Reward; contracts not delivered in time cost the Penalty.
+// Quantity, reward and penalty grow by ScalePerBuilding of their base value
+// for every building the company owns, and templates are only offered to
+// companies owning at least MinBuildings.
+type ContractTemplate struct {
+	ID               int64   `json:"id"`
+	Client           string  `json:"client"`
+	ResourceID       int64   `json:"resource_id"`
+	Quantity         int64   `json:"quantity"`
+	Reward           int64   `json:"reward"`
+	Penalty          int64   `json:"penalty"`
+	DurationMs       int64   `json:"duration_ms"`
+	OfferDurationMs  int64   `json:"offer_duration_ms"` // How long the offer can be accepted, duration_ms when omitted
+	MinBuildings     int64   `json:"min_buildings"`
+	ScalePerBuilding float64 `json:"scale_per_building"`
+}
+
+// Achievement is a milestone from achievements.json. Every event of its type
+// (narrowed to ResourceID when set) adds to the company's progress as the
+// condition says: count adds one, sum adds the event amount and max keeps
+// the largest amount. Reaching Threshold completes it and pays the reward.
+type Achievement struct {
+	ID          int64             `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Event       string            `json:"event"`
+	ResourceID  int64             `json:"resource_id"` // 0 = any resource
+	Condition   string            `json:"condition"`
+	Threshold   int64             `json:"threshold"`
+	Reward      AchievementReward `json:"reward"`
+}
+
+// AchievementReward is what completing an achievement pays.
+type AchievementReward struct {
+	Money     int64                 `json:"money"`
+	Resources []AchievementResource `json:"resources"`
+}
+
+// AchievementResource is a resource given as an achievement reward, at
+// standard quality.
+type AchievementResource struct {
+	ResourceID int64 `json:"resource_id"`
+	Quantity   int64 `json:"quantity"`
+}
+
+// Load reads the catalog files.
+func Load(resourcesPath, buildingsPath, researchPath, contractsPath, achievementsPath string) (*Catalog, error) {
+	resources, err := LoadResources(resourcesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	buildings, err := LoadBuildings(buildingsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	research, err := LoadResearch(researchPath)
+	if err != nil {
+		return nil, err
+	}
+
+	contracts, err := LoadContracts(contractsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	achievements, err := LoadAchievements(achievementsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Catalog{
+		Resources:    resources,
+		Buildings:    buildings,
+		Research:     research,
+		Contracts:    contracts,
+		Achievements: achievements,
+	}, nil
+}
+
+// LoadResources reads the resources JSON file.
+func LoadResources(path string) ([]Resource, error) {
+	var resources []Resource
+	if err := readJSON(path, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// LoadBuildings reads the production buildings JSON file.
+func LoadBuildings(path string) ([]Building, error) {
+	var buildings []Building
+	if err := readJSON(path, &buildings); err != nil {
+		return nil, err
+	}
+	return buildings, nil
+}
+
+// LoadResearch reads the research JSON file.
+func LoadResearch(path string) ([]ResearchNode, error) {
+	var nodes []ResearchNode
+	if err := readJSON(path, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// LoadContracts reads the contract templates JSON file.
+func LoadContracts(path string) ([]ContractTemplate, error) {
+	var templates []ContractTemplate
+	if err := readJSON(path, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// LoadAchievements reads the achievements JSON file.
+func LoadAchievements(path string) ([]Achievement, error) {
+	var achievements []Achievement
+	if err := readJSON(path, &achievements); err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// TypeOrDefault returns the resource type, item when not set.
+func (r Resource) TypeOrDefault() string {
+	if r.Type == "" {
+		return ResourceTypeItem
+	}
+	return r.Type
+}
+
+// VolumeOrDefault returns the storage used per unit, 1 when not set.
+func (r Resource) VolumeOrDefault() int64 {
+	if r.Volume == nil {
+		return 1
+	}
+	return *r.Volume
+}
+
+// MaxQuantityOrDefault returns the largest yield per batch, Quantity when
+// not set.
+func (r ProcessResource) MaxQuantityOrDefault() int64 {
+	if r.MaxQuantity == 0 {
+		return r.Quantity
+	}
+	return r.MaxQuantity
+}
+
+// ChanceOrDefault returns the percent chance of the yield, 100 when not set.
+func (r ProcessResource) ChanceOrDefault() int64 {
+	if r.Chance == nil {
+		return 100
+	}
+	return *r.Chance
+}
+
+// ExpectedQuantity returns the average yield per batch.
+func (r ProcessResource) ExpectedQuantity() float64 {
+	return float64(r.Quantity+r.MaxQuantityOrDefault()) / 2 * float64(r.ChanceOrDefault()) / 100
+}
+
+// UnitPrice returns the market price of a single unit in thousandths.
+func (r Resource) UnitPrice() float64 {
+	packSize := r.PackSize
+	if packSize <= 0 {
+		packSize = 1
+	}
+	return float64(r.Price) / float64(packSize)
+}
+
+// Valid reports whether the window hours are in range and ordered.
+func (w TimeWindow) Valid() bool {
+	if w.StartHour < 0 || w.StartHour > 23 {
+		return false
+	}
+	if w.EndHour < 0 || w.EndHour > 23 {
+		return false
+	}
+	return w.StartHour < w.EndHour
+}
+
+// OfferDurationOrDefault returns how long offers of the template can be
+// accepted, its delivery time when not set.
+func (t ContractTemplate) OfferDurationOrDefault() int64 {
+	if t.OfferDurationMs == 0 {
+		return t.DurationMs
+	}
+	return t.OfferDurationMs
+}
@@ -0,0 +1,767 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Report lists the problems found in a catalog. Errors make the catalog
+// unusable (rows the seed loader would skip), warnings point at balancing
+// issues that are allowed but probably unintended.
+type Report struct {
+	Errors   []string
+	Warnings []string
+}
+
+// HasErrors reports whether the catalog contains invalid entries.
+func (r *Report) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+func (r *Report) errorf(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Validate builds the resource/process graph of the catalog and reports
+// invalid rows, unreachable resources, unprofitable processes and loops
+// that destroy resources, as well as research nodes with unknown references
+// or circular prerequisites, contract templates that can't be fulfilled and
+// achievements that can't be completed.
+func Validate(c *Catalog) *Report {
+	report := &Report{}
+
+	resourceByID := validateResources(c.Resources, report)
+	validateBuildings(c.Buildings, resourceByID, report)
+
+	graph := buildGraph(c.Buildings, resourceByID)
+	checkUnproducedResources(c.Resources, graph, report)
+	checkProfitability(c.Buildings, resourceByID, report)
+	checkNegativeLoops(graph, resourceByID, report)
+	validateResearch(c.Research, c.Buildings, resourceByID, report)
+	validateContracts(c.Contracts, resourceByID, report)
+	validateAchievements(c.Achievements, resourceByID, report)
+
+	return report
+}
+
+func validateResources(resources []Resource, report *Report) map[int64]Resource {
+	resourceByID := make(map[int64]Resource, len(resources))
+	for i, resource := range resources {
+		label := resourceLabel(i, resource)
+		if resource.ID <= 0 {
+			report.errorf("%s: id must be positive", label)
+			continue
+		}
+		if _, ok := resourceByID[resource.ID]; ok {
+			report.errorf("%s: duplicate resource id", label)
+			continue
+		}
+		if resource.Name == "" {
+			report.errorf("%s: name is required", label)
+		}
+		if resource.Price < 0 {
+			report.errorf("%s: price cannot be negative", label)
+		}
+		if resource.PackSize < 0 {
+			report.errorf("%s: pack_size cannot be negative", label)
+		}
+		if resource.ShelfLifeMs < 0 {
+			report.errorf("%s: shelf_life_ms cannot be negative", label)
+		}
+		if resource.VolumeOrDefault() < 0 {
+			report.errorf("%s: volume cannot be negative", label)
+		}
+		if resourceType := resource.TypeOrDefault(); resourceType != ResourceTypeItem && resourceType != ResourceTypeFlow {
+			report.errorf("%s: invalid type %q (must be %q or %q)", label, resourceType, ResourceTypeItem, ResourceTypeFlow)
+		}
+		resourceByID[resource.ID] = resource
+	}
+	return resourceByID
+}
+
+func validateBuildings(buildings []Building, resourceByID map[int64]Resource, report *Report) {
+	buildingIDs := make(map[int64]struct{}, len(buildings))
+	processIDs := make(map[int64]int64)
+	for i, building := range buildings {
+		label := buildingLabel(i, building)
+		if building.ID <= 0 {
+			report.errorf("%s: id must be positive", label)
+		} else if _, ok := buildingIDs[building.ID]; ok {
+			report.errorf("%s: duplicate building id", label)
+		} else {
+			buildingIDs[building.ID] = struct{}{}
+		}
+		if building.Name == "" {
+			report.errorf("%s: name is required", label)
+		}
+		if building.Cost < 0 {
+			report.errorf("%s: cost cannot be negative", label)
+		}
+		if building.Upkeep < 0 {
+			report.errorf("%s: upkeep cannot be negative", label)
+		}
+		if building.StorageCapacity < 0 {
+			report.errorf("%s: storage_capacity cannot be negative", label)
+		}
+		if building.ConstructionTimeMs < 0 {
+			report.errorf("%s: construction_time_ms cannot be negative", label)
+		}
+		if building.SlotsOrDefault() <= 0 {
+			report.errorf("%s: slots must be positive", label)
+		}
+		validateLevelResources(label, building.ConstructionResources, resourceByID, report)
+		if building.Durability != nil {
+			validateDurability(label, *building.Durability, resourceByID, report)
+		}
+
+		if building.Depreciation != nil {
+			validateDepreciation(label, building.DepreciationOrDefault(), report)
+		}
+		validateLevels(label, building.Levels, resourceByID, report)
+
+		for j, process := range building.Processes {
+			processLabel := fmt.Sprintf("%s > %s", label, processLabelFor(j, process))
+			if process.ID <= 0 {
+				report.errorf("%s: id must be positive", processLabel)
+			} else if ownerID, ok := processIDs[process.ID]; ok {
+				report.errorf("%s: duplicate process id (already used in building %d)", processLabel, ownerID)
+			} else {
+				processIDs[process.ID] = building.ID
+			}
+			if process.Name == "" {
+				report.errorf("%s: name is required", processLabel)
+			}
+			if process.ProcessingTimeMs <= 0 {
+				report.errorf("%s: processing_time_ms must be positive", processLabel)
+			}
+			if process.Workers < 0 {
+				report.errorf("%s: workers must not be negative", processLabel)
+			}
+			if process.TimeWindow != nil && !process.TimeWindow.Valid() {
+				report.errorf(
+					"%s: invalid time_window %d-%d (hours must be 0-23 and start before end)",
+					processLabel,
+					process.TimeWindow.StartHour,
+					process.TimeWindow.EndHour,
+				)
+			}
+
+			validateProcessResources(processLabel, process, resourceByID, report)
+		}
+	}
+}
+
+func validateDepreciation(label string, depreciation Depreciation, report *Report) {
+	if depreciation.Curve != CurveLinear && depreciation.Curve != CurveExponential {
+		report.errorf("%s: unknown depreciation curve %q (use %q or %q)", label, depreciation.Curve, CurveLinear, CurveExponential)
+	}
+	if depreciation.InitialPercent < 0 || depreciation.InitialPercent > 100 {
+		report.errorf("%s: depreciation initial_percent must be between 0 and 100", label)
+	}
+	if depreciation.FloorPercent < 0 || depreciation.FloorPercent > depreciation.InitialPercent {
+		report.errorf("%s: depreciation floor_percent must be between 0 and initial_percent", label)
+	}
+	if depreciation.PercentPerDay < 0 {
+		report.errorf("%s: depreciation percent_per_day cannot be negative", label)
+	}
+	if depreciation.Curve == CurveExponential && depreciation.PercentPerDay >= 100 {
+		report.errorf("%s: exponential depreciation percent_per_day must be below 100", label)
+	}
+}
+
+func validateDurability(label string, durability Durability, resourceByID map[int64]Resource, report *Report) {
+	if durability.Max <= 0 {
+		report.errorf("%s: durability max must be positive", label)
+	}
+	if durability.WearPerBatchOrDefault() < 0 || durability.RepairCost < 0 {
+		report.errorf("%s: durability wear_per_batch and repair_cost cannot be negative", label)
+	}
+	if below := durability.BreakdownBelowPercentOrDefault(); below < 0 || below > 100 {
+		report.errorf("%s: durability breakdown_below_percent must be between 0 and 100", label)
+	}
+	validateLevelResources(label+" > durability", durability.RepairResources, resourceByID, report)
+}
+
+func validateLevelResources(label string, resources []LevelResource, resourceByID map[int64]Resource, report *Report) {
+	for _, levelResource := range resources {
+		if _, ok := resourceByID[levelResource.ResourceID]; !ok {
+			report.errorf("%s: unknown resource id %d", label, levelResource.ResourceID)
+		} else if levelResource.Quantity <= 0 {
+			report.errorf("%s: resource %d quantity must be positive", label, levelResource.ResourceID)
+		}
+	}
+}
+
+func validateLevels(label string, levels []Level, resourceByID map[int64]Resource, report *Report) {
+	seen := make(map[int64]struct{}, len(levels))
+	var maxLevel int64 = 1
+	for _, level := range levels {
+		levelLabel := fmt.Sprintf("%s > level %d", label, level.Level)
+		if level.Level <= 0 {
+			report.errorf("%s: level must be positive", levelLabel)
+			continue
+		}
+		if _, ok := seen[level.Level]; ok {
+			report.errorf("%s: duplicate level", levelLabel)
+			continue
+		}
+		seen[level.Level] = struct{}{}
+		if level.Level > maxLevel {
+			maxLevel = level.Level
+		}
+
+		if level.UpgradeCost < 0 || level.UpgradeTimeMs < 0 {
+			report.errorf("%s: upgrade cost and time cannot be negative", levelLabel)
+		}
+		if level.SpeedPercent < 0 || level.OutputPercent < 0 || level.MaxBatches < 0 || level.Slots < 0 {
+			report.errorf("%s: speed_percent, output_percent, max_batches and slots cannot be negative", levelLabel)
+		}
+		if level.Level == 1 && (level.UpgradeCost != 0 || level.UpgradeTimeMs != 0 || len(level.UpgradeResources) > 0) {
+			report.warnf("%s: upgrade requirements are ignored for the base level (see construction_time_ms)", levelLabel)
+		}
+
+		validateLevelResources(levelLabel, level.UpgradeResources, resourceByID, report)
+	}
+
+	// Upgrades go one level at a time, so a gap makes later levels unreachable
+	for level := int64(2); level <= maxLevel; level++ {
+		if _, ok := seen[level]; !ok {
+			report.errorf("%s: level %d is missing (levels must be consecutive)", label, level)
+		}
+	}
+}
+
+func validateProcessResources(label string, process Process, resourceByID map[int64]Resource, report *Report) {
+	seen := make(map[string]struct{}, len(process.Resources))
+	groups := make(map[int64]int)
+	outputs := 0
+	for _, processResource := range process.Resources {
+		if processResource.Direction != DirectionInput && processResource.Direction != DirectionOutput {
+			report.errorf("%s: resource %d has invalid direction %q", label, processResource.ResourceID, processResource.Direction)
+			continue
+		}
+		if _, ok := resourceByID[processResource.ResourceID]; !ok {
+			report.errorf("%s: unknown resource id %d", label, processResource.ResourceID)
+			continue
+		}
+		if processResource.Quantity <= 0 {
+			report.errorf("%s: resource %d quantity must be positive", label, processResource.ResourceID)
+			continue
+		}
+		if processResource.MaxQuantityOrDefault() < processResource.Quantity {
+			report.errorf("%s: resource %d max_quantity is below quantity", label, processResource.ResourceID)
+			continue
+		}
+		if chance := processResource.ChanceOrDefault(); chance <= 0 || chance > 100 {
+			report.errorf("%s: resource %d chance must be between 1 and 100", label, processResource.ResourceID)
+			continue
+		}
+		random := processResource.MaxQuantityOrDefault() != processResource.Quantity || processResource.ChanceOrDefault() != 100
+		if random && processResource.Direction == DirectionInput {
+			report.errorf("%s: input resource %d can't have a random quantity", label, processResource.ResourceID)
+			continue
+		}
+		if random && resourceByID[processResource.ResourceID].TypeOrDefault() == ResourceTypeFlow {
+			report.errorf("%s: flow resource %d can't have a random quantity", label, processResource.ResourceID)
+			continue
+		}
+		if processResource.Group < 0 {
+			report.errorf("%s: resource %d group can't be negative", label, processResource.ResourceID)
+			continue
+		}
+		if processResource.Group > 0 {
+			if processResource.Direction != DirectionInput {
+				report.errorf("%s: output resource %d can't be in an input group", label, processResource.ResourceID)
+				continue
+			}
+			if resourceByID[processResource.ResourceID].TypeOrDefault() == ResourceTypeFlow {
+				report.errorf("%s: flow resource %d can't be in an input group", label, processResource.ResourceID)
+				continue
+			}
+			groups[processResource.Group]++
+		}
+
+		key := fmt.Sprintf("%d|%s", processResource.ResourceID, processResource.Direction)
+		if _, ok := seen[key]; ok {
+			report.errorf("%s: resource %d is listed twice as %s", label, processResource.ResourceID, processResource.Direction)
+			continue
+		}
+		seen[key] = struct{}{}
+
+		if processResource.Direction == DirectionOutput {
+			outputs++
+		}
+	}
+
+	if outputs == 0 {
+		report.errorf("%s: process has no outputs", label)
+	}
+
+	ids := make([]int64, 0, len(groups))
+	for group := range groups {
+		ids = append(ids, group)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, group := range ids {
+		if groups[group] == 1 {
+			report.warnf("%s: input group %d has a single option", label, group)
+		}
+	}
+}
+
+// graphEdge links an input resource to an output resource of a process.
+// Ratio is the average number of output units obtained per input unit.
+type graphEdge struct {
+	To        int64
+	ProcessID int64
+	Ratio     float64
+}
+
+type resourceGraph struct {
+	Edges    map[int64][]graphEdge
+	Produced map[int64]struct{}
+}
+
+// buildGraph only uses valid process resources so that invalid rows are
+// reported once by validateBuildings and don't produce follow-up noise.
+func buildGraph(buildings []Building, resourceByID map[int64]Resource) *resourceGraph {
+	graph := &resourceGraph{
+		Edges:    make(map[int64][]graphEdge),
+		Produced: make(map[int64]struct{}),
+	}
+
+	for _, building := range buildings {
+		for _, process := range building.Processes {
+			inputs, outputs := validIO(process, resourceByID)
+			for _, output := range outputs {
+				graph.Produced[output.ResourceID] = struct{}{}
+			}
+			for _, input := range inputs {
+				for _, output := range outputs {
+					// Chance byproducts are a bonus rather than a way to
+					// turn one resource into another
+					if output.ChanceOrDefault() < 100 {
+						continue
+					}
+					graph.Edges[input.ResourceID] = append(graph.Edges[input.ResourceID], graphEdge{
+						To:        output.ResourceID,
+						ProcessID: process.ID,
+						Ratio:     output.ExpectedQuantity() / float64(input.Quantity),
+					})
+				}
+			}
+		}
+	}
+
+	return graph
+}
+
+func validIO(process Process, resourceByID map[int64]Resource) ([]ProcessResource, []ProcessResource) {
+	var inputs []ProcessResource
+	var outputs []ProcessResource
+	for _, processResource := range process.Resources {
+		if processResource.Quantity <= 0 {
+			continue
+		}
+		if _, ok := resourceByID[processResource.ResourceID]; !ok {
+			continue
+		}
+		switch processResource.Direction {
+		case DirectionInput:
+			inputs = append(inputs, processResource)
+		case DirectionOutput:
+			outputs = append(outputs, processResource)
+		}
+	}
+	return inputs, outputs
+}
+
+func checkUnproducedResources(resources []Resource, graph *resourceGraph, report *Report) {
+	for i, resource := range resources {
+		if resource.ID <= 0 {
+			continue
+		}
+		if _, ok := graph.Produced[resource.ID]; !ok {
+			report.warnf("%s: no process produces this resource (market only)", resourceLabel(i, resource))
+		}
+	}
+}
+
+func checkProfitability(buildings []Building, resourceByID map[int64]Resource, report *Report) {
+	for _, building := range buildings {
+		for _, process := range building.Processes {
+			inputs, outputs := validIO(process, resourceByID)
+			if len(outputs) == 0 {
+				continue
+			}
+
+			inputCost := cheapestInputCost(inputs, resourceByID)
+			var outputValue float64
+			for _, output := range outputs {
+				outputValue += resourceByID[output.ResourceID].UnitPrice() * output.ExpectedQuantity()
+			}
+
+			if outputValue <= inputCost {
+				report.warnf(
+					"process %d %q: unprofitable at base prices (inputs %.3f, outputs %.3f)",
+					process.ID,
+					process.Name,
+					inputCost/1000,
+					outputValue/1000,
+				)
+			}
+		}
+	}
+}
+
+// cheapestInputCost returns the cost of a batch's inputs taking the cheapest
+// option of each input group.
+func cheapestInputCost(inputs []ProcessResource, resourceByID map[int64]Resource) float64 {
+	var cost float64
+	groupCost := make(map[int64]float64)
+	for _, input := range inputs {
+		inputCost := resourceByID[input.ResourceID].UnitPrice() * float64(input.Quantity)
+		if input.Group == 0 {
+			cost += inputCost
+			continue
+		}
+		if current, ok := groupCost[input.Group]; !ok || inputCost < current {
+			groupCost[input.Group] = inputCost
+		}
+	}
+	for _, inputCost := range groupCost {
+		cost += inputCost
+	}
+	return cost
+}
+
+// checkNegativeLoops looks for resource cycles whose conversion ratios
+// multiply to less than one: running the loop ends with fewer units than
+// it started with.
+func checkNegativeLoops(graph *resourceGraph, resourceByID map[int64]Resource, report *Report) {
+	nodes := make([]int64, 0, len(graph.Edges))
+	for id := range graph.Edges {
+		nodes = append(nodes, id)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+
+	for _, cycle := range findCycles(graph, nodes) {
+		ratio := 1.0
+		for _, edge := range cycle {
+			ratio *= edge.Ratio
+		}
+		if ratio >= 1 {
+			continue
+		}
+
+		names := make([]string, 0, len(cycle)+1)
+		processes := make([]string, 0, len(cycle))
+		start := cycle[len(cycle)-1].To
+		names = append(names, resourceByID[start].Name)
+		for _, edge := range cycle {
+			names = append(names, resourceByID[edge.To].Name)
+			processes = append(processes, fmt.Sprintf("%d", edge.ProcessID))
+		}
+		report.warnf(
+			"net-negative loop %s via processes %s (yields %.3f units per unit)",
+			strings.Join(names, " -> "),
+			strings.Join(processes, ", "),
+			ratio,
+		)
+	}
+}
+
+// findCycles enumerates elementary cycles. Each cycle is only reported
+// from its smallest resource id so rotations are not duplicated.
+func findCycles(graph *resourceGraph, nodes []int64) [][]graphEdge {
+	var cycles [][]graphEdge
+	for _, start := range nodes {
+		onPath := map[int64]bool{start: true}
+		var path []graphEdge
+		var visit func(node int64)
+		visit = func(node int64) {
+			for _, edge := range graph.Edges[node] {
+				if edge.To < start {
+					continue
+				}
+				if edge.To == start {
+					cycle := make([]graphEdge, len(path)+1)
+					copy(cycle, path)
+					cycle[len(path)] = edge
+					cycles = append(cycles, cycle)
+					continue
+				}
+				if onPath[edge.To] {
+					continue
+				}
+				onPath[edge.To] = true
+				path = append(path, edge)
+				visit(edge.To)
+				path = path[:len(path)-1]
+				onPath[edge.To] = false
+			}
+		}
+		visit(start)
+	}
+	return cycles
+}
+
+func validateResearch(nodes []ResearchNode, buildings []Building, resourceByID map[int64]Resource, report *Report) {
+	buildingIDs := make(map[int64]struct{}, len(buildings))
+	processIDs := make(map[int64]struct{})
+	for _, building := range buildings {
+		buildingIDs[building.ID] = struct{}{}
+		for _, process := range building.Processes {
+			processIDs[process.ID] = struct{}{}
+		}
+	}
+
+	nodeByID := make(map[int64]ResearchNode, len(nodes))
+	for i, node := range nodes {
+		if node.ID <= 0 {
+			report.errorf("%s: id must be positive", researchLabel(i, node))
+			continue
+		}
+		if _, ok := nodeByID[node.ID]; ok {
+			report.errorf("%s: duplicate research id", researchLabel(i, node))
+			continue
+		}
+		nodeByID[node.ID] = node
+	}
+
+	for i, node := range nodes {
+		label := researchLabel(i, node)
+		if node.Name == "" {
+			report.errorf("%s: name is required", label)
+		}
+		if node.Cost < 0 || node.DurationMs < 0 {
+			report.errorf("%s: cost and duration_ms cannot be negative", label)
+		}
+
+		for _, prerequisite := range node.Prerequisites {
+			if prerequisite == node.ID {
+				report.errorf("%s: node lists itself as a prerequisite", label)
+			} else if _, ok := nodeByID[prerequisite]; !ok {
+				report.errorf("%s: unknown prerequisite %d", label, prerequisite)
+			}
+		}
+
+		for _, resource := range node.Resources {
+			if _, ok := resourceByID[resource.ResourceID]; !ok {
+				report.errorf("%s: unknown resource id %d", label, resource.ResourceID)
+			} else if resource.Quantity <= 0 {
+				report.errorf("%s: resource %d quantity must be positive", label, resource.ResourceID)
+			}
+		}
+
+		for _, buildingID := range node.Unlocks.Buildings {
+			if _, ok := buildingIDs[buildingID]; !ok {
+				report.errorf("%s: unlocks unknown building %d", label, buildingID)
+			}
+		}
+		for _, processID := range node.Unlocks.Processes {
+			if _, ok := processIDs[processID]; !ok {
+				report.errorf("%s: unlocks unknown process %d", label, processID)
+			}
+		}
+		if len(node.Unlocks.Buildings) == 0 && len(node.Unlocks.Processes) == 0 {
+			report.warnf("%s: node unlocks nothing", label)
+		}
+	}
+
+	// Nodes in a prerequisite cycle can never be started
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int64]int, len(nodeByID))
+	var visit func(id int64) bool
+	visit = func(id int64) bool {
+		switch state[id] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		state[id] = visiting
+		for _, prerequisite := range nodeByID[id].Prerequisites {
+			if _, ok := nodeByID[prerequisite]; ok && prerequisite != id && visit(prerequisite) {
+				state[id] = done
+				return true
+			}
+		}
+		state[id] = done
+		return false
+	}
+	for i, node := range nodes {
+		if _, ok := nodeByID[node.ID]; !ok || state[node.ID] != unvisited {
+			continue
+		}
+		if visit(node.ID) {
+			report.errorf("%s: prerequisites form a cycle", researchLabel(i, node))
+		}
+	}
+}
+
+func validateContracts(templates []ContractTemplate, resourceByID map[int64]Resource, report *Report) {
+	seen := make(map[int64]struct{}, len(templates))
+	for i, template := range templates {
+		label := contractLabel(i, template)
+		if template.ID <= 0 {
+			report.errorf("%s: id must be positive", label)
+			continue
+		}
+		if _, ok := seen[template.ID]; ok {
+			report.errorf("%s: duplicate contract id", label)
+			continue
+		}
+		seen[template.ID] = struct{}{}
+
+		if template.Client == "" {
+			report.errorf("%s: client is required", label)
+		}
+		if template.Quantity <= 0 {
+			report.errorf("%s: quantity must be positive", label)
+		}
+		if template.Reward < 0 || template.Penalty < 0 {
+			report.errorf("%s: reward and penalty cannot be negative", label)
+		}
+		if template.DurationMs <= 0 {
+			report.errorf("%s: duration_ms must be positive", label)
+		}
+		if template.OfferDurationMs < 0 {
+			report.errorf("%s: offer_duration_ms cannot be negative", label)
+		}
+		if template.MinBuildings < 0 || template.ScalePerBuilding < 0 {
+			report.errorf("%s: min_buildings and scale_per_building cannot be negative", label)
+		}
+
+		resource, ok := resourceByID[template.ResourceID]
+		if !ok {
+			report.errorf("%s: unknown resource id %d", label, template.ResourceID)
+			continue
+		}
+		if resource.TypeOrDefault() == ResourceTypeFlow {
+			report.errorf("%s: resource %d is a flow and can't be delivered", label, template.ResourceID)
+			continue
+		}
+		if template.Quantity > 0 && float64(template.Reward) < resource.UnitPrice()*float64(template.Quantity) {
+			report.warnf("%s: reward is below the market value of the goods", label)
+		}
+	}
+}
+
+func validateAchievements(achievements []Achievement, resourceByID map[int64]Resource, report *Report) {
+	seen := make(map[int64]struct{}, len(achievements))
+	for i, achievement := range achievements {
+		label := achievementLabel(i, achievement)
+		if achievement.ID <= 0 {
+			report.errorf("%s: id must be positive", label)
+			continue
+		}
+		if _, ok := seen[achievement.ID]; ok {
+			report.errorf("%s: duplicate achievement id", label)
+			continue
+		}
+		seen[achievement.ID] = struct{}{}
+
+		if achievement.Name == "" {
+			report.errorf("%s: name is required", label)
+		}
+		switch achievement.Condition {
+		case ConditionCount, ConditionSum, ConditionMax:
+		default:
+			report.errorf("%s: invalid condition %q (must be %q, %q or %q)",
+				label, achievement.Condition, ConditionCount, ConditionSum, ConditionMax)
+		}
+		if achievement.Threshold <= 0 {
+			report.errorf("%s: threshold must be positive", label)
+		}
+		if achievement.Reward.Money < 0 {
+			report.errorf("%s: reward money cannot be negative", label)
+		}
+
+		for _, reward := range achievement.Reward.Resources {
+			resource, ok := resourceByID[reward.ResourceID]
+			if !ok {
+				report.errorf("%s: unknown reward resource id %d", label, reward.ResourceID)
+				continue
+			}
+			if resource.TypeOrDefault() == ResourceTypeFlow {
+				report.errorf("%s: reward resource %d is a flow and can't be stored", label, reward.ResourceID)
+			}
+			if reward.Quantity <= 0 {
+				report.errorf("%s: reward quantity of resource %d must be positive", label, reward.ResourceID)
+			}
+		}
+
+		switch achievement.Event {
+		case EventResourceProduced, EventResourceSold:
+			if achievement.ResourceID == 0 {
+				continue
+			}
+			resource, ok := resourceByID[achievement.ResourceID]
+			if !ok {
+				report.errorf("%s: unknown resource id %d", label, achievement.ResourceID)
+				continue
+			}
+			if resource.TypeOrDefault() == ResourceTypeFlow {
+				report.errorf("%s: resource %d is a flow and is never collected or sold", label, achievement.ResourceID)
+			}
+		case EventBuildingPurchased, EventMoneyEarned, EventContractCompleted:
+			if achievement.ResourceID != 0 {
+				report.warnf("%s: resource_id is ignored for %q events", label, achievement.Event)
+			}
+		default:
+			report.errorf("%s: unknown event %q", label, achievement.Event)
+		}
+	}
+}
+
+func resourceLabel(index int, resource Resource) string {
+	if resource.Name != "" {
+		return fmt.Sprintf("resource %d %q", resource.ID, resource.Name)
+	}
+	return fmt.Sprintf("resource #%d (id %d)", index+1, resource.ID)
+}
+
+func buildingLabel(index int, building Building) string {
+	if building.Name != "" {
+		return fmt.Sprintf("building %d %q", building.ID, building.Name)
+	}
+	return fmt.Sprintf("building #%d (id %d)", index+1, building.ID)
+}
+
+func processLabelFor(index int, process Process) string {
+	if process.Name != "" {
+		return fmt.Sprintf("process %d %q", process.ID, process.Name)
+	}
+	return fmt.Sprintf("process #%d (id %d)", index+1, process.ID)
+}
+
+func researchLabel(index int, node ResearchNode) string {
+	if node.Name != "" {
+		return fmt.Sprintf("research %d %q", node.ID, node.Name)
+	}
+	return fmt.Sprintf("research #%d (id %d)", index+1, node.ID)
+}
+
+func contractLabel(index int, template ContractTemplate) string {
+	if template.Client != "" {
+		return fmt.Sprintf("contract %d %q", template.ID, template.Client)
+	}
+	return fmt.Sprintf("contract #%d (id %d)", index+1, template.ID)
+}
+
+func achievementLabel(index int, achievement Achievement) string {
+	if achievement.Name != "" {
+		return fmt.Sprintf("achievement %d %q", achievement.ID, achievement.Name)
+	}
+	return fmt.Sprintf("achievement #%d (id %d)", index+1, achievement.ID)
+}
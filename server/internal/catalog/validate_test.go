@@ -0,0 +1,285 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+// validCatalog returns a small catalog without errors: seeds are germinated
+// into more seeds and grown into wheat using electricity.
+func validCatalog() *Catalog {
+	return &Catalog{
+		Resources: []Resource{
+			{ID: 1, Name: "Seeds", Price: 100, PackSize: 1},
+			{ID: 2, Name: "Wheat", Price: 1000, PackSize: 1},
+			{ID: 3, Name: "Electricity", Type: ResourceTypeFlow, Price: 10, PackSize: 1},
+		},
+		Buildings: []Building{
+			{
+				ID:   1,
+				Name: "Solar panel",
+				Cost: 1000,
+				Processes: []Process{
+					{ID: 10, Name: "Generate", ProcessingTimeMs: 1000, Resources: []ProcessResource{
+						{ResourceID: 3, Direction: DirectionOutput, Quantity: 5},
+					}},
+				},
+			},
+			{
+				ID:   2,
+				Name: "Farm",
+				Cost: 5000,
+				Levels: []Level{
+					{Level: 1, MaxBatches: 5},
+					{Level: 2, UpgradeCost: 1000, SpeedPercent: 120},
+				},
+				Processes: []Process{
+					{ID: 20, Name: "Germinate", ProcessingTimeMs: 1000, Resources: []ProcessResource{
+						{ResourceID: 1, Direction: DirectionInput, Quantity: 1},
+						{ResourceID: 1, Direction: DirectionOutput, Quantity: 2},
+					}},
+					{ID: 21, Name: "Grow", ProcessingTimeMs: 1000, Resources: []ProcessResource{
+						{ResourceID: 1, Direction: DirectionInput, Quantity: 1},
+						{ResourceID: 3, Direction: DirectionInput, Quantity: 2},
+						{ResourceID: 2, Direction: DirectionOutput, Quantity: 1, MaxQuantity: 3},
+					}},
+				},
+			},
+		},
+		Research: []ResearchNode{
+			{ID: 1, Name: "Irrigation", Cost: 100, DurationMs: 1000, Unlocks: ResearchUnlocks{Processes: []int64{21}}},
+			{ID: 2, Name: "Machinery", Prerequisites: []int64{1}, Unlocks: ResearchUnlocks{Buildings: []int64{2}}},
+		},
+		Contracts: []ContractTemplate{
+			{ID: 1, Client: "Bakery", ResourceID: 2, Quantity: 10, Reward: 20000, DurationMs: 1000},
+		},
+		Achievements: []Achievement{
+			{ID: 1, Name: "Farmer", Event: EventResourceProduced, ResourceID: 2, Condition: ConditionSum, Threshold: 100},
+			{ID: 2, Name: "Builder", Event: EventBuildingPurchased, Condition: ConditionCount, Threshold: 3,
+				Reward: AchievementReward{Money: 1000, Resources: []AchievementResource{{ResourceID: 1, Quantity: 10}}}},
+		},
+	}
+}
+
+func TestValidateValidCatalog(t *testing.T) {
+	report := Validate(validCatalog())
+	if report.HasErrors() {
+		t.Fatalf("unexpected errors: %v", report.Errors)
+	}
+	if len(report.Warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", report.Warnings)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Catalog)
+		want   string
+	}{
+		{
+			name:   "duplicate resource id",
+			mutate: func(c *Catalog) { c.Resources = append(c.Resources, Resource{ID: 1, Name: "Other"}) },
+			want:   "duplicate resource id",
+		},
+		{
+			name:   "invalid resource type",
+			mutate: func(c *Catalog) { c.Resources[0].Type = "gas" },
+			want:   `invalid type "gas"`,
+		},
+		{
+			name:   "negative price",
+			mutate: func(c *Catalog) { c.Resources[1].Price = -1 },
+			want:   "price cannot be negative",
+		},
+		{
+			name: "unknown process resource",
+			mutate: func(c *Catalog) {
+				c.Buildings[1].Processes[0].Resources[0].ResourceID = 99
+			},
+			want: "unknown resource id 99",
+		},
+		{
+			name: "random input",
+			mutate: func(c *Catalog) {
+				c.Buildings[1].Processes[0].Resources[0].MaxQuantity = 3
+			},
+			want: "input resource 1 can't have a random quantity",
+		},
+		{
+			name: "random flow output",
+			mutate: func(c *Catalog) {
+				c.Buildings[0].Processes[0].Resources[0].Chance = int64Ptr(50)
+			},
+			want: "flow resource 3 can't have a random quantity",
+		},
+		{
+			name: "chance out of range",
+			mutate: func(c *Catalog) {
+				c.Buildings[1].Processes[1].Resources[2].Chance = int64Ptr(150)
+			},
+			want: "chance must be between 1 and 100",
+		},
+		{
+			name: "output in an input group",
+			mutate: func(c *Catalog) {
+				c.Buildings[1].Processes[0].Resources[1].Group = 1
+			},
+			want: "output resource 1 can't be in an input group",
+		},
+		{
+			name: "process without outputs",
+			mutate: func(c *Catalog) {
+				c.Buildings[1].Processes[0].Resources = c.Buildings[1].Processes[0].Resources[:1]
+			},
+			want: "process has no outputs",
+		},
+		{
+			name: "levels not consecutive",
+			mutate: func(c *Catalog) {
+				c.Buildings[1].Levels[1].Level = 3
+			},
+			want: "level 2 is missing",
+		},
+		{
+			name:   "unknown research prerequisite",
+			mutate: func(c *Catalog) { c.Research[1].Prerequisites = []int64{9} },
+			want:   "unknown prerequisite 9",
+		},
+		{
+			name:   "research prerequisite cycle",
+			mutate: func(c *Catalog) { c.Research[0].Prerequisites = []int64{2} },
+			want:   "prerequisites form a cycle",
+		},
+		{
+			name:   "research unlocks unknown building",
+			mutate: func(c *Catalog) { c.Research[1].Unlocks.Buildings = []int64{7} },
+			want:   "unlocks unknown building 7",
+		},
+		{
+			name:   "contract for a flow",
+			mutate: func(c *Catalog) { c.Contracts[0].ResourceID = 3 },
+			want:   "is a flow and can't be delivered",
+		},
+		{
+			name:   "contract without duration",
+			mutate: func(c *Catalog) { c.Contracts[0].DurationMs = 0 },
+			want:   "duration_ms must be positive",
+		},
+		{
+			name:   "achievement invalid condition",
+			mutate: func(c *Catalog) { c.Achievements[0].Condition = "avg" },
+			want:   `invalid condition "avg"`,
+		},
+		{
+			name:   "achievement unknown event",
+			mutate: func(c *Catalog) { c.Achievements[1].Event = "research_completed" },
+			want:   `unknown event "research_completed"`,
+		},
+		{
+			name: "achievement rewards a flow",
+			mutate: func(c *Catalog) {
+				c.Achievements[1].Reward.Resources[0].ResourceID = 3
+			},
+			want: "reward resource 3 is a flow",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validCatalog()
+			tt.mutate(c)
+
+			report := Validate(c)
+			if !containsMessage(report.Errors, tt.want) {
+				t.Fatalf("errors %v do not contain %q", report.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Catalog)
+		want   string
+	}{
+		{
+			name:   "unprofitable process",
+			mutate: func(c *Catalog) { c.Resources[1].Price = 50 },
+			want:   `process 21 "Grow": unprofitable at base prices`,
+		},
+		{
+			name: "net-negative loop",
+			mutate: func(c *Catalog) {
+				c.Buildings[1].Processes = append(c.Buildings[1].Processes, Process{
+					ID:               22,
+					Name:             "Thresh",
+					ProcessingTimeMs: 1000,
+					Resources: []ProcessResource{
+						{ResourceID: 2, Direction: DirectionInput, Quantity: 4},
+						{ResourceID: 1, Direction: DirectionOutput, Quantity: 1},
+					},
+				})
+			},
+			want: "net-negative loop Seeds -> Wheat -> Seeds via processes 21, 22",
+		},
+		{
+			name:   "unproduced resource",
+			mutate: func(c *Catalog) { c.Resources = append(c.Resources, Resource{ID: 4, Name: "Salt", Price: 10}) },
+			want:   `resource 4 "Salt": no process produces this resource`,
+		},
+		{
+			name:   "reward below market value",
+			mutate: func(c *Catalog) { c.Contracts[0].Reward = 5000 },
+			want:   "reward is below the market value of the goods",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validCatalog()
+			tt.mutate(c)
+
+			report := Validate(c)
+			if report.HasErrors() {
+				t.Fatalf("unexpected errors: %v", report.Errors)
+			}
+			if !containsMessage(report.Warnings, tt.want) {
+				t.Fatalf("warnings %v do not contain %q", report.Warnings, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateShippedCatalog keeps the data files the server loads by
+// default free of errors.
+func TestValidateShippedCatalog(t *testing.T) {
+	c, err := Load(
+		"../../data/resources.json",
+		"../../data/production_buildings.json",
+		"../../data/research.json",
+		"../../data/contracts.json",
+		"../../data/achievements.json",
+	)
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+
+	if report := Validate(c); report.HasErrors() {
+		t.Fatalf("shipped catalog has errors: %v", report.Errors)
+	}
+}
+
+func containsMessage(messages []string, want string) bool {
+	for _, message := range messages {
+		if strings.Contains(message, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package db
+
+import "time"
+
+// Achievement event types.
+const (
+	EventBuildingPurchased = "building_purchased"
+	EventResourceProduced  = "resource_produced" // Amount in units
+	EventResourceSold      = "resource_sold"     // Amount in units
+	EventMoneyEarned       = "money_earned"      // Market sales and contract rewards, in thousandths
+	EventContractCompleted = "contract_completed"
+)
+
+// Achievement conditions.
+const (
+	ConditionCount = "count" // Events
+	ConditionSum   = "sum"   // Total of the event amounts
+	ConditionMax   = "max"   // Largest event amount
+)
+
+// Achievement is a milestone with a fixed, non-autogenerated ID. Companies
+// complete it once their progress on its events reaches Threshold.
+type Achievement struct {
+	ID              int64
+	Name            string
+	Description     string
+	Event           string
+	ResourceID      int64 // 0 = any resource
+	Condition       string
+	Threshold       int64
+	RewardMoney     int64
+	RewardResources []ResourceQuantity
+}
+
+// CompanyAchievement is the progress of a company on an achievement. The
+// reward is paid once completed; RewardedAt stays nil while it couldn't be
+// (e.g. storage full) and it's retried later.
+type CompanyAchievement struct {
+	CompanyID     int64
+	AchievementID int64
+	Progress      int64
+	CompletedAt   *time.Time
+	RewardedAt    *time.Time
+}
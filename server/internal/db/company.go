@@ -4,12 +4,16 @@ import "time"
 
 // Company represents a company in the database
 type Company struct {
-	ID        int64
-	UserID    int64
-	Name      string
-	Money     int64 // Stored in thousandths (e.g., 50,000.000 = 50000000)
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID         int64
+	UserID     int64
+	Name       string
+	Money      int64 // Stored in thousandths (e.g., 50,000.000 = 50000000)
+	UpkeepDebt int64 // Unpaid upkeep, buildings are idle while positive
+	Workers    int64 // Hired workers
+	// Wages are paid up to this time, nil before the first hire
+	WagesPaidUntil *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 // MoneyToFloat converts money from integer (thousandths) to float with 3 decimals
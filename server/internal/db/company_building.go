@@ -0,0 +1,29 @@
+package db
+
+import "time"
+
+// CompanyBuilding represents a production building owned by a company.
+type CompanyBuilding struct {
+	ID                     int64
+	CompanyID              int64
+	BuildingID             int64
+	Level                  int64
+	UpgradeFinishesAt      *time.Time // Set while upgrading to Level + 1
+	ConstructionFinishesAt *time.Time // Nil for buildings bought without construction
+	Wear                   int64      // Durability points lost since the last repair
+	BrokenAt               *time.Time // Set while broken down, until repaired
+	UpkeepPeriodsPaid      int64      // Upkeep periods charged since CreatedAt
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+}
+
+// UnderConstruction reports whether the building can't be used yet at the
+// given time.
+func (b *CompanyBuilding) UnderConstruction(now time.Time) bool {
+	return b.ConstructionFinishesAt != nil && now.Before(*b.ConstructionFinishesAt)
+}
+
+// Upgrading reports whether an upgrade is still in progress at the given time.
+func (b *CompanyBuilding) Upgrading(now time.Time) bool {
+	return b.UpgradeFinishesAt != nil && now.Before(*b.UpgradeFinishesAt)
+}
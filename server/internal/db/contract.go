@@ -0,0 +1,52 @@
+package db
+
+import "time"
+
+// Contract statuses.
+const (
+	ContractOffered   = "offered"   // Can be accepted until OfferExpiresAt
+	ContractAccepted  = "accepted"  // Being delivered until Deadline
+	ContractCompleted = "completed" // Fully delivered and paid
+	ContractFailed    = "failed"    // Deadline passed, penalty charged
+)
+
+// ContractTemplate is an NPC order from the seed file with a fixed,
+// non-autogenerated ID. Offers are made from templates for each company.
+type ContractTemplate struct {
+	ID               int64
+	Client           string
+	ResourceID       int64
+	Quantity         int64
+	Reward           int64
+	Penalty          int64
+	DurationMs       int64
+	OfferDurationMs  int64
+	MinBuildings     int64
+	ScalePerBuilding float64
+}
+
+// Contract is an NPC order offered to a company, with quantity, reward and
+// penalty already scaled to the company's size.
+type Contract struct {
+	ID             int64
+	CompanyID      int64
+	TemplateID     int64
+	Client         string
+	ResourceID     int64
+	Quantity       int64
+	Delivered      int64
+	Reward         int64
+	Penalty        int64
+	DurationMs     int64
+	Status         string
+	OfferedAt      time.Time
+	OfferExpiresAt time.Time
+	AcceptedAt     *time.Time
+	Deadline       *time.Time
+	ClosedAt       *time.Time // Completed or failed
+}
+
+// Overdue reports whether an accepted contract missed its deadline.
+func (c *Contract) Overdue(now time.Time) bool {
+	return c.Status == ContractAccepted && c.Deadline != nil && !now.Before(*c.Deadline)
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	_ "embed"
 	"fmt"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -15,8 +17,82 @@ type DB struct {
 	*sql.DB
 }
 
+// columnMigrations lists columns added to tables after they were first
+// created. CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so
+// these are added with ALTER TABLE when missing.
+var columnMigrations = []struct {
+	Table      string
+	Column     string
+	Definition string
+}{
+	{"production_buildings", "depreciation_curve", "TEXT NOT NULL DEFAULT 'linear'"},
+	{"production_buildings", "depreciation_initial_percent", "REAL NOT NULL DEFAULT 80"},
+	{"production_buildings", "depreciation_percent_per_day", "REAL NOT NULL DEFAULT 2"},
+	{"production_buildings", "depreciation_floor_percent", "REAL NOT NULL DEFAULT 20"},
+	{"production_buildings", "upkeep", "INTEGER NOT NULL DEFAULT 0"},
+	{"companies", "upkeep_debt", "INTEGER NOT NULL DEFAULT 0"},
+	{"company_buildings", "upkeep_periods_paid", "INTEGER NOT NULL DEFAULT 0"},
+	{"resources", "shelf_life_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"resources", "volume", "INTEGER NOT NULL DEFAULT 1"},
+	{"production_buildings", "storage_capacity", "INTEGER NOT NULL DEFAULT 0"},
+	{"production_processes", "workers", "INTEGER NOT NULL DEFAULT 0"},
+	{"production_runs", "workers", "INTEGER NOT NULL DEFAULT 0"},
+	{"companies", "workers", "INTEGER NOT NULL DEFAULT 0"},
+	{"companies", "wages_paid_until", "DATETIME"},
+	{"resources", "type", "TEXT NOT NULL DEFAULT 'item'"},
+	{"inventory_lots", "quality", "INTEGER NOT NULL DEFAULT 1"},
+	{"production_runs", "quality", "INTEGER NOT NULL DEFAULT 1"},
+	{"production_process_resources", "max_quantity", "INTEGER NOT NULL DEFAULT 0"},
+	{"production_process_resources", "chance", "INTEGER NOT NULL DEFAULT 100"},
+	{"production_runs", "seed", "INTEGER NOT NULL DEFAULT 0"},
+	{"production_process_resources", "input_group", "INTEGER NOT NULL DEFAULT 0"},
+	{"production_buildings", "construction_time_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"company_buildings", "construction_finishes_at", "DATETIME"},
+	{"production_buildings", "max_durability", "INTEGER NOT NULL DEFAULT 0"},
+	{"production_buildings", "wear_per_batch", "INTEGER NOT NULL DEFAULT 1"},
+	{"production_buildings", "breakdown_below_percent", "INTEGER NOT NULL DEFAULT 50"},
+	{"production_buildings", "repair_cost", "INTEGER NOT NULL DEFAULT 0"},
+	{"company_buildings", "wear", "INTEGER NOT NULL DEFAULT 0"},
+	{"company_buildings", "broken_at", "DATETIME"},
+	{"production_buildings", "slots", "INTEGER NOT NULL DEFAULT 1"},
+	{"production_building_levels", "slots", "INTEGER NOT NULL DEFAULT 0"},
+	{"production_runs", "slot", "INTEGER NOT NULL DEFAULT 1"},
+	{"production_runs", "work_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"production_runs", "output_percent", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// tableRebuilds lists tables whose keys changed after they were first
+// created. SQLite can't alter constraints, so a table missing Column is
+// renamed, created again from the schema and refilled with its old Columns.
+var tableRebuilds = []struct {
+	Table   string
+	Column  string
+	Columns string
+}{
+	{"company_inventory", "quality", "id, company_id, resource_id, quantity, created_at, updated_at"},
+	{"building_buffers", "quality", "company_building_id, resource_id, quantity"},
+}
+
+// timestampLayout has a fixed width so stored values compare correctly as
+// text and match the format of CURRENT_TIMESTAMP.
+const timestampLayout = "2006-01-02 15:04:05.000"
+
+// Timestamp formats a time for DATETIME columns compared in SQL queries.
+func Timestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
 // Open opens a new database connection and initializes the schema
 func Open(dataSourceName string) (*DB, error) {
+	// Pragmas in the DSN apply to every pooled connection, not just the first.
+	// Immediate transactions take the write lock up front, so checks done
+	// inside a transaction can't be invalidated by a concurrent writer.
+	separator := "?"
+	if strings.Contains(dataSourceName, "?") {
+		separator = "&"
+	}
+	dataSourceName += separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate"
+
 	db, err := sql.Open("sqlite", dataSourceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -36,5 +112,101 @@ func Open(dataSourceName string) (*DB, error) {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
+	if err := migrateColumns(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	if err := rebuildTables(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return &DB{DB: db}, nil
 }
+
+func migrateColumns(db *sql.DB) error {
+	for _, migration := range columnMigrations {
+		exists, err := columnExists(db, migration.Table, migration.Column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN %s %s",
+			migration.Table,
+			migration.Column,
+			migration.Definition,
+		)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rebuildTables(db *sql.DB) error {
+	for _, rebuild := range tableRebuilds {
+		exists, err := columnExists(db, rebuild.Table, rebuild.Column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		// The schema runs again after dropping the old table to restore the
+		// indexes that were dropped with it
+		old := rebuild.Table + "_old"
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", rebuild.Table, old),
+			schema,
+			fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", rebuild.Table, rebuild.Columns, rebuild.Columns, old),
+			fmt.Sprintf("DROP TABLE %s", old),
+			schema,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid          int
+			name         string
+			columnType   string
+			notNull      int
+			defaultValue sql.NullString
+			primaryKey   int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &primaryKey); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
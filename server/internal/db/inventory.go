@@ -2,30 +2,98 @@ package db
 
 import "time"
 
+// Resource types. Items are stored in the inventory; flows (like
+// electricity) are produced and consumed continuously while runs are active,
+// and only the stock bought or left from before is stored.
+const (
+	ResourceTypeItem = "item"
+	ResourceTypeFlow = "flow"
+)
+
 // Resource represents a resource type in the game
 type Resource struct {
-	ID       int64
-	Name     string
-	Price    int64 // Price in thousandths for pack_size units
-	PackSize int64 // Number of units per pack
+	ID          int64
+	Name        string
+	Type        string // ResourceTypeItem or ResourceTypeFlow
+	Price       int64  // Price in thousandths for pack_size units
+	PackSize    int64  // Number of units per pack
+	ShelfLifeMs int64  // 0 = never expires
+	Volume      int64  // Storage used per unit
+}
+
+// IsFlow reports whether the resource is a flow resource.
+func (r *Resource) IsFlow() bool {
+	return r.Type == ResourceTypeFlow
+}
+
+// Quality tiers of items. Stock bought on the market is standard quality;
+// production can make better tiers, which sell for more.
+const (
+	DefaultQuality = 1
+	MaxQuality     = 5
+)
+
+// Orders in which stock of different qualities is consumed.
+const (
+	QualityLowestFirst  = "lowest"
+	QualityHighestFirst = "highest"
+)
+
+// ResourceQuantity is an amount of a resource.
+type ResourceQuantity struct {
+	ResourceID int64
+	Quality    int64 // DefaultQuality when 0
+	Quantity   int64
+}
+
+// QualityOrDefault returns the quality tier, DefaultQuality when not set.
+func (q ResourceQuantity) QualityOrDefault() int64 {
+	if q.Quality <= 0 {
+		return DefaultQuality
+	}
+	return q.Quality
+}
+
+// StorageUsage is the storage used by a company and its capacity.
+type StorageUsage struct {
+	Limited  bool // False when the server runs with unlimited storage
+	Used     int64
+	Capacity int64
 }
 
-// CompanyInventory represents the quantity of a resource owned by a company
+// CompanyInventory represents the quantity of a resource of one quality
+// owned by a company
 type CompanyInventory struct {
 	ID         int64
 	CompanyID  int64
 	ResourceID int64
+	Quality    int64
 	Quantity   int64
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
 
-// InventoryWithDetails combines inventory and resource details
-type InventoryWithDetails struct {
+// InventoryLot is a quantity of a resource acquired at the same time.
+type InventoryLot struct {
 	ID         int64
+	CompanyID  int64
 	ResourceID int64
-	Name       string
+	Quality    int64
 	Quantity   int64
-	Price      int64 // price per pack in thousandths
-	PackSize   int64 // units per pack
+	AcquiredAt time.Time
+	ExpiresAt  *time.Time // Nil if the resource doesn't expire
+}
+
+// InventoryWithDetails combines inventory and resource details
+type InventoryWithDetails struct {
+	ID           int64
+	ResourceID   int64
+	Name         string
+	Quality      int64
+	Quantity     int64
+	Price        int64 // price per pack in thousandths, at standard quality
+	PackSize     int64 // units per pack
+	ShelfLifeMs  int64
+	ExpiringSoon int64      // Units that expire within the expiring soon window
+	NextExpiryAt *time.Time // Nil if nothing expires
 }
@@ -0,0 +1,40 @@
+package db
+
+import "time"
+
+// Leaderboard boards.
+const (
+	BoardNetWorth     = "net_worth"
+	BoardCash         = "cash"
+	BoardProduction   = "production"    // Units of a resource produced
+	BoardMarketProfit = "market_profit" // Market sales minus purchases
+)
+
+// Leaderboard periods. Boards that rank a current value (net worth, cash)
+// only have the all-time period.
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+	PeriodAll    = "all"
+)
+
+// LeaderboardEntry is the precomputed position of a company on a board.
+type LeaderboardEntry struct {
+	Board       string
+	Period      string
+	ResourceID  int64 // Resource of production boards, 0 otherwise
+	Rank        int64 // Tied companies share a rank
+	CompanyID   int64
+	CompanyName string
+	Value       int64 // Thousandths, or units for production boards
+	ComputedAt  time.Time
+}
+
+// ProductionOutput records the units of a resource a company collected from
+// a production run.
+type ProductionOutput struct {
+	CompanyID  int64
+	ResourceID int64
+	Quantity   int64
+	ProducedAt time.Time
+}
@@ -0,0 +1,37 @@
+package db
+
+import "time"
+
+// Ledger entry kinds.
+const (
+	LedgerMarketBuy         = "market_buy"
+	LedgerMarketSell        = "market_sell"
+	LedgerBuildingPurchase  = "building_purchase"
+	LedgerBuildingUpgrade   = "building_upgrade"
+	LedgerBuildingSale      = "building_sale"
+	LedgerBuildingRepair    = "building_repair"
+	LedgerUpkeep            = "upkeep"
+	LedgerUpkeepDebt        = "upkeep_debt"
+	LedgerWages             = "wages"
+	LedgerResearch          = "research"
+	LedgerRush              = "rush"
+	LedgerContractReward    = "contract_reward"
+	LedgerContractPenalty   = "contract_penalty"
+	LedgerLoan              = "loan"
+	LedgerLoanPayment       = "loan_payment"
+	LedgerLoanRepayment     = "loan_repayment"
+	LedgerLoanSeizure       = "loan_seizure"
+	LedgerAchievementReward = "achievement_reward"
+)
+
+// LedgerEntry records a change to a company's money.
+type LedgerEntry struct {
+	ID          int64
+	CompanyID   int64
+	Amount      int64 // Signed, in thousandths
+	Balance     int64 // Money after the change
+	Kind        string
+	Description string
+	ReferenceID *int64 // Resource, owned building, run, research node, contract, loan or achievement, by kind
+	CreatedAt   time.Time
+}
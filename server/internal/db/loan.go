@@ -0,0 +1,27 @@
+package db
+
+import "time"
+
+// Loan statuses.
+const (
+	LoanActive    = "active"
+	LoanRepaid    = "repaid"
+	LoanDefaulted = "defaulted" // Too many missed payments, buildings seized
+)
+
+// Loan is money lent to a company by the bank. It's paid back in Periods
+// installments of principal plus interest, one every upkeep period after
+// TakenAt.
+type Loan struct {
+	ID              int64
+	CompanyID       int64
+	Principal       int64   // Amount lent
+	Balance         int64   // Principal still owed, plus the interest of missed payments. Written off once defaulted
+	InterestPercent float64 // Per period, fixed when the loan is taken
+	Periods         int64
+	PeriodsPaid     int64 // Periods settled since TakenAt, paid or missed
+	MissedPayments  int64 // Missed in a row
+	Status          string
+	TakenAt         time.Time
+	ClosedAt        *time.Time // Repaid or defaulted
+}
@@ -0,0 +1,24 @@
+package db
+
+import "time"
+
+// Notification kinds
+const (
+	NotificationProductionFinished = "production_finished"
+	NotificationContractExpiring   = "contract_expiring"
+	NotificationOrderFilled        = "order_filled"
+	NotificationBuildingBroken     = "building_broken"
+)
+
+// Notification is a message to a company about something that happened in
+// the game.
+type Notification struct {
+	ID          int64
+	CompanyID   int64
+	Kind        string
+	Message     string
+	ReferenceID *int64  // Run, contract, resource or company building it's about
+	DedupeKey   *string // Notifications with the same key are only created once
+	ReadAt      *time.Time
+	CreatedAt   time.Time
+}
@@ -1,9 +1,51 @@
 package db
 
+// Depreciation curves for the resale value of buildings.
+const (
+	DepreciationLinear      = "linear"
+	DepreciationExponential = "exponential"
+)
+
 // ProductionBuilding represents a production building type in the game
-// with a fixed, non-autogenerated ID.
+// with a fixed, non-autogenerated ID. Bought buildings are under
+// construction for ConstructionTimeMs before they can be used, and
+// buildings with a MaxDurability wear down with every batch they run.
 type ProductionBuilding struct {
-	ID   int64
-	Name string
-	Cost int64
+	ID                         int64
+	Name                       string
+	Cost                       int64
+	DepreciationCurve          string
+	DepreciationInitialPercent float64
+	DepreciationPercentPerDay  float64
+	DepreciationFloorPercent   float64
+	Upkeep                     int64 // Charged every upkeep period
+	StorageCapacity            int64 // Storage added to the owner's capacity
+	Slots                      int64 // Processes that can run at once
+	ConstructionTimeMs         int64
+	ConstructionResources      []ResourceQuantity // Consumed when buying
+	MaxDurability              int64              // 0 = never wears
+	WearPerBatch               int64
+	BreakdownBelowPercent      int64              // Durability under which runs may break it down
+	RepairCost                 int64              // Per durability point
+	RepairResources            []ResourceQuantity // For a full repair
+}
+
+// ProductionBuildingLevel holds the upgrade requirements and bonuses of a
+// building level.
+type ProductionBuildingLevel struct {
+	BuildingID    int64
+	Level         int64
+	UpgradeCost   int64
+	UpgradeTimeMs int64
+	SpeedPercent  int64
+	OutputPercent int64
+	MaxBatches    int64 // 0 = unlimited
+	Slots         int64 // 0 = the building's slots
+	Resources     []ProductionBuildingLevelResource
+}
+
+// ProductionBuildingLevelResource is a resource consumed by an upgrade.
+type ProductionBuildingLevelResource struct {
+	ResourceID int64
+	Quantity   int64
 }
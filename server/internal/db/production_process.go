@@ -9,4 +9,5 @@ type ProductionProcess struct {
 	BuildingID       int64
 	WindowStartHour  *int64
 	WindowEndHour    *int64
+	Workers          int64 // Workers needed to run at full speed
 }
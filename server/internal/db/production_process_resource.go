@@ -1,9 +1,33 @@
 package db
 
 // ProductionProcessResource represents a resource input/output for a process.
+// Item inputs sharing a Group are alternatives and a run takes only one of
+// them. Item outputs may be random: each batch yields between Quantity and
+// MaxQuantity units with a Chance percent probability.
 type ProductionProcessResource struct {
-	ProcessID  int64
-	ResourceID int64
-	Direction  string
-	Quantity   int64
+	ProcessID   int64
+	ResourceID  int64
+	Direction   string
+	Quantity    int64
+	Group       int64 // 0 = always needed
+	MaxQuantity int64 // 0 = always Quantity
+	Chance      int64 // Percent
+}
+
+// MaxQuantityOrDefault returns the largest yield per batch.
+func (r ProductionProcessResource) MaxQuantityOrDefault() int64 {
+	if r.MaxQuantity < r.Quantity {
+		return r.Quantity
+	}
+	return r.MaxQuantity
+}
+
+// Random reports whether the yield of a batch varies from run to run.
+func (r ProductionProcessResource) Random() bool {
+	return r.MaxQuantityOrDefault() != r.Quantity || r.Chance < 100
+}
+
+// ExpectedQuantity returns the average yield per batch.
+func (r ProductionProcessResource) ExpectedQuantity() float64 {
+	return float64(r.Quantity+r.MaxQuantityOrDefault()) / 2 * float64(r.Chance) / 100
 }
@@ -0,0 +1,26 @@
+package db
+
+import "time"
+
+// ProductionRun represents a number of batches of a process running in a
+// company building.
+type ProductionRun struct {
+	ID                int64
+	CompanyBuildingID int64
+	ProcessID         int64
+	Slot              int64 // Slot of the building, from 1, held until collected
+	Batches           int64
+	Workers           int64 // Workers busy until the run finishes
+	Quality           int64 // Quality tier of the item outputs
+	Seed              int64 // Seeds the random yields of the outputs
+	WorkMs            int64 // Duration at full speed, before energy shortages
+	OutputPercent     int64 // Output of the building level the run started at
+	StartedAt         time.Time
+	FinishesAt        time.Time
+	CollectedAt       *time.Time
+}
+
+// Finished reports whether the run can be collected at the given time.
+func (r *ProductionRun) Finished(now time.Time) bool {
+	return !now.Before(r.FinishesAt)
+}
@@ -0,0 +1,35 @@
+package db
+
+import "time"
+
+// Research unlock kinds.
+const (
+	UnlockBuilding = "building"
+	UnlockProcess  = "process"
+)
+
+// ResearchNode is a node of the tech tree with a fixed, non-autogenerated ID.
+type ResearchNode struct {
+	ID              int64
+	Name            string
+	Cost            int64
+	DurationMs      int64
+	Prerequisites   []int64
+	Resources       []ResourceQuantity
+	UnlockBuildings []int64
+	UnlockProcesses []int64
+}
+
+// CompanyResearch is a research node started by a company. The node is
+// completed once FinishesAt has passed.
+type CompanyResearch struct {
+	CompanyID  int64
+	NodeID     int64
+	StartedAt  time.Time
+	FinishesAt time.Time
+}
+
+// Completed reports whether the research has finished at the given time.
+func (r *CompanyResearch) Completed(now time.Time) bool {
+	return !now.Before(r.FinishesAt)
+}
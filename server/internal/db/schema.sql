@@ -29,6 +29,9 @@ CREATE TABLE IF NOT EXISTS companies (
     user_id INTEGER NOT NULL UNIQUE,
     name TEXT NOT NULL,
     money INTEGER NOT NULL DEFAULT 50000000, -- Stored in thousandths (50,000.000 = 50000000)
+    upkeep_debt INTEGER NOT NULL DEFAULT 0, -- Unpaid upkeep; buildings stay idle while above 0
+    workers INTEGER NOT NULL DEFAULT 0, -- Hired workers
+    wages_paid_until DATETIME, -- NULL before the first hire
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
@@ -41,14 +44,49 @@ CREATE TABLE IF NOT EXISTS resources (
     id INTEGER PRIMARY KEY,
     name TEXT NOT NULL,
     price INTEGER NOT NULL, -- Price in thousandths for pack_size units
-    pack_size INTEGER NOT NULL DEFAULT 1 -- Number of units per pack
+    pack_size INTEGER NOT NULL DEFAULT 1, -- Number of units per pack
+    shelf_life_ms INTEGER NOT NULL DEFAULT 0, -- 0 = never expires
+    volume INTEGER NOT NULL DEFAULT 1, -- Storage used per unit
+    type TEXT NOT NULL DEFAULT 'item' -- 'item' or 'flow' (produced and consumed while running)
 );
 
 -- Production buildings table (available production buildings in the game)
 CREATE TABLE IF NOT EXISTS production_buildings (
     id INTEGER PRIMARY KEY,
     name TEXT NOT NULL,
-    cost INTEGER NOT NULL
+    cost INTEGER NOT NULL,
+    depreciation_curve TEXT NOT NULL DEFAULT 'linear', -- 'linear' or 'exponential'
+    depreciation_initial_percent REAL NOT NULL DEFAULT 80, -- Resale value right after buying
+    depreciation_percent_per_day REAL NOT NULL DEFAULT 2,
+    depreciation_floor_percent REAL NOT NULL DEFAULT 20, -- Resale value never drops below this
+    upkeep INTEGER NOT NULL DEFAULT 0, -- Charged every upkeep period
+    storage_capacity INTEGER NOT NULL DEFAULT 0, -- Storage added to the owner (warehouses)
+    construction_time_ms INTEGER NOT NULL DEFAULT 0, -- Time before a bought building can be used
+    slots INTEGER NOT NULL DEFAULT 1, -- Processes that can run at once
+    max_durability INTEGER NOT NULL DEFAULT 0, -- 0 = never wears
+    wear_per_batch INTEGER NOT NULL DEFAULT 1,
+    breakdown_below_percent INTEGER NOT NULL DEFAULT 50, -- Runs may break it down under this durability
+    repair_cost INTEGER NOT NULL DEFAULT 0 -- Money per durability point, in thousandths
+);
+
+-- Resources consumed by a full repair of a building
+CREATE TABLE IF NOT EXISTS production_building_repair_resources (
+    building_id INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (building_id, resource_id),
+    FOREIGN KEY (building_id) REFERENCES production_buildings(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
+);
+
+-- Resources consumed when buying a building
+CREATE TABLE IF NOT EXISTS production_building_construction_resources (
+    building_id INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (building_id, resource_id),
+    FOREIGN KEY (building_id) REFERENCES production_buildings(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
 );
 
 -- Production processes table (available production processes in the game)
@@ -59,6 +97,7 @@ CREATE TABLE IF NOT EXISTS production_processes (
     building_id INTEGER NOT NULL,
     window_start_hour INTEGER,
     window_end_hour INTEGER,
+    workers INTEGER NOT NULL DEFAULT 0, -- Workers needed to run at full speed
     FOREIGN KEY (building_id) REFERENCES production_buildings(id) ON DELETE CASCADE
 );
 
@@ -70,6 +109,9 @@ CREATE TABLE IF NOT EXISTS production_process_resources (
     resource_id INTEGER NOT NULL,
     direction TEXT NOT NULL,
     quantity INTEGER NOT NULL,
+    input_group INTEGER NOT NULL DEFAULT 0, -- Inputs sharing a group are alternatives, 0 = always needed
+    max_quantity INTEGER NOT NULL DEFAULT 0, -- 0 = always quantity
+    chance INTEGER NOT NULL DEFAULT 100, -- Percent chance of the yield
     PRIMARY KEY (process_id, resource_id, direction),
     FOREIGN KEY (process_id) REFERENCES production_processes(id) ON DELETE CASCADE,
     FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE,
@@ -83,14 +125,342 @@ CREATE TABLE IF NOT EXISTS company_inventory (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     company_id INTEGER NOT NULL,
     resource_id INTEGER NOT NULL,
+    quality INTEGER NOT NULL DEFAULT 1, -- Quality tier, 1 = standard
     quantity INTEGER NOT NULL DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(company_id, resource_id),
+    UNIQUE(company_id, resource_id, quality),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
+);
+
+-- Inventory lots (quantities acquired at the same time, consumed oldest first).
+-- Stock from before lots were tracked has no lot, never expires and is
+-- consumed before any lot.
+CREATE TABLE IF NOT EXISTS inventory_lots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quality INTEGER NOT NULL DEFAULT 1,
+    quantity INTEGER NOT NULL,
+    acquired_at DATETIME NOT NULL,
+    expires_at DATETIME, -- NULL for resources without shelf life
     FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
     FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
 );
 
+CREATE INDEX IF NOT EXISTS idx_inventory_lots_company_resource ON inventory_lots(company_id, resource_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_lots_expires_at ON inventory_lots(expires_at);
+
 -- Index for faster lookups
 CREATE INDEX IF NOT EXISTS idx_company_inventory_company_id ON company_inventory(company_id);
-CREATE INDEX IF NOT EXISTS idx_company_inventory_resource_id ON company_inventory(resource_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_company_inventory_resource_id ON company_inventory(resource_id);
+-- Production building levels table (upgrade requirements and bonuses per level)
+CREATE TABLE IF NOT EXISTS production_building_levels (
+    building_id INTEGER NOT NULL,
+    level INTEGER NOT NULL,
+    upgrade_cost INTEGER NOT NULL DEFAULT 0, -- Money in thousandths
+    upgrade_time_ms INTEGER NOT NULL DEFAULT 0,
+    speed_percent INTEGER NOT NULL DEFAULT 100, -- 125 = runs finish in 100/125 of the time
+    output_percent INTEGER NOT NULL DEFAULT 100, -- 110 = outputs are multiplied by 1.1
+    max_batches INTEGER NOT NULL DEFAULT 0, -- Max batches per run, 0 = unlimited
+    slots INTEGER NOT NULL DEFAULT 0, -- Processes that can run at once, 0 = the building's slots
+    PRIMARY KEY (building_id, level),
+    FOREIGN KEY (building_id) REFERENCES production_buildings(id) ON DELETE CASCADE
+);
+
+-- Resources consumed when upgrading a building to a level
+CREATE TABLE IF NOT EXISTS production_building_level_resources (
+    building_id INTEGER NOT NULL,
+    level INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (building_id, level, resource_id),
+    FOREIGN KEY (building_id, level) REFERENCES production_building_levels(building_id, level) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
+);
+
+-- Company buildings table (production buildings owned by companies)
+CREATE TABLE IF NOT EXISTS company_buildings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    building_id INTEGER NOT NULL,
+    level INTEGER NOT NULL DEFAULT 1,
+    upgrade_finishes_at DATETIME, -- Set while upgrading to level + 1
+    construction_finishes_at DATETIME, -- Unusable before this time
+    wear INTEGER NOT NULL DEFAULT 0, -- Durability points lost since the last repair
+    broken_at DATETIME, -- Set while broken down
+    upkeep_periods_paid INTEGER NOT NULL DEFAULT 0, -- Upkeep periods charged since created_at
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (building_id) REFERENCES production_buildings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_company_buildings_company_id ON company_buildings(company_id);
+
+-- Production runs table (batches of a process running in a company building)
+CREATE TABLE IF NOT EXISTS production_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_building_id INTEGER NOT NULL,
+    process_id INTEGER NOT NULL,
+    slot INTEGER NOT NULL DEFAULT 1, -- Slot of the building held until collected
+    batches INTEGER NOT NULL,
+    workers INTEGER NOT NULL DEFAULT 0, -- Workers busy until the run finishes
+    quality INTEGER NOT NULL DEFAULT 1, -- Quality tier of the item outputs
+    seed INTEGER NOT NULL DEFAULT 0, -- Seeds the random yields of the outputs
+    work_ms INTEGER NOT NULL DEFAULT 0, -- Duration at full speed, before energy shortages
+    output_percent INTEGER NOT NULL DEFAULT 0, -- Output of the building level the run started at
+    started_at DATETIME NOT NULL,
+    finishes_at DATETIME NOT NULL,
+    collected_at DATETIME, -- NULL while the run is active
+    FOREIGN KEY (company_building_id) REFERENCES company_buildings(id) ON DELETE CASCADE,
+    FOREIGN KEY (process_id) REFERENCES production_processes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_production_runs_company_building_id ON production_runs(company_building_id);
+CREATE INDEX IF NOT EXISTS idx_production_runs_finishes_at ON production_runs(finishes_at);
+
+-- Company ledger table (history of every change to companies.money)
+CREATE TABLE IF NOT EXISTS company_ledger (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL, -- Signed, in thousandths
+    balance INTEGER NOT NULL, -- Money after the change
+    kind TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    reference_id INTEGER, -- Related entity (building, run, ...) depending on kind
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_company_ledger_company_id ON company_ledger(company_id);
+
+-- Supply links table (outputs of a company building routed to another one)
+CREATE TABLE IF NOT EXISTS supply_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    source_building_id INTEGER NOT NULL, -- Company building whose outputs are routed
+    target_building_id INTEGER NOT NULL, -- Company building that receives them
+    resource_id INTEGER NOT NULL,
+    target_process_id INTEGER NOT NULL, -- Process started in the target from its buffer
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_building_id, resource_id),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_building_id) REFERENCES company_buildings(id) ON DELETE CASCADE,
+    FOREIGN KEY (target_building_id) REFERENCES company_buildings(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE,
+    FOREIGN KEY (target_process_id) REFERENCES production_processes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_supply_links_company_id ON supply_links(company_id);
+CREATE INDEX IF NOT EXISTS idx_supply_links_target_building_id ON supply_links(target_building_id);
+
+-- Contract templates table (NPC orders from the seed file)
+CREATE TABLE IF NOT EXISTS contract_templates (
+    id INTEGER PRIMARY KEY, -- Fixed ID from the seed file
+    client TEXT NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    reward INTEGER NOT NULL DEFAULT 0, -- Money in thousandths
+    penalty INTEGER NOT NULL DEFAULT 0, -- Money in thousandths
+    duration_ms INTEGER NOT NULL, -- Delivery time once accepted
+    offer_duration_ms INTEGER NOT NULL, -- How long offers can be accepted
+    min_buildings INTEGER NOT NULL DEFAULT 0,
+    scale_per_building REAL NOT NULL DEFAULT 0,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
+);
+
+-- Contracts table (NPC orders offered to a company, scaled to its size)
+CREATE TABLE IF NOT EXISTS contracts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    template_id INTEGER NOT NULL, -- Not a foreign key, templates may be removed from the seed file
+    client TEXT NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    delivered INTEGER NOT NULL DEFAULT 0,
+    reward INTEGER NOT NULL, -- Money in thousandths
+    penalty INTEGER NOT NULL, -- Full penalty, prorated to the undelivered units
+    duration_ms INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'offered' CHECK (status IN ('offered', 'accepted', 'completed', 'failed')),
+    offered_at DATETIME NOT NULL,
+    offer_expires_at DATETIME NOT NULL,
+    accepted_at DATETIME,
+    deadline DATETIME, -- Set when accepted
+    closed_at DATETIME, -- Set when completed or failed
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_contracts_company_id ON contracts(company_id);
+CREATE INDEX IF NOT EXISTS idx_contracts_deadline ON contracts(status, deadline);
+
+-- Loans table (money lent by the bank, paid back in installments)
+CREATE TABLE IF NOT EXISTS loans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    principal INTEGER NOT NULL, -- Money in thousandths
+    balance INTEGER NOT NULL, -- Still owed, in thousandths
+    interest_percent REAL NOT NULL, -- Per period
+    periods INTEGER NOT NULL, -- Installments, one per upkeep period
+    periods_paid INTEGER NOT NULL DEFAULT 0, -- Periods settled since taken_at, paid or missed
+    missed_payments INTEGER NOT NULL DEFAULT 0, -- Missed in a row
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'repaid', 'defaulted')),
+    taken_at DATETIME NOT NULL,
+    closed_at DATETIME,
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_loans_company_id ON loans(company_id);
+
+-- Company valuations table (periodic net worth snapshots)
+CREATE TABLE IF NOT EXISTS company_valuations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    cash INTEGER NOT NULL, -- Money in thousandths
+    inventory INTEGER NOT NULL, -- Stock at market sell prices
+    buildings INTEGER NOT NULL, -- Owned buildings at their sale value
+    liabilities INTEGER NOT NULL, -- Upkeep debt and loan balances
+    net_worth INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_company_valuations_company_id ON company_valuations(company_id);
+
+-- Production outputs table (units collected from production runs, for leaderboards)
+CREATE TABLE IF NOT EXISTS production_outputs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    produced_at DATETIME NOT NULL, -- When the run finished
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_production_outputs_produced_at ON production_outputs(produced_at);
+
+-- Leaderboard entries table (rankings precomputed by the leaderboard job)
+CREATE TABLE IF NOT EXISTS leaderboard_entries (
+    board TEXT NOT NULL, -- net_worth, cash, production or market_profit
+    period TEXT NOT NULL, -- daily, weekly or all
+    resource_id INTEGER NOT NULL DEFAULT 0, -- Resource of production boards, 0 otherwise
+    company_id INTEGER NOT NULL,
+    rank INTEGER NOT NULL, -- Tied companies share a rank
+    value INTEGER NOT NULL,
+    computed_at DATETIME NOT NULL,
+    PRIMARY KEY (board, period, resource_id, company_id),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_entries_rank ON leaderboard_entries(board, period, resource_id, rank);
+
+-- Achievements table (milestones completed by reaching a threshold on game events)
+CREATE TABLE IF NOT EXISTS achievements (
+    id INTEGER PRIMARY KEY, -- Fixed ID from the seed file
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    event TEXT NOT NULL, -- building_purchased, resource_produced, resource_sold, money_earned or contract_completed
+    resource_id INTEGER NOT NULL DEFAULT 0, -- Narrows resource events, 0 = any
+    condition TEXT NOT NULL CHECK (condition IN ('count', 'sum', 'max')),
+    threshold INTEGER NOT NULL,
+    reward_money INTEGER NOT NULL DEFAULT 0 -- In thousandths
+);
+
+-- Resources given when completing an achievement
+CREATE TABLE IF NOT EXISTS achievement_reward_resources (
+    achievement_id INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (achievement_id, resource_id),
+    FOREIGN KEY (achievement_id) REFERENCES achievements(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
+);
+
+-- Company achievements table (progress of each company, completed once it reaches the threshold)
+CREATE TABLE IF NOT EXISTS company_achievements (
+    company_id INTEGER NOT NULL,
+    achievement_id INTEGER NOT NULL,
+    progress INTEGER NOT NULL DEFAULT 0,
+    completed_at DATETIME,
+    rewarded_at DATETIME, -- NULL while the reward is pending
+    PRIMARY KEY (company_id, achievement_id),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (achievement_id) REFERENCES achievements(id) ON DELETE CASCADE
+);
+
+-- Notifications table (messages to a company, pruned after the retention period)
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    message TEXT NOT NULL,
+    reference_id INTEGER,
+    dedupe_key TEXT UNIQUE, -- Set for notifications that must not repeat, like "run:<id>"
+    read_at DATETIME, -- NULL while unread
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_company ON notifications(company_id, id);
+CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications(created_at);
+
+-- Building buffers table (inputs reserved in a company building by supply links)
+CREATE TABLE IF NOT EXISTS building_buffers (
+    company_building_id INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quality INTEGER NOT NULL DEFAULT 1,
+    quantity INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (company_building_id, resource_id, quality),
+    FOREIGN KEY (company_building_id) REFERENCES company_buildings(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE,
+    CHECK (quantity >= 0)
+);
+
+-- Research nodes table (tech tree that unlocks buildings and processes)
+CREATE TABLE IF NOT EXISTS research_nodes (
+    id INTEGER PRIMARY KEY, -- Fixed ID from the seed file
+    name TEXT NOT NULL,
+    cost INTEGER NOT NULL DEFAULT 0, -- Money in thousandths
+    duration_ms INTEGER NOT NULL DEFAULT 0
+);
+
+-- Nodes that must be completed before a research node can be started
+CREATE TABLE IF NOT EXISTS research_node_prerequisites (
+    node_id INTEGER NOT NULL,
+    prerequisite_id INTEGER NOT NULL, -- Not a foreign key, nodes are seeded in any order
+    PRIMARY KEY (node_id, prerequisite_id),
+    FOREIGN KEY (node_id) REFERENCES research_nodes(id) ON DELETE CASCADE
+);
+
+-- Resources consumed when starting a research node
+CREATE TABLE IF NOT EXISTS research_node_resources (
+    node_id INTEGER NOT NULL,
+    resource_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (node_id, resource_id),
+    FOREIGN KEY (node_id) REFERENCES research_nodes(id) ON DELETE CASCADE,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
+);
+
+-- Buildings and processes unlocked by a research node
+CREATE TABLE IF NOT EXISTS research_node_unlocks (
+    node_id INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('building', 'process')),
+    target_id INTEGER NOT NULL, -- Production building or process ID, by kind
+    PRIMARY KEY (node_id, kind, target_id),
+    FOREIGN KEY (node_id) REFERENCES research_nodes(id) ON DELETE CASCADE
+);
+
+-- Company research table (nodes started by a company, completed once finishes_at passes)
+CREATE TABLE IF NOT EXISTS company_research (
+    company_id INTEGER NOT NULL,
+    node_id INTEGER NOT NULL,
+    started_at DATETIME NOT NULL,
+    finishes_at DATETIME NOT NULL,
+    PRIMARY KEY (company_id, node_id),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (node_id) REFERENCES research_nodes(id) ON DELETE CASCADE
+);
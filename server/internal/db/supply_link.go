@@ -0,0 +1,15 @@
+package db
+
+import "time"
+
+// SupplyLink routes a resource collected in one company building into the
+// input buffer of another, which runs TargetProcessID from the buffer.
+type SupplyLink struct {
+	ID               int64
+	CompanyID        int64
+	SourceBuildingID int64
+	TargetBuildingID int64
+	ResourceID       int64
+	TargetProcessID  int64
+	CreatedAt        time.Time
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// Tx is a database transaction. A transaction begun with a context that
+// already carries one (see Transact) is nested in it as a savepoint, so
+// repository methods that need a transaction of their own can also run as
+// part of a bigger one: rolling them back only undoes their own writes, and
+// committing them leaves the outer transaction to decide.
+type Tx struct {
+	tx        *sql.Tx
+	savepoint string // Set when nested
+	done      bool
+}
+
+type txKey struct{}
+
+var savepoints atomic.Int64
+
+// BeginTx starts a transaction, or a savepoint of the transaction carried by
+// ctx.
+func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if outer, ok := ctx.Value(txKey{}).(*Tx); ok {
+		name := fmt.Sprintf("sp%d", savepoints.Add(1))
+		if _, err := outer.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+			return nil, err
+		}
+		return &Tx{tx: outer.tx, savepoint: name}, nil
+	}
+
+	tx, err := d.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx: tx}, nil
+}
+
+// Transact runs fn in a transaction, committed if fn returns nil and rolled
+// back otherwise. Repository calls made with the context passed to fn run
+// inside the transaction.
+func (d *DB) Transact(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExecContext runs a statement in the transaction carried by ctx, if any.
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if tx, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return tx.ExecContext(ctx, query, args...)
+	}
+	return d.DB.ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs a query in the transaction carried by ctx, if any.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if tx, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return tx.QueryContext(ctx, query, args...)
+	}
+	return d.DB.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a query in the transaction carried by ctx, if any.
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if tx, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+	return d.DB.QueryRowContext(ctx, query, args...)
+}
+
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.tx.QueryRowContext(ctx, query, args...)
+}
+
+// Commit commits the transaction, or releases the savepoint keeping its
+// writes in the outer transaction.
+func (t *Tx) Commit() error {
+	if t.savepoint == "" {
+		return t.tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.tx.Exec("RELEASE " + t.savepoint)
+	return err
+}
+
+// Rollback rolls the transaction back, or undoes the writes made since the
+// savepoint.
+func (t *Tx) Rollback() error {
+	if t.savepoint == "" {
+		return t.tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if _, err := t.tx.Exec("ROLLBACK TO " + t.savepoint); err != nil {
+		return err
+	}
+	_, err := t.tx.Exec("RELEASE " + t.savepoint)
+	return err
+}
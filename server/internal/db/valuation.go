@@ -0,0 +1,15 @@
+package db
+
+import "time"
+
+// Valuation is the net worth of a company and what it's made of, all in
+// thousandths.
+type Valuation struct {
+	CompanyID   int64
+	Cash        int64
+	Inventory   int64 // Stock at market sell prices
+	Buildings   int64 // Owned buildings at their sale value
+	Liabilities int64 // Upkeep debt and loan balances
+	NetWorth    int64
+	CreatedAt   time.Time
+}
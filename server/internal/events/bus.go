@@ -0,0 +1,169 @@
+// Package events delivers domain events published by the services to the
+// subscribers registered at startup.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Event is something that happened in the game. Services publish events
+// once the change they describe is stored.
+type Event interface {
+	EventName() string
+}
+
+// Publisher publishes events. Services depend on it rather than on the bus.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// Delivery says how a subscriber receives events.
+type Delivery int
+
+const (
+	// Sync subscribers run in the publisher's goroutine before Publish
+	// returns, in the order they subscribed.
+	Sync Delivery = iota
+	// Async subscribers run in a goroutine of their own, receiving events in
+	// the order they were published. Publishing blocks while a subscriber's
+	// queue is full.
+	Async
+)
+
+// asyncQueueSize is how many events an async subscriber can fall behind by
+// before publishers wait for it.
+const asyncQueueSize = 256
+
+type subscriber struct {
+	name   string
+	handle func(ctx context.Context, event Event) error
+	queue  chan queued // Nil for sync subscribers
+	wg     *sync.WaitGroup
+}
+
+type queued struct {
+	ctx   context.Context
+	event Event
+}
+
+// Bus is an in-process event bus. Subscribers are isolated from each other
+// and from the publisher: their errors and panics are logged, never
+// returned.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string][]*subscriber
+	wg          sync.WaitGroup
+	closed      bool
+}
+
+// NewBus creates a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[string][]*subscriber)}
+}
+
+// Subscribe registers handler for the events of type T. The name
+// identifies the subscriber in logs. Subscribers should be registered at
+// startup, before events are published.
+func Subscribe[T Event](b *Bus, name string, delivery Delivery, handler func(ctx context.Context, event T) error) {
+	var zero T
+	sub := &subscriber{
+		name: name,
+		handle: func(ctx context.Context, event Event) error {
+			return handler(ctx, event.(T))
+		},
+		wg: &b.wg,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		log.Printf("Event subscriber %s not registered: bus closed", name)
+		return
+	}
+
+	if delivery == Async {
+		sub.queue = make(chan queued, asyncQueueSize)
+		b.wg.Add(1)
+		go sub.run()
+	}
+	b.subscribers[zero.EventName()] = append(b.subscribers[zero.EventName()], sub)
+}
+
+// Publish delivers an event to its subscribers. Async subscribers get a
+// context that isn't canceled with the publisher's, so events published
+// while handling a request outlive it.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	// Sync subscribers run without the lock held, so they can publish too
+	b.mu.RLock()
+	subs := b.subscribers[event.EventName()]
+	closed := b.closed
+	b.mu.RUnlock()
+
+	if closed {
+		return
+	}
+
+	for _, sub := range subs {
+		if sub.queue == nil {
+			sub.deliver(ctx, event)
+			continue
+		}
+		b.enqueue(sub, queued{ctx: context.WithoutCancel(ctx), event: event})
+	}
+}
+
+func (b *Bus) enqueue(sub *subscriber, item queued) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// Queues are closed along with the bus
+	if b.closed {
+		return
+	}
+	sub.queue <- item
+}
+
+// Close stops accepting events and waits for async subscribers to handle
+// the events already queued.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, subs := range b.subscribers {
+		for _, sub := range subs {
+			if sub.queue != nil {
+				close(sub.queue)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
+
+func (s *subscriber) run() {
+	defer s.wg.Done()
+	for item := range s.queue {
+		s.deliver(item.ctx, item.event)
+	}
+}
+
+// deliver runs the handler, logging its error or panic so a bad subscriber
+// can't break the publisher or the other subscribers.
+func (s *subscriber) deliver(ctx context.Context, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event subscriber %s panicked on %s: %v", s.name, event.EventName(), r)
+		}
+	}()
+
+	if err := s.handle(ctx, event); err != nil {
+		log.Printf("Event subscriber %s failed on %s: %v", s.name, event.EventName(), err)
+	}
+}
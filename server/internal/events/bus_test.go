@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	N int
+}
+
+func (testEvent) EventName() string { return "test" }
+
+func TestBusSyncOrder(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	var got []string
+	for _, name := range []string{"first", "second", "third"} {
+		Subscribe(bus, name, Sync, func(ctx context.Context, event testEvent) error {
+			got = append(got, name)
+			return nil
+		})
+	}
+
+	bus.Publish(context.Background(), testEvent{})
+
+	// Sync subscribers have run once Publish returns
+	want := []string{"first", "second", "third"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("subscribers ran in order %v, want %v", got, want)
+	}
+}
+
+func TestBusAsyncDeliveredBeforeClose(t *testing.T) {
+	bus := NewBus()
+
+	var mu sync.Mutex
+	var got []int
+	Subscribe(bus, "slow", Async, func(ctx context.Context, event testEvent) error {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, event.N)
+		return nil
+	})
+
+	want := make([]int, 0, 10)
+	for n := 0; n < 10; n++ {
+		bus.Publish(context.Background(), testEvent{N: n})
+		want = append(want, n)
+	}
+	bus.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("delivered %v before Close returned, want %v", got, want)
+	}
+
+	// Events published after Close are dropped
+	bus.Publish(context.Background(), testEvent{N: 10})
+	if len(got) != len(want) {
+		t.Fatalf("delivered %v after Close", got)
+	}
+}
+
+func TestBusSubscriberFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		delivery Delivery
+		handler  func(ctx context.Context, event testEvent) error
+	}{
+		{
+			name:     "sync panic",
+			delivery: Sync,
+			handler:  func(ctx context.Context, event testEvent) error { panic("boom") },
+		},
+		{
+			name:     "sync error",
+			delivery: Sync,
+			handler:  func(ctx context.Context, event testEvent) error { return errors.New("boom") },
+		},
+		{
+			name:     "async panic",
+			delivery: Async,
+			handler:  func(ctx context.Context, event testEvent) error { panic("boom") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewBus()
+
+			var mu sync.Mutex
+			delivered := make(map[string]int)
+			count := func(name string) func(ctx context.Context, event testEvent) error {
+				return func(ctx context.Context, event testEvent) error {
+					mu.Lock()
+					defer mu.Unlock()
+					delivered[name]++
+					return nil
+				}
+			}
+
+			Subscribe(bus, "before", Sync, count("before"))
+			Subscribe(bus, "failing", tt.delivery, tt.handler)
+			Subscribe(bus, "after", Sync, count("after"))
+			Subscribe(bus, "async", Async, count("async"))
+
+			bus.Publish(context.Background(), testEvent{N: 1})
+			bus.Publish(context.Background(), testEvent{N: 2})
+			bus.Close()
+
+			mu.Lock()
+			defer mu.Unlock()
+			want := map[string]int{"before": 2, "after": 2, "async": 2}
+			if !reflect.DeepEqual(delivered, want) {
+				t.Fatalf("delivered %v, want %v", delivered, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package events
+
+import "time"
+
+// Trade sides.
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// CompanyCreated is published when a player founds a company.
+type CompanyCreated struct {
+	CompanyID int64
+	UserID    int64
+	Name      string
+}
+
+func (CompanyCreated) EventName() string { return "company.created" }
+
+// TradeExecuted is published when a company buys from or sells to the
+// market.
+type TradeExecuted struct {
+	CompanyID  int64
+	Side       string // SideBuy or SideSell
+	ResourceID int64
+	Quality    int64
+	Packs      int64
+	Units      int64
+	Amount     int64 // Money paid or received, in thousandths
+}
+
+func (TradeExecuted) EventName() string { return "trade.executed" }
+
+// BuildingPurchased is published when a company buys a building.
+type BuildingPurchased struct {
+	CompanyID         int64
+	CompanyBuildingID int64
+	BuildingID        int64
+	Cost              int64
+}
+
+func (BuildingPurchased) EventName() string { return "building.purchased" }
+
+// ProductionStarted is published when a run starts, by hand or fed by a
+// supply link.
+type ProductionStarted struct {
+	CompanyID         int64
+	CompanyBuildingID int64
+	RunID             int64
+	ProcessID         int64
+	Batches           int64
+	FinishesAt        time.Time
+}
+
+func (ProductionStarted) EventName() string { return "production.started" }
+
+// RunCollected is published when the outputs of a finished run are
+// collected.
+type RunCollected struct {
+	CompanyID         int64
+	CompanyBuildingID int64
+	RunID             int64
+	Outputs           []Output
+	FinishedAt        time.Time
+	BrokeDown         bool // Collecting wore the building down until it broke
+}
+
+func (RunCollected) EventName() string { return "run.collected" }
+
+// Output is a resource collected from a run.
+type Output struct {
+	ResourceID int64
+	Quality    int64
+	Quantity   int64
+	RoutedTo   *int64 // Company building whose buffer received it instead of the inventory
+}
+
+// ContractCompleted is published when the delivery that completes a
+// contract is made and its reward paid.
+type ContractCompleted struct {
+	CompanyID  int64
+	ContractID int64
+	ResourceID int64
+	Reward     int64
+}
+
+func (ContractCompleted) EventName() string { return "contract.completed" }
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// AchievementHandler handles HTTP requests for achievements.
+type AchievementHandler struct {
+	achievementService service.AchievementService
+	companyRepo        repository.CompanyRepository
+}
+
+// NewAchievementHandler creates a new achievement handler.
+func NewAchievementHandler(achievementService service.AchievementService, companyRepo repository.CompanyRepository) *AchievementHandler {
+	return &AchievementHandler{
+		achievementService: achievementService,
+		companyRepo:        companyRepo,
+	}
+}
+
+type AchievementResponse struct {
+	ID              int64                                     `json:"id"`
+	Name            string                                    `json:"name"`
+	Description     string                                    `json:"description"`
+	Event           string                                    `json:"event"`
+	ResourceID      *int64                                    `json:"resource_id"` // Nil when any resource counts
+	ResourceName    string                                    `json:"resource_name"`
+	Condition       string                                    `json:"condition"` // count, sum or max
+	Threshold       int64                                     `json:"threshold"`
+	Progress        int64                                     `json:"progress"`
+	Completed       bool                                      `json:"completed"`
+	CompletedAt     *string                                   `json:"completed_at"`
+	RewardPending   bool                                      `json:"reward_pending"` // Completed but not paid yet, e.g. storage full
+	RewardMoney     int64                                     `json:"reward_money"`
+	RewardResources []ProductionBuildingLevelResourceResponse `json:"reward_resources"`
+}
+
+// GetMyAchievements lists every achievement with the progress of the
+// user's company.
+func (h *AchievementHandler) GetMyAchievements(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	achievements, err := h.achievementService.GetAchievements(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get achievements", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]AchievementResponse, 0, len(achievements))
+	for _, achievement := range achievements {
+		item := AchievementResponse{
+			ID:              achievement.ID,
+			Name:            achievement.Name,
+			Description:     achievement.Description,
+			Event:           achievement.Event,
+			ResourceName:    achievement.ResourceName,
+			Condition:       achievement.Condition,
+			Threshold:       achievement.Threshold,
+			Progress:        achievement.Progress,
+			Completed:       achievement.CompletedAt != nil,
+			RewardPending:   achievement.CompletedAt != nil && achievement.RewardedAt == nil,
+			RewardMoney:     achievement.RewardMoney,
+			RewardResources: make([]ProductionBuildingLevelResourceResponse, 0, len(achievement.RewardResources)),
+		}
+		if achievement.ResourceID != 0 {
+			resourceID := achievement.ResourceID
+			item.ResourceID = &resourceID
+		}
+		if achievement.CompletedAt != nil {
+			completedAt := achievement.CompletedAt.Format(time.RFC3339)
+			item.CompletedAt = &completedAt
+		}
+		for _, resource := range achievement.RewardResources {
+			item.RewardResources = append(item.RewardResources, ProductionBuildingLevelResourceResponse{
+				ResourceID:   resource.ResourceID,
+				ResourceName: resource.ResourceName,
+				Quantity:     resource.Quantity,
+			})
+		}
+		response = append(response, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
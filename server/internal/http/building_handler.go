@@ -0,0 +1,338 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"yourownboss/internal/auth"
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// BuildingHandler handles HTTP requests for buildings owned by companies.
+type BuildingHandler struct {
+	buildingService service.BuildingService
+	companyRepo     repository.CompanyRepository
+}
+
+// NewBuildingHandler creates a new building handler.
+func NewBuildingHandler(buildingService service.BuildingService, companyRepo repository.CompanyRepository) *BuildingHandler {
+	return &BuildingHandler{
+		buildingService: buildingService,
+		companyRepo:     companyRepo,
+	}
+}
+
+type BuyBuildingRequest struct {
+	BuildingID int64 `json:"building_id"`
+}
+
+type CompanyBuildingResponse struct {
+	ID                     int64                                     `json:"id"`
+	BuildingID             int64                                     `json:"building_id"`
+	Name                   string                                    `json:"name"`
+	Level                  int64                                     `json:"level"`
+	Upgrading              bool                                      `json:"upgrading"`
+	UpgradeFinishesAt      *string                                   `json:"upgrade_finishes_at"`
+	UnderConstruction      bool                                      `json:"under_construction"`
+	ConstructionFinishesAt *string                                   `json:"construction_finishes_at"` // Null once built
+	ConstructionPercent    int64                                     `json:"construction_percent"`     // 100 once built
+	Durability             *int64                                    `json:"durability"`               // Null for buildings that never wear
+	MaxDurability          int64                                     `json:"max_durability"`
+	Broken                 bool                                      `json:"broken"`
+	BrokenAt               *string                                   `json:"broken_at"`
+	RepairCost             int64                                     `json:"repair_cost"` // To repair all the wear now
+	RepairResources        []ProductionBuildingLevelResourceResponse `json:"repair_resources"`
+	SpeedPercent           int64                                     `json:"speed_percent"`
+	OutputPercent          int64                                     `json:"output_percent"`
+	MaxBatches             int64                                     `json:"max_batches"` // 0 = unlimited
+	Slots                  int64                                     `json:"slots"`       // Processes that can run at once
+	NextLevel              *ProductionBuildingLevelResponse          `json:"next_level"`
+	SaleValue              int64                                     `json:"sale_value"`
+	Upkeep                 int64                                     `json:"upkeep"` // Per upkeep period
+	CreatedAt              string                                    `json:"created_at"`
+}
+
+type SellBuildingResponse struct {
+	Refund int64 `json:"refund"`
+	Money  int64 `json:"money"`
+}
+
+// GetMyBuildings lists the buildings owned by the user's company.
+func (h *BuildingHandler) GetMyBuildings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	buildings, err := h.buildingService.GetCompanyBuildings(ctx, company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get buildings", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]CompanyBuildingResponse, 0, len(buildings))
+	for i := range buildings {
+		response = append(response, toCompanyBuildingResponse(&buildings[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMyBuilding returns a single building owned by the user's company.
+func (h *BuildingHandler) GetMyBuilding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	building, err := h.buildingService.GetCompanyBuilding(ctx, company.ID, companyBuildingID)
+	if err != nil {
+		respondBuildingError(w, err, "Failed to get building")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCompanyBuildingResponse(building))
+}
+
+// BuyBuilding buys a production building for the user's company.
+func (h *BuildingHandler) BuyBuilding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	var req BuyBuildingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	building, err := h.buildingService.BuyBuilding(ctx, company.ID, req.BuildingID)
+	if err != nil {
+		respondBuildingError(w, err, "Failed to buy building")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toCompanyBuildingResponse(building))
+}
+
+// UpgradeBuilding starts the upgrade of an owned building to the next level.
+func (h *BuildingHandler) UpgradeBuilding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	building, err := h.buildingService.UpgradeBuilding(ctx, company.ID, companyBuildingID)
+	if err != nil {
+		respondBuildingError(w, err, "Failed to upgrade building")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCompanyBuildingResponse(building))
+}
+
+// SellBuilding sells an owned building for its depreciated value. Buildings
+// with an active run, an upgrade or buffered resources are only sold with
+// ?force=true.
+func (h *BuildingHandler) SellBuilding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	force := false
+	if value := r.URL.Query().Get("force"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid force parameter", http.StatusBadRequest)
+			return
+		}
+		force = parsed
+	}
+
+	sale, err := h.buildingService.SellBuilding(ctx, company.ID, companyBuildingID, force)
+	if err != nil {
+		respondBuildingError(w, err, "Failed to sell building")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SellBuildingResponse{
+		Refund: sale.Refund,
+		Money:  sale.Balance,
+	})
+}
+
+// RepairBuilding restores the durability of an owned building and clears a
+// breakdown.
+func (h *BuildingHandler) RepairBuilding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	building, err := h.buildingService.RepairBuilding(ctx, company.ID, companyBuildingID)
+	if err != nil {
+		respondBuildingError(w, err, "Failed to repair building")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCompanyBuildingResponse(building))
+}
+
+func toCompanyBuildingResponse(building *service.CompanyBuildingDetails) CompanyBuildingResponse {
+	response := CompanyBuildingResponse{
+		ID:                  building.ID,
+		BuildingID:          building.BuildingID,
+		Name:                building.Name,
+		Level:               building.Level,
+		Upgrading:           building.UpgradeFinishesAt != nil,
+		UnderConstruction:   building.ConstructionFinishesAt != nil,
+		ConstructionPercent: building.ConstructionPercent,
+		Durability:          building.Durability,
+		MaxDurability:       building.MaxDurability,
+		Broken:              building.BrokenAt != nil,
+		RepairCost:          building.RepairCost,
+		RepairResources:     toLevelResourceResponses(building.RepairResources),
+		SpeedPercent:        building.SpeedPercent,
+		OutputPercent:       building.OutputPercent,
+		MaxBatches:          building.MaxBatches,
+		Slots:               building.Slots,
+		SaleValue:           building.SaleValue,
+		Upkeep:              building.Upkeep,
+		CreatedAt:           building.CreatedAt.Format(time.RFC3339),
+	}
+
+	if building.UpgradeFinishesAt != nil {
+		finishesAt := building.UpgradeFinishesAt.Format(time.RFC3339)
+		response.UpgradeFinishesAt = &finishesAt
+	}
+	if building.ConstructionFinishesAt != nil {
+		finishesAt := building.ConstructionFinishesAt.Format(time.RFC3339)
+		response.ConstructionFinishesAt = &finishesAt
+	}
+	if building.BrokenAt != nil {
+		brokenAt := building.BrokenAt.Format(time.RFC3339)
+		response.BrokenAt = &brokenAt
+	}
+	if building.NextLevel != nil {
+		next := toLevelResponse(*building.NextLevel)
+		response.NextLevel = &next
+	}
+
+	return response
+}
+
+// respondBuildingError maps errors shared by the owned building endpoints.
+func respondBuildingError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrBuildingNotFound:
+		http.Error(w, "Production building not found", http.StatusNotFound)
+	case service.ErrCompanyBuildingNotFound:
+		http.Error(w, "Building not found", http.StatusNotFound)
+	case service.ErrInsufficientFunds:
+		http.Error(w, "Insufficient funds", http.StatusBadRequest)
+	case repository.ErrInsufficientStock:
+		http.Error(w, "Insufficient stock", http.StatusBadRequest)
+	case repository.ErrStorageFull:
+		http.Error(w, "Not enough storage capacity", http.StatusBadRequest)
+	case service.ErrStorageInUse:
+		http.Error(w, "Storage is in use, free some space before selling", http.StatusConflict)
+	case service.ErrMaxLevelReached:
+		http.Error(w, "Building is already at max level", http.StatusConflict)
+	case service.ErrBuildingUpgrading:
+		http.Error(w, "Building is being upgraded", http.StatusConflict)
+	case service.ErrBuildingUnderConstruction:
+		http.Error(w, "Building is under construction", http.StatusConflict)
+	case service.ErrBuildingBusy:
+		http.Error(w, "Building has an active production run", http.StatusConflict)
+	case service.ErrBuildingBroken:
+		http.Error(w, "Building is broken down, repair it first", http.StatusConflict)
+	case service.ErrNothingToRepair:
+		http.Error(w, "Building has no wear to repair", http.StatusConflict)
+	case service.ErrRepairConflict:
+		http.Error(w, "Building changed during the repair, try again", http.StatusConflict)
+	case service.ErrBufferNotEmpty:
+		http.Error(w, "Building has buffered resources", http.StatusConflict)
+	case service.ErrBuildingLocked:
+		http.Error(w, "Building is locked by research", http.StatusForbidden)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// companyFromRequest loads the company of the authenticated user and writes
+// the error response when it can't.
+func companyFromRequest(w http.ResponseWriter, r *http.Request, companyRepo repository.CompanyRepository) (*db.Company, bool) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	company, err := companyRepo.GetByUserID(r.Context(), userID)
+	if err != nil {
+		if err == repository.ErrCompanyNotFound {
+			http.Error(w, "Company not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to get company", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+
+	return company, true
+}
+
+func int64URLParam(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	value, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil || value <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return value, true
+}
@@ -24,12 +24,14 @@ type CreateCompanyRequest struct {
 }
 
 type CompanyResponse struct {
-	ID        int64  `json:"id"`
-	UserID    int64  `json:"user_id"`
-	Name      string `json:"name"`
-	Money     int64  `json:"money"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	Name       string `json:"name"`
+	Money      int64  `json:"money"`
+	UpkeepDebt int64  `json:"upkeep_debt"`
+	Workers    int64  `json:"workers"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
 }
 
 func (h *CompanyHandler) CreateCompany(w http.ResponseWriter, r *http.Request) {
@@ -62,12 +64,14 @@ func (h *CompanyHandler) CreateCompany(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := CompanyResponse{
-		ID:        company.ID,
-		UserID:    company.UserID,
-		Name:      company.Name,
-		Money:     company.Money,
-		CreatedAt: company.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: company.UpdatedAt.Format(time.RFC3339),
+		ID:         company.ID,
+		UserID:     company.UserID,
+		Name:       company.Name,
+		Money:      company.Money,
+		UpkeepDebt: company.UpkeepDebt,
+		Workers:    company.Workers,
+		CreatedAt:  company.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  company.UpdatedAt.Format(time.RFC3339),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -97,12 +101,14 @@ func (h *CompanyHandler) GetMyCompany(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := CompanyResponse{
-		ID:        company.ID,
-		UserID:    company.UserID,
-		Name:      company.Name,
-		Money:     company.Money,
-		CreatedAt: company.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: company.UpdatedAt.Format(time.RFC3339),
+		ID:         company.ID,
+		UserID:     company.UserID,
+		Name:       company.Name,
+		Money:      company.Money,
+		UpkeepDebt: company.UpkeepDebt,
+		Workers:    company.Workers,
+		CreatedAt:  company.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  company.UpdatedAt.Format(time.RFC3339),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
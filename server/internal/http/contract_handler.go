@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// ContractHandler handles HTTP requests for NPC contracts.
+type ContractHandler struct {
+	contractService service.ContractService
+	companyRepo     repository.CompanyRepository
+}
+
+// NewContractHandler creates a new contract handler.
+func NewContractHandler(contractService service.ContractService, companyRepo repository.CompanyRepository) *ContractHandler {
+	return &ContractHandler{
+		contractService: contractService,
+		companyRepo:     companyRepo,
+	}
+}
+
+type ContractResponse struct {
+	ID             int64   `json:"id"`
+	Client         string  `json:"client"`
+	ResourceID     int64   `json:"resource_id"`
+	ResourceName   string  `json:"resource_name"`
+	Quantity       int64   `json:"quantity"`
+	Delivered      int64   `json:"delivered"`
+	Reward         int64   `json:"reward"`
+	Penalty        int64   `json:"penalty"` // Charged in proportion to the units not delivered
+	DurationMs     int64   `json:"duration_ms"`
+	Status         string  `json:"status"` // offered, accepted, completed or failed
+	OfferedAt      string  `json:"offered_at"`
+	OfferExpiresAt string  `json:"offer_expires_at"`
+	AcceptedAt     *string `json:"accepted_at"`
+	Deadline       *string `json:"deadline"`
+	ClosedAt       *string `json:"closed_at"`
+}
+
+type DeliverContractRequest struct {
+	Quantity int64 `json:"quantity"` // Omitted or 0 = everything left
+}
+
+type ContractDeliveryResponse struct {
+	Contract ContractResponse `json:"contract"`
+	Quantity int64            `json:"quantity"`
+	Reward   int64            `json:"reward"`
+}
+
+// GetMyContracts lists the offered, active and closed contracts of the
+// user's company.
+func (h *ContractHandler) GetMyContracts(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	contracts, err := h.contractService.GetContracts(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get contracts", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]ContractResponse, 0, len(contracts))
+	for _, contract := range contracts {
+		response = append(response, toContractResponse(contract))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AcceptContract accepts an offered contract.
+func (h *ContractHandler) AcceptContract(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	contractID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	contract, err := h.contractService.AcceptContract(r.Context(), company.ID, contractID)
+	if err != nil {
+		respondContractError(w, err, "Failed to accept contract")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toContractResponse(*contract))
+}
+
+// DeliverContract delivers goods from the inventory to an accepted contract.
+func (h *ContractHandler) DeliverContract(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	contractID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req DeliverContractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.contractService.DeliverContract(r.Context(), company.ID, contractID, req.Quantity)
+	if err != nil {
+		respondContractError(w, err, "Failed to deliver contract")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ContractDeliveryResponse{
+		Contract: toContractResponse(delivery.Contract),
+		Quantity: delivery.Quantity,
+		Reward:   delivery.Reward,
+	})
+}
+
+func toContractResponse(contract service.ContractDetails) ContractResponse {
+	response := ContractResponse{
+		ID:             contract.ID,
+		Client:         contract.Client,
+		ResourceID:     contract.ResourceID,
+		ResourceName:   contract.ResourceName,
+		Quantity:       contract.Quantity,
+		Delivered:      contract.Delivered,
+		Reward:         contract.Reward,
+		Penalty:        contract.Penalty,
+		DurationMs:     contract.DurationMs,
+		Status:         contract.Status,
+		OfferedAt:      contract.OfferedAt.Format(time.RFC3339),
+		OfferExpiresAt: contract.OfferExpiresAt.Format(time.RFC3339),
+	}
+	if contract.AcceptedAt != nil {
+		acceptedAt := contract.AcceptedAt.Format(time.RFC3339)
+		response.AcceptedAt = &acceptedAt
+	}
+	if contract.Deadline != nil {
+		deadline := contract.Deadline.Format(time.RFC3339)
+		response.Deadline = &deadline
+	}
+	if contract.ClosedAt != nil {
+		closedAt := contract.ClosedAt.Format(time.RFC3339)
+		response.ClosedAt = &closedAt
+	}
+	return response
+}
+
+func respondContractError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrContractNotFound:
+		http.Error(w, "Contract not found", http.StatusNotFound)
+	case service.ErrContractNotOffered:
+		http.Error(w, "Contract is no longer offered", http.StatusConflict)
+	case service.ErrContractNotActive:
+		http.Error(w, "Contract is not being delivered", http.StatusConflict)
+	case service.ErrDeliveryConflict:
+		http.Error(w, "Contract changed during the delivery, try again", http.StatusConflict)
+	case service.ErrInvalidDelivery:
+		http.Error(w, "Quantity cannot be negative", http.StatusBadRequest)
+	case repository.ErrInsufficientStock:
+		http.Error(w, "Insufficient stock", http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
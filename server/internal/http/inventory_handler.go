@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"yourownboss/internal/auth"
 	"yourownboss/internal/repository"
@@ -36,19 +37,32 @@ func NewMarketHandler(marketService service.MarketService, companyRepo repositor
 // --- Response Types ---
 
 type InventoryItemResponse struct {
-	ID         int64  `json:"id"`
-	ResourceID int64  `json:"resource_id"`
-	Name       string `json:"name"`
-	Quantity   int64  `json:"quantity"`
-	Price      int64  `json:"price"`     // Price per pack
-	PackSize   int64  `json:"pack_size"` // Units per pack
+	ID            int64   `json:"id"`
+	ResourceID    int64   `json:"resource_id"`
+	Name          string  `json:"name"`
+	Quality       int64   `json:"quality"` // 1 (standard) to 5
+	Quantity      int64   `json:"quantity"`
+	Price         int64   `json:"price"`           // Price per pack at standard quality
+	PackSize      int64   `json:"pack_size"`       // Units per pack
+	ShelfLifeMs   int64   `json:"shelf_life_ms"`   // 0 = never expires
+	ExpiringSoon  int64   `json:"expiring_soon"`   // Units expiring within 24h
+	NextExpiresAt *string `json:"next_expires_at"` // Null if nothing expires
 }
 
 type ResourceResponse struct {
-	ID       int64  `json:"id"`
-	Name     string `json:"name"`
-	Price    int64  `json:"price"`     // Price per pack
-	PackSize int64  `json:"pack_size"` // Units per pack
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`          // "item" or "flow"
+	Price       int64  `json:"price"`         // Price per pack
+	PackSize    int64  `json:"pack_size"`     // Units per pack
+	ShelfLifeMs int64  `json:"shelf_life_ms"` // 0 = never expires
+	Volume      int64  `json:"volume"`        // Storage used per unit
+}
+
+type StorageResponse struct {
+	Limited  bool  `json:"limited"` // False when storage is unlimited
+	Used     int64 `json:"used"`
+	Capacity int64 `json:"capacity"`
 }
 
 type BuyRequest struct {
@@ -58,6 +72,7 @@ type BuyRequest struct {
 
 type SellRequest struct {
 	ResourceID int64 `json:"resource_id"`
+	Quality    int64 `json:"quality"`    // Optional, standard quality when omitted
 	PackCount  int64 `json:"pack_count"` // Number of packs to sell
 }
 
@@ -91,14 +106,22 @@ func (h *InventoryHandler) GetInventory(w http.ResponseWriter, r *http.Request)
 
 	response := make([]InventoryItemResponse, 0)
 	for _, item := range inventory {
-		response = append(response, InventoryItemResponse{
-			ID:         item.ID,
-			ResourceID: item.ResourceID,
-			Name:       item.Name,
-			Quantity:   item.Quantity,
-			Price:      item.Price,
-			PackSize:   item.PackSize,
-		})
+		itemResponse := InventoryItemResponse{
+			ID:           item.ID,
+			ResourceID:   item.ResourceID,
+			Name:         item.Name,
+			Quality:      item.Quality,
+			Quantity:     item.Quantity,
+			Price:        item.Price,
+			PackSize:     item.PackSize,
+			ShelfLifeMs:  item.ShelfLifeMs,
+			ExpiringSoon: item.ExpiringSoon,
+		}
+		if item.NextExpiryAt != nil {
+			nextExpiresAt := item.NextExpiryAt.Format(time.RFC3339)
+			itemResponse.NextExpiresAt = &nextExpiresAt
+		}
+		response = append(response, itemResponse)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -117,10 +140,13 @@ func (h *InventoryHandler) GetResources(w http.ResponseWriter, r *http.Request)
 	response := make([]ResourceResponse, 0)
 	for _, res := range resources {
 		response = append(response, ResourceResponse{
-			ID:       res.ID,
-			Name:     res.Name,
-			Price:    res.Price,
-			PackSize: res.PackSize,
+			ID:          res.ID,
+			Name:        res.Name,
+			Type:        res.Type,
+			Price:       res.Price,
+			PackSize:    res.PackSize,
+			ShelfLifeMs: res.ShelfLifeMs,
+			Volume:      res.Volume,
 		})
 	}
 
@@ -128,6 +154,29 @@ func (h *InventoryHandler) GetResources(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetStorage returns the storage used by the company and its capacity.
+func (h *InventoryHandler) GetStorage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	usage, err := h.inventoryService.GetStorage(ctx, company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get storage", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(StorageResponse{
+		Limited:  usage.Limited,
+		Used:     usage.Used,
+		Capacity: usage.Capacity,
+	})
+}
+
 // --- Market Handler Methods ---
 
 func (h *MarketHandler) BuyResource(w http.ResponseWriter, r *http.Request) {
@@ -168,6 +217,8 @@ func (h *MarketHandler) BuyResource(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Insufficient funds", http.StatusBadRequest)
 		case repository.ErrInsufficientStock:
 			http.Error(w, "Insufficient stock", http.StatusBadRequest)
+		case repository.ErrStorageFull:
+			http.Error(w, "Not enough storage capacity", http.StatusBadRequest)
 		default:
 			http.Error(w, "Failed to buy resource", http.StatusInternalServerError)
 		}
@@ -210,11 +261,13 @@ func (h *MarketHandler) SellResource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.marketService.SellResource(ctx, company.ID, req.ResourceID, req.PackCount)
+	err = h.marketService.SellResource(ctx, company.ID, req.ResourceID, req.Quality, req.PackCount)
 	if err != nil {
 		switch err {
 		case repository.ErrInsufficientStock:
 			http.Error(w, "Insufficient stock", http.StatusBadRequest)
+		case service.ErrInvalidQuality:
+			http.Error(w, "Invalid quality", http.StatusBadRequest)
 		default:
 			http.Error(w, "Failed to sell resource", http.StatusInternalServerError)
 		}
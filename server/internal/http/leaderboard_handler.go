@@ -0,0 +1,144 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"yourownboss/internal/auth"
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// LeaderboardHandler handles HTTP requests for the public leaderboards.
+type LeaderboardHandler struct {
+	leaderboardService service.LeaderboardService
+	companyRepo        repository.CompanyRepository
+}
+
+// NewLeaderboardHandler creates a new leaderboard handler.
+func NewLeaderboardHandler(leaderboardService service.LeaderboardService, companyRepo repository.CompanyRepository) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboardService: leaderboardService,
+		companyRepo:        companyRepo,
+	}
+}
+
+type LeaderboardResponse struct {
+	Board      string                     `json:"board"`
+	Period     string                     `json:"period"`
+	ResourceID *int64                     `json:"resource_id"` // Production boards only
+	Total      int64                      `json:"total"`
+	ComputedAt *string                    `json:"computed_at"` // Nil until the board is first computed
+	Entries    []LeaderboardEntryResponse `json:"entries"`
+	MyRank     *LeaderboardEntryResponse  `json:"my_rank"` // Nil when anonymous or not ranked
+}
+
+type LeaderboardEntryResponse struct {
+	Rank        int64  `json:"rank"`
+	CompanyID   int64  `json:"company_id"`
+	CompanyName string `json:"company_name"`
+	Value       int64  `json:"value"`
+}
+
+// GetLeaderboard returns a page of a board. Supports ?period= (daily,
+// weekly or all), ?resource_id= for production boards, and ?limit= and
+// ?offset= for pagination. Authenticated users also get their company's
+// rank.
+func (h *LeaderboardHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var companyID int64
+	if userID, ok := auth.GetUserIDFromContext(ctx); ok {
+		company, err := h.companyRepo.GetByUserID(ctx, userID)
+		switch err {
+		case nil:
+			companyID = company.ID
+		case repository.ErrCompanyNotFound:
+		default:
+			http.Error(w, "Failed to get company", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	query := service.LeaderboardQuery{
+		Board:  chi.URLParam(r, "board"),
+		Period: r.URL.Query().Get("period"),
+	}
+	if raw := r.URL.Query().Get("resource_id"); raw != "" {
+		resourceID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || resourceID <= 0 {
+			http.Error(w, "Invalid resource_id parameter", http.StatusBadRequest)
+			return
+		}
+		query.ResourceID = resourceID
+	}
+
+	var ok bool
+	if query.Limit, ok = intQueryParam(w, r, "limit"); !ok {
+		return
+	}
+	if query.Offset, ok = intQueryParam(w, r, "offset"); !ok {
+		return
+	}
+
+	leaderboard, err := h.leaderboardService.GetLeaderboard(ctx, query, companyID)
+	if err != nil {
+		respondLeaderboardError(w, err, "Failed to get leaderboard")
+		return
+	}
+
+	entries := make([]LeaderboardEntryResponse, 0, len(leaderboard.Entries))
+	for _, entry := range leaderboard.Entries {
+		entries = append(entries, toLeaderboardEntryResponse(entry))
+	}
+
+	response := LeaderboardResponse{
+		Board:   leaderboard.Board,
+		Period:  leaderboard.Period,
+		Total:   leaderboard.Total,
+		Entries: entries,
+	}
+	if leaderboard.ResourceID != 0 {
+		response.ResourceID = &leaderboard.ResourceID
+	}
+	if leaderboard.ComputedAt != nil {
+		computedAt := leaderboard.ComputedAt.Format(time.RFC3339)
+		response.ComputedAt = &computedAt
+	}
+	if leaderboard.Mine != nil {
+		mine := toLeaderboardEntryResponse(*leaderboard.Mine)
+		response.MyRank = &mine
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func toLeaderboardEntryResponse(entry db.LeaderboardEntry) LeaderboardEntryResponse {
+	return LeaderboardEntryResponse{
+		Rank:        entry.Rank,
+		CompanyID:   entry.CompanyID,
+		CompanyName: entry.CompanyName,
+		Value:       entry.Value,
+	}
+}
+
+func respondLeaderboardError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrUnknownBoard:
+		http.Error(w, "Leaderboard not found", http.StatusNotFound)
+	case service.ErrInvalidPeriod:
+		http.Error(w, "Period not available for this leaderboard", http.StatusBadRequest)
+	case service.ErrInvalidBoardResource:
+		http.Error(w, "Production leaderboards need the resource_id of an item resource", http.StatusBadRequest)
+	case service.ErrUnexpectedBoardFilter:
+		http.Error(w, "Only production leaderboards take a resource_id", http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// LedgerHandler handles HTTP requests for the company money history.
+type LedgerHandler struct {
+	ledgerService service.LedgerService
+	companyRepo   repository.CompanyRepository
+}
+
+// NewLedgerHandler creates a new ledger handler.
+func NewLedgerHandler(ledgerService service.LedgerService, companyRepo repository.CompanyRepository) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+		companyRepo:   companyRepo,
+	}
+}
+
+type LedgerEntryResponse struct {
+	ID          int64  `json:"id"`
+	Amount      int64  `json:"amount"`
+	Balance     int64  `json:"balance"`
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	ReferenceID *int64 `json:"reference_id"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// GetMyLedger lists the money movements of the user's company, newest first.
+// Supports ?limit= and ?offset= for pagination.
+func (h *LedgerHandler) GetMyLedger(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	limit, ok := intQueryParam(w, r, "limit")
+	if !ok {
+		return
+	}
+	offset, ok := intQueryParam(w, r, "offset")
+	if !ok {
+		return
+	}
+
+	entries, err := h.ledgerService.GetHistory(ctx, company.ID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to get ledger", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]LedgerEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, LedgerEntryResponse{
+			ID:          entry.ID,
+			Amount:      entry.Amount,
+			Balance:     entry.Balance,
+			Kind:        entry.Kind,
+			Description: entry.Description,
+			ReferenceID: entry.ReferenceID,
+			CreatedAt:   entry.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// intQueryParam reads an optional non-negative integer query parameter.
+// Missing parameters are returned as 0.
+func intQueryParam(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, true
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		http.Error(w, "Invalid "+name+" parameter", http.StatusBadRequest)
+		return 0, false
+	}
+	return value, true
+}
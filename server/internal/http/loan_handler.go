@@ -0,0 +1,185 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// LoanHandler handles HTTP requests for bank loans.
+type LoanHandler struct {
+	loanService service.LoanService
+	companyRepo repository.CompanyRepository
+}
+
+// NewLoanHandler creates a new loan handler.
+func NewLoanHandler(loanService service.LoanService, companyRepo repository.CompanyRepository) *LoanHandler {
+	return &LoanHandler{
+		loanService: loanService,
+		companyRepo: companyRepo,
+	}
+}
+
+type LoansResponse struct {
+	NetWorth        int64          `json:"net_worth"`
+	CreditLimit     int64          `json:"credit_limit"`
+	Available       int64          `json:"available"`
+	InterestPercent float64        `json:"interest_percent"` // Per period, for new loans
+	Blocked         bool           `json:"blocked"`          // Defaulted before, can't borrow
+	Loans           []LoanResponse `json:"loans"`
+}
+
+type LoanResponse struct {
+	ID              int64   `json:"id"`
+	Principal       int64   `json:"principal"`
+	Balance         int64   `json:"balance"`
+	InterestPercent float64 `json:"interest_percent"`
+	Periods         int64   `json:"periods"`
+	PeriodsPaid     int64   `json:"periods_paid"`
+	MissedPayments  int64   `json:"missed_payments"`
+	Status          string  `json:"status"` // active, repaid or defaulted
+	NextPayment     int64   `json:"next_payment"`
+	NextPaymentAt   *string `json:"next_payment_at"`
+	TakenAt         string  `json:"taken_at"`
+	ClosedAt        *string `json:"closed_at"`
+}
+
+type TakeLoanRequest struct {
+	Amount  int64 `json:"amount"`
+	Periods int64 `json:"periods"`
+}
+
+type RepayLoanRequest struct {
+	Amount int64 `json:"amount"` // Omitted or 0 = the whole balance
+}
+
+// GetMyLoans returns the credit and loans of the user's company.
+func (h *LoanHandler) GetMyLoans(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	overview, err := h.loanService.GetLoans(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get loans", http.StatusInternalServerError)
+		return
+	}
+
+	loans := make([]LoanResponse, 0, len(overview.Loans))
+	for _, loan := range overview.Loans {
+		loans = append(loans, toLoanResponse(loan))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LoansResponse{
+		NetWorth:        overview.NetWorth,
+		CreditLimit:     overview.CreditLimit,
+		Available:       overview.Available,
+		InterestPercent: overview.InterestPercent,
+		Blocked:         overview.Blocked,
+		Loans:           loans,
+	})
+}
+
+// TakeLoan borrows money from the bank.
+func (h *LoanHandler) TakeLoan(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	var req TakeLoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	loan, err := h.loanService.TakeLoan(r.Context(), company.ID, req.Amount, req.Periods)
+	if err != nil {
+		respondLoanError(w, err, "Failed to take loan")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toLoanResponse(*loan))
+}
+
+// RepayLoan pays back a loan early.
+func (h *LoanHandler) RepayLoan(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	loanID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req RepayLoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	loan, err := h.loanService.RepayLoan(r.Context(), company.ID, loanID, req.Amount)
+	if err != nil {
+		respondLoanError(w, err, "Failed to repay loan")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toLoanResponse(*loan))
+}
+
+func toLoanResponse(loan service.LoanDetails) LoanResponse {
+	response := LoanResponse{
+		ID:              loan.ID,
+		Principal:       loan.Principal,
+		Balance:         loan.Balance,
+		InterestPercent: loan.InterestPercent,
+		Periods:         loan.Periods,
+		PeriodsPaid:     loan.PeriodsPaid,
+		MissedPayments:  loan.MissedPayments,
+		Status:          loan.Status,
+		NextPayment:     loan.NextPayment,
+		TakenAt:         loan.TakenAt.Format(time.RFC3339),
+	}
+	if loan.NextPaymentAt != nil {
+		nextPaymentAt := loan.NextPaymentAt.Format(time.RFC3339)
+		response.NextPaymentAt = &nextPaymentAt
+	}
+	if loan.ClosedAt != nil {
+		closedAt := loan.ClosedAt.Format(time.RFC3339)
+		response.ClosedAt = &closedAt
+	}
+	return response
+}
+
+func respondLoanError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrLoanNotFound:
+		http.Error(w, "Loan not found", http.StatusNotFound)
+	case service.ErrLoanClosed:
+		http.Error(w, "Loan is already closed", http.StatusConflict)
+	case service.ErrInvalidLoanAmount:
+		http.Error(w, "Amount must be positive", http.StatusBadRequest)
+	case service.ErrInvalidLoanPeriods:
+		http.Error(w, "Periods out of range", http.StatusBadRequest)
+	case service.ErrCreditLimit:
+		http.Error(w, "Loan exceeds the credit limit", http.StatusConflict)
+	case service.ErrLoansBlocked:
+		http.Error(w, "Company defaulted on a loan and can't borrow", http.StatusForbidden)
+	case service.ErrRepayConflict:
+		http.Error(w, "Loan changed during the repayment, try again", http.StatusConflict)
+	case service.ErrInsufficientFunds:
+		http.Error(w, "Insufficient funds", http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
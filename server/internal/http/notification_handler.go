@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// NotificationHandler handles HTTP requests for the notification inbox.
+type NotificationHandler struct {
+	notificationService service.NotificationService
+	companyRepo         repository.CompanyRepository
+}
+
+// NewNotificationHandler creates a new notification handler.
+func NewNotificationHandler(notificationService service.NotificationService, companyRepo repository.CompanyRepository) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		companyRepo:         companyRepo,
+	}
+}
+
+type NotificationsResponse struct {
+	UnreadCount   int64                  `json:"unread_count"` // Of the whole inbox, not just this page
+	Notifications []NotificationResponse `json:"notifications"`
+}
+
+type NotificationResponse struct {
+	ID          int64   `json:"id"`
+	Kind        string  `json:"kind"` // production_finished, contract_expiring, order_filled or building_broken
+	Message     string  `json:"message"`
+	ReferenceID *int64  `json:"reference_id"` // Run, contract, resource or company building, by kind
+	Read        bool    `json:"read"`
+	ReadAt      *string `json:"read_at"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+type MarkAllReadResponse struct {
+	Marked int64 `json:"marked"`
+}
+
+// GetNotifications lists the notifications of the user's company, newest
+// first. Supports ?unread=true to skip read ones, and ?limit= and ?offset=
+// for pagination.
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	unreadOnly := false
+	if raw := r.URL.Query().Get("unread"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "Invalid unread parameter", http.StatusBadRequest)
+			return
+		}
+		unreadOnly = parsed
+	}
+	limit, ok := intQueryParam(w, r, "limit")
+	if !ok {
+		return
+	}
+	offset, ok := intQueryParam(w, r, "offset")
+	if !ok {
+		return
+	}
+
+	inbox, err := h.notificationService.GetNotifications(r.Context(), company.ID, unreadOnly, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to get notifications", http.StatusInternalServerError)
+		return
+	}
+
+	response := NotificationsResponse{
+		UnreadCount:   inbox.UnreadCount,
+		Notifications: make([]NotificationResponse, 0, len(inbox.Notifications)),
+	}
+	for _, notification := range inbox.Notifications {
+		item := NotificationResponse{
+			ID:          notification.ID,
+			Kind:        notification.Kind,
+			Message:     notification.Message,
+			ReferenceID: notification.ReferenceID,
+			Read:        notification.ReadAt != nil,
+			CreatedAt:   notification.CreatedAt.Format(time.RFC3339),
+		}
+		if notification.ReadAt != nil {
+			readAt := notification.ReadAt.Format(time.RFC3339)
+			item.ReadAt = &readAt
+		}
+		response.Notifications = append(response.Notifications, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// MarkRead marks a notification of the user's company as read.
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	notificationID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.MarkRead(r.Context(), company.ID, notificationID); err != nil {
+		if err == service.ErrNotificationNotFound {
+			http.Error(w, "Notification not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to mark notification as read", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkAllRead marks every notification of the user's company as read.
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	marked, err := h.notificationService.MarkAllRead(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to mark notifications as read", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MarkAllReadResponse{Marked: marked})
+}
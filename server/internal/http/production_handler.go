@@ -3,25 +3,67 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
+	"yourownboss/internal/auth"
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
 	"yourownboss/internal/service"
 )
 
 // ProductionHandler handles HTTP requests for production buildings.
 type ProductionHandler struct {
 	productionService service.ProductionService
+	companyRepo       repository.CompanyRepository
 }
 
 // NewProductionHandler creates a new production handler.
-func NewProductionHandler(productionService service.ProductionService) *ProductionHandler {
-	return &ProductionHandler{productionService: productionService}
+func NewProductionHandler(productionService service.ProductionService, companyRepo repository.CompanyRepository) *ProductionHandler {
+	return &ProductionHandler{
+		productionService: productionService,
+		companyRepo:       companyRepo,
+	}
 }
 
 type ProductionBuildingResponse struct {
-	ID        int64                       `json:"id"`
-	Name      string                      `json:"name"`
-	Cost      int64                       `json:"cost"`
-	Processes []ProductionProcessResponse `json:"processes"`
+	ID                    int64                                     `json:"id"`
+	Name                  string                                    `json:"name"`
+	Cost                  int64                                     `json:"cost"`
+	Upkeep                int64                                     `json:"upkeep"` // Per upkeep period
+	StorageCapacity       int64                                     `json:"storage_capacity"`
+	Slots                 int64                                     `json:"slots"`                // Processes that can run at once
+	ConstructionTimeMs    int64                                     `json:"construction_time_ms"` // 0 = usable right after buying
+	ConstructionResources []ProductionBuildingLevelResourceResponse `json:"construction_resources"`
+	Durability            *BuildingDurabilityResponse               `json:"durability"` // Null for buildings that never wear
+	Locked                bool                                      `json:"locked"`     // Not unlocked by research yet
+	Levels                []ProductionBuildingLevelResponse         `json:"levels"`
+	Processes             []ProductionProcessResponse               `json:"processes"`
+}
+
+type BuildingDurabilityResponse struct {
+	Max                   int64                                     `json:"max"`
+	WearPerBatch          int64                                     `json:"wear_per_batch"`
+	BreakdownBelowPercent int64                                     `json:"breakdown_below_percent"`
+	RepairCost            int64                                     `json:"repair_cost"`      // Per point
+	RepairResources       []ProductionBuildingLevelResourceResponse `json:"repair_resources"` // For a full repair
+}
+
+type ProductionBuildingLevelResponse struct {
+	Level         int64                                     `json:"level"`
+	UpgradeCost   int64                                     `json:"upgrade_cost"`
+	UpgradeTimeMs int64                                     `json:"upgrade_time_ms"`
+	SpeedPercent  int64                                     `json:"speed_percent"`
+	OutputPercent int64                                     `json:"output_percent"`
+	MaxBatches    int64                                     `json:"max_batches"` // 0 = unlimited
+	Slots         int64                                     `json:"slots"`       // 0 = the building's slots
+	Resources     []ProductionBuildingLevelResourceResponse `json:"resources"`
+}
+
+type ProductionBuildingLevelResourceResponse struct {
+	ResourceID   int64  `json:"resource_id"`
+	ResourceName string `json:"resource_name"`
+	Quantity     int64  `json:"quantity"`
 }
 
 type ProductionProcessResponse struct {
@@ -30,21 +72,42 @@ type ProductionProcessResponse struct {
 	ProcessingTimeMs int64                               `json:"processing_time_ms"`
 	WindowStartHour  *int64                              `json:"window_start_hour"`
 	WindowEndHour    *int64                              `json:"window_end_hour"`
+	Workers          int64                               `json:"workers"`
+	Locked           bool                                `json:"locked"` // Not unlocked by research yet
 	Resources        []ProductionProcessResourceResponse `json:"resources"`
 }
 
 type ProductionProcessResourceResponse struct {
 	ResourceID   int64  `json:"resource_id"`
 	ResourceName string `json:"resource_name"`
+	ResourceType string `json:"resource_type"` // Flow quantities are spread over the batch time
 	Direction    string `json:"direction"`
-	Quantity     int64  `json:"quantity"`
+	Quantity     int64  `json:"quantity"`     // Smallest yield per batch for random outputs
+	Group        int64  `json:"group"`        // Inputs sharing a non-zero group are alternatives
+	MaxQuantity  int64  `json:"max_quantity"` // Largest yield per batch
+	Chance       int64  `json:"chance"`       // Percent chance of the yield per batch
 }
 
 // GetProductionBuildings returns buildings with processes and resources.
+// Entries gated by research are marked as locked unless the user's company
+// has unlocked them.
 func (h *ProductionHandler) GetProductionBuildings(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	buildings, err := h.productionService.GetProductionBuildings(ctx)
+	var companyID int64
+	if userID, ok := auth.GetUserIDFromContext(ctx); ok {
+		company, err := h.companyRepo.GetByUserID(ctx, userID)
+		switch err {
+		case nil:
+			companyID = company.ID
+		case repository.ErrCompanyNotFound:
+		default:
+			http.Error(w, "Failed to get company", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	buildings, err := h.productionService.GetProductionBuildings(ctx, companyID)
 	if err != nil {
 		http.Error(w, "Failed to get production buildings", http.StatusInternalServerError)
 		return
@@ -59,8 +122,12 @@ func (h *ProductionHandler) GetProductionBuildings(w http.ResponseWriter, r *htt
 				resources = append(resources, ProductionProcessResourceResponse{
 					ResourceID:   resource.ResourceID,
 					ResourceName: resource.ResourceName,
+					ResourceType: resource.ResourceType,
 					Direction:    resource.Direction,
 					Quantity:     resource.Quantity,
+					Group:        resource.Group,
+					MaxQuantity:  resource.MaxQuantity,
+					Chance:       resource.Chance,
 				})
 			}
 
@@ -70,18 +137,363 @@ func (h *ProductionHandler) GetProductionBuildings(w http.ResponseWriter, r *htt
 				ProcessingTimeMs: process.ProcessingTimeMs,
 				WindowStartHour:  process.WindowStartHour,
 				WindowEndHour:    process.WindowEndHour,
+				Workers:          process.Workers,
+				Locked:           process.Locked,
 				Resources:        resources,
 			})
 		}
 
+		levels := make([]ProductionBuildingLevelResponse, 0, len(building.Levels))
+		for _, level := range building.Levels {
+			levels = append(levels, toLevelResponse(level))
+		}
+
+		var durability *BuildingDurabilityResponse
+		if building.Durability != nil {
+			durability = &BuildingDurabilityResponse{
+				Max:                   building.Durability.Max,
+				WearPerBatch:          building.Durability.WearPerBatch,
+				BreakdownBelowPercent: building.Durability.BreakdownBelowPercent,
+				RepairCost:            building.Durability.RepairCost,
+				RepairResources:       toLevelResourceResponses(building.Durability.RepairResources),
+			}
+		}
+
 		response = append(response, ProductionBuildingResponse{
-			ID:        building.ID,
-			Name:      building.Name,
-			Cost:      building.Cost,
-			Processes: processes,
+			ID:                    building.ID,
+			Name:                  building.Name,
+			Cost:                  building.Cost,
+			Upkeep:                building.Upkeep,
+			StorageCapacity:       building.StorageCapacity,
+			Slots:                 building.Slots,
+			ConstructionTimeMs:    building.ConstructionTimeMs,
+			ConstructionResources: toLevelResourceResponses(building.ConstructionResources),
+			Durability:            durability,
+			Locked:                building.Locked,
+			Levels:                levels,
+			Processes:             processes,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type ProcessAnalyticsResponse struct {
+	ProcessID        int64    `json:"process_id"`
+	ProcessName      string   `json:"process_name"`
+	BuildingID       int64    `json:"building_id"`
+	BuildingName     string   `json:"building_name"`
+	BuildingCost     int64    `json:"building_cost"`
+	ProcessingTimeMs int64    `json:"processing_time_ms"`
+	WindowStartHour  *int64   `json:"window_start_hour"`
+	WindowEndHour    *int64   `json:"window_end_hour"`
+	ActiveHours      int64    `json:"active_hours"`
+	InputCost        int64    `json:"input_cost"`
+	OutputValue      int64    `json:"output_value"`      // Item outputs only, flows can't be sold
+	FlowOutputValue  int64    `json:"flow_output_value"` // Flow outputs at market price, not in the profit
+	ProfitPerBatch   int64    `json:"profit_per_batch"`
+	BatchesPerHour   float64  `json:"batches_per_hour"`
+	ProfitPerHour    int64    `json:"profit_per_hour"`
+	ReturnOnCost     float64  `json:"return_on_cost"` // Daily profit as % of building cost
+	PaybackHours     *float64 `json:"payback_hours"`
+}
+
+// GetProcessAnalytics returns profitability figures for every process.
+// Accepts ?sort=<field>&order=asc|desc (default: profit_per_hour desc).
+func (h *ProductionHandler) GetProcessAnalytics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	order := strings.ToLower(r.URL.Query().Get("order"))
+	if order != "" && order != "asc" && order != "desc" {
+		http.Error(w, "Order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	analytics, err := h.productionService.GetProcessAnalytics(ctx, r.URL.Query().Get("sort"), order != "asc")
+	if err != nil {
+		switch err {
+		case service.ErrInvalidAnalyticsSort:
+			http.Error(w, "Invalid sort field", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to get process analytics", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := make([]ProcessAnalyticsResponse, 0, len(analytics))
+	for _, item := range analytics {
+		response = append(response, ProcessAnalyticsResponse{
+			ProcessID:        item.ProcessID,
+			ProcessName:      item.ProcessName,
+			BuildingID:       item.BuildingID,
+			BuildingName:     item.BuildingName,
+			BuildingCost:     item.BuildingCost,
+			ProcessingTimeMs: item.ProcessingTimeMs,
+			WindowStartHour:  item.WindowStartHour,
+			WindowEndHour:    item.WindowEndHour,
+			ActiveHours:      item.ActiveHours,
+			InputCost:        item.InputCost,
+			OutputValue:      item.OutputValue,
+			FlowOutputValue:  item.FlowOutputValue,
+			ProfitPerBatch:   item.ProfitPerBatch,
+			BatchesPerHour:   item.BatchesPerHour,
+			ProfitPerHour:    item.ProfitPerHour,
+			ReturnOnCost:     item.ReturnOnCost,
+			PaybackHours:     item.PaybackHours,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type StartRunRequest struct {
+	ProcessID    int64   `json:"process_id"`
+	Batches      int64   `json:"batches"`
+	InputQuality string  `json:"input_quality"` // "lowest" (default) or "highest" quality inputs first
+	Inputs       []int64 `json:"inputs"`        // Resources picked for input groups, cheapest in stock otherwise
+}
+
+type ProductionRunResponse struct {
+	ID                int64   `json:"id"`
+	CompanyBuildingID int64   `json:"company_building_id"`
+	ProcessID         int64   `json:"process_id"`
+	Slot              int64   `json:"slot"`
+	Batches           int64   `json:"batches"`
+	Workers           int64   `json:"workers"`
+	Quality           int64   `json:"quality"` // Quality of the outputs
+	Seed              int64   `json:"seed"`    // Seed of the random output yields
+	StartedAt         string  `json:"started_at"`
+	FinishesAt        string  `json:"finishes_at"`
+	CollectedAt       *string `json:"collected_at"`
+}
+
+type CollectedResourceResponse struct {
+	ResourceID   int64  `json:"resource_id"`
+	ResourceName string `json:"resource_name"`
+	Quality      int64  `json:"quality"`
+	Quantity     int64  `json:"quantity"`
+	RoutedTo     *int64 `json:"routed_to"` // Building that received it by supply link, null if in inventory
+}
+
+// GetRuns returns the most recent production runs of an owned building.
+func (h *ProductionHandler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	runs, err := h.productionService.GetRuns(ctx, company.ID, companyBuildingID)
+	if err != nil {
+		respondProductionError(w, err, "Failed to get production runs")
+		return
+	}
+
+	response := make([]ProductionRunResponse, 0, len(runs))
+	for i := range runs {
+		response = append(response, toProductionRunResponse(&runs[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// StartRun starts a number of batches of a process in an owned building.
+func (h *ProductionHandler) StartRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req StartRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.productionService.StartRun(ctx, company.ID, companyBuildingID, req.ProcessID, req.Batches, service.RunOptions{
+		InputQuality: req.InputQuality,
+		Inputs:       req.Inputs,
+	})
+	if err != nil {
+		respondProductionError(w, err, "Failed to start production run")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toProductionRunResponse(run))
+}
+
+// CollectRun moves the outputs of a finished run to the inventory.
+func (h *ProductionHandler) CollectRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	runID, ok := int64URLParam(w, r, "runID")
+	if !ok {
+		return
+	}
+
+	collected, err := h.productionService.CollectRun(ctx, company.ID, companyBuildingID, runID)
+	if err != nil {
+		respondProductionError(w, err, "Failed to collect production run")
+		return
+	}
+
+	response := make([]CollectedResourceResponse, 0, len(collected))
+	for _, resource := range collected {
+		response = append(response, CollectedResourceResponse{
+			ResourceID:   resource.ResourceID,
+			ResourceName: resource.ResourceName,
+			Quality:      resource.Quality,
+			Quantity:     resource.Quantity,
+			RoutedTo:     resource.RoutedTo,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type GridFlowResponse struct {
+	ResourceID   int64   `json:"resource_id"`
+	ResourceName string  `json:"resource_name"`
+	Supply       float64 `json:"supply_per_hour"`
+	Demand       float64 `json:"demand_per_hour"`
+	Stored       int64   `json:"stored"`
+}
+
+// GetMyGrid returns the supply and demand of the flow resources (like
+// electricity) of the user's company.
+func (h *ProductionHandler) GetMyGrid(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	grid, err := h.productionService.GetGrid(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get grid", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]GridFlowResponse, 0, len(grid))
+	for _, flow := range grid {
+		response = append(response, GridFlowResponse{
+			ResourceID:   flow.ResourceID,
+			ResourceName: flow.ResourceName,
+			Supply:       flow.Supply,
+			Demand:       flow.Demand,
+			Stored:       flow.Stored,
 		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+func toProductionRunResponse(run *db.ProductionRun) ProductionRunResponse {
+	response := ProductionRunResponse{
+		ID:                run.ID,
+		CompanyBuildingID: run.CompanyBuildingID,
+		ProcessID:         run.ProcessID,
+		Slot:              run.Slot,
+		Batches:           run.Batches,
+		Workers:           run.Workers,
+		Quality:           run.Quality,
+		Seed:              run.Seed,
+		StartedAt:         run.StartedAt.Format(time.RFC3339),
+		FinishesAt:        run.FinishesAt.Format(time.RFC3339),
+	}
+	if run.CollectedAt != nil {
+		collectedAt := run.CollectedAt.Format(time.RFC3339)
+		response.CollectedAt = &collectedAt
+	}
+	return response
+}
+
+func toLevelResourceResponses(resources []service.ProductionBuildingLevelResourceDetails) []ProductionBuildingLevelResourceResponse {
+	response := make([]ProductionBuildingLevelResourceResponse, 0, len(resources))
+	for _, resource := range resources {
+		response = append(response, ProductionBuildingLevelResourceResponse{
+			ResourceID:   resource.ResourceID,
+			ResourceName: resource.ResourceName,
+			Quantity:     resource.Quantity,
+		})
+	}
+	return response
+}
+
+func toLevelResponse(level service.ProductionBuildingLevelDetails) ProductionBuildingLevelResponse {
+	resources := toLevelResourceResponses(level.Resources)
+
+	return ProductionBuildingLevelResponse{
+		Level:         level.Level,
+		UpgradeCost:   level.UpgradeCost,
+		UpgradeTimeMs: level.UpgradeTimeMs,
+		SpeedPercent:  level.SpeedPercent,
+		OutputPercent: level.OutputPercent,
+		MaxBatches:    level.MaxBatches,
+		Slots:         level.Slots,
+		Resources:     resources,
+	}
+}
+
+// respondProductionError maps errors of the production run endpoints.
+func respondProductionError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrInvalidBatches:
+		http.Error(w, "Batches must be positive", http.StatusBadRequest)
+	case service.ErrTooManyBatches:
+		http.Error(w, "Batches exceed the building capacity", http.StatusBadRequest)
+	case service.ErrProcessNotAvailable:
+		http.Error(w, "Process not available in this building", http.StatusBadRequest)
+	case service.ErrRunNotFound:
+		http.Error(w, "Production run not found", http.StatusNotFound)
+	case service.ErrRunNotFinished:
+		http.Error(w, "Production run has not finished yet", http.StatusConflict)
+	case service.ErrRunAlreadyCollected:
+		http.Error(w, "Production run already collected", http.StatusConflict)
+	case service.ErrBuildingsIdle:
+		http.Error(w, "Buildings are idle until the upkeep debt is paid", http.StatusConflict)
+	case service.ErrNoWorkersAvailable:
+		http.Error(w, "No workers available", http.StatusConflict)
+	case service.ErrNotEnoughEnergy:
+		http.Error(w, "Not enough energy to run the process", http.StatusConflict)
+	case service.ErrProcessLocked:
+		http.Error(w, "Process is locked by research", http.StatusForbidden)
+	case service.ErrInvalidQualityOrder:
+		http.Error(w, "Input quality must be lowest or highest", http.StatusBadRequest)
+	case service.ErrInvalidInputChoice:
+		http.Error(w, "Chosen input is not an option of the process", http.StatusBadRequest)
+	case service.ErrNoFreeSlot:
+		http.Error(w, "Every production slot of the building is busy", http.StatusConflict)
+	default:
+		respondBuildingError(w, err, fallback)
+	}
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// ResearchHandler handles HTTP requests for the research tree.
+type ResearchHandler struct {
+	researchService service.ResearchService
+	companyRepo     repository.CompanyRepository
+}
+
+// NewResearchHandler creates a new research handler.
+func NewResearchHandler(researchService service.ResearchService, companyRepo repository.CompanyRepository) *ResearchHandler {
+	return &ResearchHandler{
+		researchService: researchService,
+		companyRepo:     companyRepo,
+	}
+}
+
+type ResearchNodeResponse struct {
+	ID              int64                      `json:"id"`
+	Name            string                     `json:"name"`
+	Cost            int64                      `json:"cost"`
+	DurationMs      int64                      `json:"duration_ms"`
+	Prerequisites   []int64                    `json:"prerequisites"`
+	Resources       []ResearchResourceResponse `json:"resources"`
+	UnlockBuildings []int64                    `json:"unlock_buildings"`
+	UnlockProcesses []int64                    `json:"unlock_processes"`
+	Status          string                     `json:"status"` // locked, available, researching or completed
+	StartedAt       *string                    `json:"started_at"`
+	FinishesAt      *string                    `json:"finishes_at"`
+}
+
+type ResearchResourceResponse struct {
+	ResourceID   int64  `json:"resource_id"`
+	ResourceName string `json:"resource_name"`
+	Quantity     int64  `json:"quantity"`
+}
+
+// GetMyResearch lists the research nodes with their status for the user's
+// company.
+func (h *ResearchHandler) GetMyResearch(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	nodes, err := h.researchService.GetResearch(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get research", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]ResearchNodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		response = append(response, toResearchNodeResponse(node))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// StartResearch pays for a research node and starts researching it.
+func (h *ResearchHandler) StartResearch(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	nodeID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	node, err := h.researchService.StartResearch(r.Context(), company.ID, nodeID)
+	if err != nil {
+		respondResearchError(w, err, "Failed to start research")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toResearchNodeResponse(*node))
+}
+
+func toResearchNodeResponse(node service.ResearchNodeDetails) ResearchNodeResponse {
+	resources := make([]ResearchResourceResponse, 0, len(node.Resources))
+	for _, resource := range node.Resources {
+		resources = append(resources, ResearchResourceResponse{
+			ResourceID:   resource.ResourceID,
+			ResourceName: resource.ResourceName,
+			Quantity:     resource.Quantity,
+		})
+	}
+
+	response := ResearchNodeResponse{
+		ID:              node.ID,
+		Name:            node.Name,
+		Cost:            node.Cost,
+		DurationMs:      node.DurationMs,
+		Prerequisites:   nonNilIDs(node.Prerequisites),
+		Resources:       resources,
+		UnlockBuildings: nonNilIDs(node.UnlockBuildings),
+		UnlockProcesses: nonNilIDs(node.UnlockProcesses),
+		Status:          node.Status,
+	}
+	if node.StartedAt != nil {
+		startedAt := node.StartedAt.Format(time.RFC3339)
+		response.StartedAt = &startedAt
+	}
+	if node.FinishesAt != nil {
+		finishesAt := node.FinishesAt.Format(time.RFC3339)
+		response.FinishesAt = &finishesAt
+	}
+	return response
+}
+
+// nonNilIDs makes empty ID lists encode as [] instead of null.
+func nonNilIDs(ids []int64) []int64 {
+	if ids == nil {
+		return []int64{}
+	}
+	return ids
+}
+
+func respondResearchError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrResearchNotFound:
+		http.Error(w, "Research node not found", http.StatusNotFound)
+	case service.ErrResearchPrerequisites:
+		http.Error(w, "Research prerequisites are not completed", http.StatusConflict)
+	case service.ErrResearchStarted:
+		http.Error(w, "Research already started", http.StatusConflict)
+	case service.ErrResearchBusy:
+		http.Error(w, "Another research is in progress", http.StatusConflict)
+	case service.ErrInsufficientFunds:
+		http.Error(w, "Insufficient funds", http.StatusBadRequest)
+	case repository.ErrInsufficientStock:
+		http.Error(w, "Insufficient stock", http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// RushHandler handles HTTP requests for finishing runs and constructions
+// early.
+type RushHandler struct {
+	rushService service.RushService
+	companyRepo repository.CompanyRepository
+}
+
+// NewRushHandler creates a new rush handler.
+func NewRushHandler(rushService service.RushService, companyRepo repository.CompanyRepository) *RushHandler {
+	return &RushHandler{
+		rushService: rushService,
+		companyRepo: companyRepo,
+	}
+}
+
+type RushQuoteResponse struct {
+	RemainingMs int64 `json:"remaining_ms"` // Work left, without closed time window hours
+	Cost        int64 `json:"cost"`
+}
+
+type RushResponse struct {
+	Cost  int64 `json:"cost"`
+	Money int64 `json:"money"`
+}
+
+// QuoteRun returns the current price of rushing an active run.
+func (h *RushHandler) QuoteRun(w http.ResponseWriter, r *http.Request) {
+	companyID, companyBuildingID, runID, ok := h.runParams(w, r)
+	if !ok {
+		return
+	}
+
+	quote, err := h.rushService.QuoteRun(r.Context(), companyID, companyBuildingID, runID)
+	if err != nil {
+		respondRushError(w, err, "Failed to quote rush")
+		return
+	}
+
+	respondRushQuote(w, quote)
+}
+
+// RushRun finishes an active run now in exchange for money.
+func (h *RushHandler) RushRun(w http.ResponseWriter, r *http.Request) {
+	companyID, companyBuildingID, runID, ok := h.runParams(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.rushService.RushRun(r.Context(), companyID, companyBuildingID, runID)
+	if err != nil {
+		respondRushError(w, err, "Failed to rush production run")
+		return
+	}
+
+	respondRush(w, result)
+}
+
+// QuoteConstruction returns the current price of rushing the construction
+// of an owned building.
+func (h *RushHandler) QuoteConstruction(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	quote, err := h.rushService.QuoteConstruction(r.Context(), company.ID, companyBuildingID)
+	if err != nil {
+		respondRushError(w, err, "Failed to quote rush")
+		return
+	}
+
+	respondRushQuote(w, quote)
+}
+
+// RushConstruction finishes the construction of an owned building now in
+// exchange for money.
+func (h *RushHandler) RushConstruction(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	result, err := h.rushService.RushConstruction(r.Context(), company.ID, companyBuildingID)
+	if err != nil {
+		respondRushError(w, err, "Failed to rush construction")
+		return
+	}
+
+	respondRush(w, result)
+}
+
+func (h *RushHandler) runParams(w http.ResponseWriter, r *http.Request) (int64, int64, int64, bool) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	companyBuildingID, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	runID, ok := int64URLParam(w, r, "runID")
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	return company.ID, companyBuildingID, runID, true
+}
+
+func respondRushQuote(w http.ResponseWriter, quote *service.RushQuote) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RushQuoteResponse{
+		RemainingMs: quote.RemainingMs,
+		Cost:        quote.Cost,
+	})
+}
+
+func respondRush(w http.ResponseWriter, result *service.RushResult) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RushResponse{
+		Cost:  result.Cost,
+		Money: result.Balance,
+	})
+}
+
+func respondRushError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrNothingToRush:
+		http.Error(w, "Already finished", http.StatusConflict)
+	default:
+		respondProductionError(w, err, fallback)
+	}
+}
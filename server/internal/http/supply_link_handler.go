@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// SupplyLinkHandler handles HTTP requests for supply links between owned
+// buildings.
+type SupplyLinkHandler struct {
+	supplyLinkService service.SupplyLinkService
+	companyRepo       repository.CompanyRepository
+}
+
+// NewSupplyLinkHandler creates a new supply link handler.
+func NewSupplyLinkHandler(supplyLinkService service.SupplyLinkService, companyRepo repository.CompanyRepository) *SupplyLinkHandler {
+	return &SupplyLinkHandler{
+		supplyLinkService: supplyLinkService,
+		companyRepo:       companyRepo,
+	}
+}
+
+type CreateSupplyLinkRequest struct {
+	SourceBuildingID int64 `json:"source_building_id"`
+	TargetBuildingID int64 `json:"target_building_id"`
+	ResourceID       int64 `json:"resource_id"`
+	ProcessID        int64 `json:"process_id"` // Process the target runs from its buffer
+}
+
+type SupplyLinkResponse struct {
+	ID                int64  `json:"id"`
+	SourceBuildingID  int64  `json:"source_building_id"`
+	TargetBuildingID  int64  `json:"target_building_id"`
+	ResourceID        int64  `json:"resource_id"`
+	ResourceName      string `json:"resource_name"`
+	TargetProcessID   int64  `json:"process_id"`
+	TargetProcessName string `json:"process_name"`
+	Buffered          int64  `json:"buffered"` // Units waiting in the target
+	CreatedAt         string `json:"created_at"`
+}
+
+// GetMyLinks lists the supply links of the user's company.
+func (h *SupplyLinkHandler) GetMyLinks(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	links, err := h.supplyLinkService.GetLinks(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get supply links", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]SupplyLinkResponse, 0, len(links))
+	for _, link := range links {
+		response = append(response, toSupplyLinkResponse(link))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateLink routes a resource collected in one owned building to another.
+func (h *SupplyLinkHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	var req CreateSupplyLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.supplyLinkService.CreateLink(
+		r.Context(),
+		company.ID,
+		req.SourceBuildingID,
+		req.TargetBuildingID,
+		req.ResourceID,
+		req.ProcessID,
+	)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidLinkResource:
+			http.Error(w, "Resource is not produced by the source or used by the target process", http.StatusBadRequest)
+		case service.ErrProcessNotAvailable:
+			http.Error(w, "Process not available in the target building", http.StatusBadRequest)
+		case service.ErrSupplyLinkExists:
+			http.Error(w, "Resource is already linked from this building", http.StatusConflict)
+		case service.ErrLinkProcessMismatch:
+			http.Error(w, "Target building is already linked to a different process", http.StatusConflict)
+		case service.ErrEndlessLoop:
+			http.Error(w, "Link would create a loop with no net consumption", http.StatusBadRequest)
+		default:
+			respondBuildingError(w, err, "Failed to create supply link")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toSupplyLinkResponse(*link))
+}
+
+// DeleteLink removes a supply link. Buffered resources no other link
+// delivers go back to the inventory.
+func (h *SupplyLinkHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	id, ok := int64URLParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.supplyLinkService.DeleteLink(r.Context(), company.ID, id); err != nil {
+		switch err {
+		case service.ErrSupplyLinkNotFound:
+			http.Error(w, "Supply link not found", http.StatusNotFound)
+		case repository.ErrStorageFull:
+			http.Error(w, "Not enough storage capacity for the buffered resources", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to delete supply link", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toSupplyLinkResponse(link service.SupplyLinkDetails) SupplyLinkResponse {
+	return SupplyLinkResponse{
+		ID:                link.ID,
+		SourceBuildingID:  link.SourceBuildingID,
+		TargetBuildingID:  link.TargetBuildingID,
+		ResourceID:        link.ResourceID,
+		ResourceName:      link.ResourceName,
+		TargetProcessID:   link.TargetProcessID,
+		TargetProcessName: link.TargetProcessName,
+		Buffered:          link.Buffered,
+		CreatedAt:         link.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// ValuationHandler handles HTTP requests for company net worth.
+type ValuationHandler struct {
+	valuationService service.ValuationService
+	companyRepo      repository.CompanyRepository
+}
+
+// NewValuationHandler creates a new valuation handler.
+func NewValuationHandler(valuationService service.ValuationService, companyRepo repository.CompanyRepository) *ValuationHandler {
+	return &ValuationHandler{
+		valuationService: valuationService,
+		companyRepo:      companyRepo,
+	}
+}
+
+type ValuationResponse struct {
+	Cash        int64  `json:"cash"`
+	Inventory   int64  `json:"inventory"`   // Stock at market sell prices
+	Buildings   int64  `json:"buildings"`   // Owned buildings at their sale value
+	Liabilities int64  `json:"liabilities"` // Upkeep debt and loan balances
+	NetWorth    int64  `json:"net_worth"`
+	ValuedAt    string `json:"valued_at"`
+}
+
+// GetMyValuation returns the current net worth of the user's company.
+func (h *ValuationHandler) GetMyValuation(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	valuation, err := h.valuationService.GetValuation(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get valuation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toValuationResponse(*valuation))
+}
+
+// GetMyValuationHistory lists the net worth snapshots of the user's company,
+// newest first. Supports ?limit= and ?offset= for pagination.
+func (h *ValuationHandler) GetMyValuationHistory(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	limit, ok := intQueryParam(w, r, "limit")
+	if !ok {
+		return
+	}
+	offset, ok := intQueryParam(w, r, "offset")
+	if !ok {
+		return
+	}
+
+	valuations, err := h.valuationService.GetHistory(r.Context(), company.ID, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to get valuation history", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]ValuationResponse, 0, len(valuations))
+	for _, valuation := range valuations {
+		response = append(response, toValuationResponse(valuation))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func toValuationResponse(valuation db.Valuation) ValuationResponse {
+	return ValuationResponse{
+		Cash:        valuation.Cash,
+		Inventory:   valuation.Inventory,
+		Buildings:   valuation.Buildings,
+		Liabilities: valuation.Liabilities,
+		NetWorth:    valuation.NetWorth,
+		ValuedAt:    valuation.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"yourownboss/internal/repository"
+	"yourownboss/internal/service"
+)
+
+// WorkforceHandler handles HTTP requests for company workers.
+type WorkforceHandler struct {
+	workforceService service.WorkforceService
+	companyRepo      repository.CompanyRepository
+}
+
+// NewWorkforceHandler creates a new workforce handler.
+func NewWorkforceHandler(workforceService service.WorkforceService, companyRepo repository.CompanyRepository) *WorkforceHandler {
+	return &WorkforceHandler{
+		workforceService: workforceService,
+		companyRepo:      companyRepo,
+	}
+}
+
+type WorkersRequest struct {
+	Count int64 `json:"count"`
+}
+
+type WorkforceResponse struct {
+	Hired     int64 `json:"hired"`
+	Busy      int64 `json:"busy"`
+	Available int64 `json:"available"`
+	Wage      int64 `json:"wage"` // Per worker and upkeep period
+}
+
+// GetMyWorkers returns the workers of the user's company.
+func (h *WorkforceHandler) GetMyWorkers(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	workforce, err := h.workforceService.GetWorkforce(r.Context(), company.ID)
+	if err != nil {
+		http.Error(w, "Failed to get workers", http.StatusInternalServerError)
+		return
+	}
+
+	respondWorkforce(w, workforce)
+}
+
+// HireWorkers hires workers for the user's company.
+func (h *WorkforceHandler) HireWorkers(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	var req WorkersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	workforce, err := h.workforceService.Hire(r.Context(), company.ID, req.Count)
+	if err != nil {
+		respondWorkforceError(w, err, "Failed to hire workers")
+		return
+	}
+
+	respondWorkforce(w, workforce)
+}
+
+// FireWorkers fires workers of the user's company. Workers busy in
+// unfinished runs can't be fired.
+func (h *WorkforceHandler) FireWorkers(w http.ResponseWriter, r *http.Request) {
+	company, ok := companyFromRequest(w, r, h.companyRepo)
+	if !ok {
+		return
+	}
+
+	var req WorkersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	workforce, err := h.workforceService.Fire(r.Context(), company.ID, req.Count)
+	if err != nil {
+		respondWorkforceError(w, err, "Failed to fire workers")
+		return
+	}
+
+	respondWorkforce(w, workforce)
+}
+
+func respondWorkforce(w http.ResponseWriter, workforce *service.Workforce) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(WorkforceResponse{
+		Hired:     workforce.Hired,
+		Busy:      workforce.Busy,
+		Available: workforce.Available,
+		Wage:      workforce.Wage,
+	})
+}
+
+func respondWorkforceError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrInvalidWorkerCount:
+		http.Error(w, "Worker count must be positive", http.StatusBadRequest)
+	case service.ErrWorkersBusy:
+		http.Error(w, "Workers are busy in production runs", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
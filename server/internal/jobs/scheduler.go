@@ -0,0 +1,89 @@
+// Package jobs runs background tasks on a fixed schedule.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Func is the work done by a job on every tick.
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      Func
+}
+
+// Scheduler runs registered jobs periodically until it is stopped.
+type Scheduler struct {
+	jobs   []job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates an empty scheduler.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers a job that runs once per interval. Jobs must be registered
+// before Start.
+func (s *Scheduler) Every(name string, interval time.Duration, run Func) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start launches every registered job in its own goroutine. The first run
+// happens after one interval; work due at startup is left to the lazy checks
+// done when players read their data.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, j := range s.jobs {
+		if j.interval <= 0 {
+			log.Printf("Job %s disabled: interval must be positive", j.name)
+			continue
+		}
+
+		s.wg.Add(1)
+		go func(j job) {
+			defer s.wg.Done()
+
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					runJob(ctx, j)
+				}
+			}
+		}(j)
+	}
+}
+
+// Stop cancels the running jobs and waits for them to return.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// runJob runs a single tick, logging errors and panics so one bad run
+// doesn't stop the job.
+func runJob(ctx context.Context, j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", j.name, r)
+		}
+	}()
+
+	if err := j.run(ctx); err != nil {
+		log.Printf("Job %s failed: %v", j.name, err)
+	}
+}
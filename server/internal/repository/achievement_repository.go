@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+
+	"yourownboss/internal/db"
+)
+
+// AchievementRepository handles the achievement definitions.
+type AchievementRepository interface {
+	GetAll(ctx context.Context) ([]db.Achievement, error)
+	Upsert(ctx context.Context, achievement db.Achievement) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type achievementRepository struct {
+	db *db.DB
+}
+
+// NewAchievementRepository creates a new achievement repository.
+func NewAchievementRepository(database *db.DB) AchievementRepository {
+	return &achievementRepository{db: database}
+}
+
+func (r *achievementRepository) GetAll(ctx context.Context) ([]db.Achievement, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, name, description, event, resource_id, condition, threshold, reward_money
+		 FROM achievements
+		 ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var achievements []db.Achievement
+	for rows.Next() {
+		var achievement db.Achievement
+		if err := rows.Scan(
+			&achievement.ID,
+			&achievement.Name,
+			&achievement.Description,
+			&achievement.Event,
+			&achievement.ResourceID,
+			&achievement.Condition,
+			&achievement.Threshold,
+			&achievement.RewardMoney,
+		); err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, achievement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range achievements {
+		if err := r.loadRewardResources(ctx, &achievements[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return achievements, nil
+}
+
+// Upsert creates or updates an achievement and replaces its reward
+// resources. Progress already made is kept.
+func (r *achievementRepository) Upsert(ctx context.Context, achievement db.Achievement) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO achievements (id, name, description, event, resource_id, condition, threshold, reward_money)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(id)
+		 DO UPDATE SET name = excluded.name,
+			description = excluded.description,
+			event = excluded.event,
+			resource_id = excluded.resource_id,
+			condition = excluded.condition,
+			threshold = excluded.threshold,
+			reward_money = excluded.reward_money`,
+		achievement.ID,
+		achievement.Name,
+		achievement.Description,
+		achievement.Event,
+		achievement.ResourceID,
+		achievement.Condition,
+		achievement.Threshold,
+		achievement.RewardMoney,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM achievement_reward_resources WHERE achievement_id = ?`, achievement.ID); err != nil {
+		return err
+	}
+
+	for _, resource := range achievement.RewardResources {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO achievement_reward_resources (achievement_id, resource_id, quantity) VALUES (?, ?, ?)
+			 ON CONFLICT(achievement_id, resource_id) DO UPDATE SET quantity = quantity + excluded.quantity`,
+			achievement.ID,
+			resource.ResourceID,
+			resource.Quantity,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *achievementRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM achievements WHERE id = ?`, id)
+	return err
+}
+
+func (r *achievementRepository) loadRewardResources(ctx context.Context, achievement *db.Achievement) error {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT resource_id, quantity FROM achievement_reward_resources WHERE achievement_id = ? ORDER BY resource_id`,
+		achievement.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	achievement.RewardResources = nil
+	for rows.Next() {
+		var resource db.ResourceQuantity
+		if err := rows.Scan(&resource.ResourceID, &resource.Quantity); err != nil {
+			return err
+		}
+		achievement.RewardResources = append(achievement.RewardResources, resource)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrInsufficientBuffer = errors.New("insufficient buffered resources")
+)
+
+// BuildingBufferRepository handles the inputs reserved in company buildings
+// by supply links. Buffered resources are not part of the inventory, and
+// like the inventory they are kept per quality tier.
+type BuildingBufferRepository interface {
+	GetByBuilding(ctx context.Context, companyBuildingID int64) ([]db.ResourceQuantity, error)
+	Add(ctx context.Context, companyBuildingID int64, item db.ResourceQuantity) error
+	Remove(ctx context.Context, companyBuildingID int64, item db.ResourceQuantity) error
+	Consume(ctx context.Context, companyBuildingID, resourceID, quantity int64, order string) ([]db.ResourceQuantity, error)
+}
+
+type buildingBufferRepository struct {
+	db *db.DB
+}
+
+// NewBuildingBufferRepository creates a new building buffer repository.
+func NewBuildingBufferRepository(database *db.DB) BuildingBufferRepository {
+	return &buildingBufferRepository{db: database}
+}
+
+func (r *buildingBufferRepository) GetByBuilding(ctx context.Context, companyBuildingID int64) ([]db.ResourceQuantity, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT resource_id, quality, quantity FROM building_buffers
+		 WHERE company_building_id = ? AND quantity > 0
+		 ORDER BY resource_id, quality`,
+		companyBuildingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buffer := make([]db.ResourceQuantity, 0)
+	for rows.Next() {
+		var item db.ResourceQuantity
+		if err := rows.Scan(&item.ResourceID, &item.Quality, &item.Quantity); err != nil {
+			return nil, err
+		}
+		buffer = append(buffer, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buffer, nil
+}
+
+func (r *buildingBufferRepository) Add(ctx context.Context, companyBuildingID int64, item db.ResourceQuantity) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO building_buffers (company_building_id, resource_id, quality, quantity) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (company_building_id, resource_id, quality) DO UPDATE SET quantity = quantity + excluded.quantity`,
+		companyBuildingID, item.ResourceID, item.QualityOrDefault(), item.Quantity,
+	)
+	return err
+}
+
+// Remove takes a quantity of one quality out of a buffer. It fails with
+// ErrInsufficientBuffer instead of leaving it negative.
+func (r *buildingBufferRepository) Remove(ctx context.Context, companyBuildingID int64, item db.ResourceQuantity) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE building_buffers SET quantity = quantity - ?
+		 WHERE company_building_id = ? AND resource_id = ? AND quality = ? AND quantity >= ?`,
+		item.Quantity, companyBuildingID, item.ResourceID, item.QualityOrDefault(), item.Quantity,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInsufficientBuffer
+	}
+
+	return nil
+}
+
+// Consume takes a quantity of any quality out of a buffer, going through the
+// qualities in the given order (lowest first unless QualityHighestFirst), and
+// returns how much of each quality it took.
+func (r *buildingBufferRepository) Consume(
+	ctx context.Context,
+	companyBuildingID, resourceID, quantity int64,
+	order string,
+) ([]db.ResourceQuantity, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	direction := "ASC"
+	if order == db.QualityHighestFirst {
+		direction = "DESC"
+	}
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT quality, quantity FROM building_buffers
+		 WHERE company_building_id = ? AND resource_id = ? AND quantity > 0
+		 ORDER BY quality `+direction,
+		companyBuildingID, resourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var stock []db.ResourceQuantity
+	var available int64
+	for rows.Next() {
+		item := db.ResourceQuantity{ResourceID: resourceID}
+		if err := rows.Scan(&item.Quality, &item.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stock = append(stock, item)
+		available += item.Quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if available < quantity {
+		return nil, ErrInsufficientBuffer
+	}
+
+	var taken []db.ResourceQuantity
+	remaining := quantity
+	for _, item := range stock {
+		if remaining <= 0 {
+			break
+		}
+		amount := min(item.Quantity, remaining)
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE building_buffers SET quantity = quantity - ?
+			 WHERE company_building_id = ? AND resource_id = ? AND quality = ?`,
+			amount, companyBuildingID, resourceID, item.Quality,
+		); err != nil {
+			return nil, err
+		}
+		taken = append(taken, db.ResourceQuantity{ResourceID: resourceID, Quality: item.Quality, Quantity: amount})
+		remaining -= amount
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return taken, nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+var ErrCompanyAchievementChanged = errors.New("company achievement changed")
+
+// CompanyAchievementRepository handles the progress of companies on
+// achievements.
+type CompanyAchievementRepository interface {
+	GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyAchievement, error)
+	GetUnrewarded(ctx context.Context, companyID int64) ([]db.CompanyAchievement, error)
+	AddProgress(ctx context.Context, companyID int64, achievement db.Achievement, amount int64) error
+	Complete(ctx context.Context, companyID int64, achievement db.Achievement, now time.Time) error
+	MarkRewarded(ctx context.Context, companyID, achievementID int64, now time.Time) error
+	UnmarkRewarded(ctx context.Context, companyID, achievementID int64) error
+}
+
+type companyAchievementRepository struct {
+	db *db.DB
+}
+
+// NewCompanyAchievementRepository creates a new company achievement repository.
+func NewCompanyAchievementRepository(database *db.DB) CompanyAchievementRepository {
+	return &companyAchievementRepository{db: database}
+}
+
+const companyAchievementColumns = `company_id, achievement_id, progress, completed_at, rewarded_at`
+
+func scanCompanyAchievement(scanner interface{ Scan(...interface{}) error }) (*db.CompanyAchievement, error) {
+	var achievement db.CompanyAchievement
+	var completedAt, rewardedAt sql.NullTime
+	if err := scanner.Scan(
+		&achievement.CompanyID,
+		&achievement.AchievementID,
+		&achievement.Progress,
+		&completedAt,
+		&rewardedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if completedAt.Valid {
+		value := completedAt.Time
+		achievement.CompletedAt = &value
+	}
+	if rewardedAt.Valid {
+		value := rewardedAt.Time
+		achievement.RewardedAt = &value
+	}
+
+	return &achievement, nil
+}
+
+// GetAllByCompany returns the achievements a company has made progress on.
+func (r *companyAchievementRepository) GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyAchievement, error) {
+	return r.query(
+		ctx,
+		`SELECT `+companyAchievementColumns+` FROM company_achievements WHERE company_id = ? ORDER BY achievement_id`,
+		companyID,
+	)
+}
+
+// GetUnrewarded returns the completed achievements of a company whose reward
+// is still pending.
+func (r *companyAchievementRepository) GetUnrewarded(ctx context.Context, companyID int64) ([]db.CompanyAchievement, error) {
+	return r.query(
+		ctx,
+		`SELECT `+companyAchievementColumns+` FROM company_achievements
+		 WHERE company_id = ? AND completed_at IS NOT NULL AND rewarded_at IS NULL
+		 ORDER BY achievement_id`,
+		companyID,
+	)
+}
+
+// AddProgress adds an event amount to the progress of a company as the
+// achievement condition says. Completed achievements keep their progress.
+func (r *companyAchievementRepository) AddProgress(ctx context.Context, companyID int64, achievement db.Achievement, amount int64) error {
+	progress := `progress + excluded.progress`
+	initial := amount
+	switch achievement.Condition {
+	case db.ConditionCount:
+		initial = 1
+	case db.ConditionMax:
+		progress = `MAX(progress, excluded.progress)`
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO company_achievements (company_id, achievement_id, progress) VALUES (?, ?, ?)
+		 ON CONFLICT(company_id, achievement_id)
+		 DO UPDATE SET progress = `+progress+` WHERE completed_at IS NULL`,
+		companyID,
+		achievement.ID,
+		initial,
+	)
+	return err
+}
+
+// Complete marks an achievement as completed if the company's progress
+// reached its threshold. It fails with ErrCompanyAchievementChanged when
+// the threshold isn't reached or the achievement was already completed, so
+// only one caller gets to pay the reward.
+func (r *companyAchievementRepository) Complete(ctx context.Context, companyID int64, achievement db.Achievement, now time.Time) error {
+	return r.update(
+		ctx,
+		`UPDATE company_achievements SET completed_at = ?
+		 WHERE company_id = ? AND achievement_id = ? AND completed_at IS NULL AND progress >= ?`,
+		db.Timestamp(now),
+		companyID,
+		achievement.ID,
+		achievement.Threshold,
+	)
+}
+
+// MarkRewarded records the reward of a completed achievement as paid. It
+// fails with ErrCompanyAchievementChanged if it was already paid.
+func (r *companyAchievementRepository) MarkRewarded(ctx context.Context, companyID, achievementID int64, now time.Time) error {
+	return r.update(
+		ctx,
+		`UPDATE company_achievements SET rewarded_at = ?
+		 WHERE company_id = ? AND achievement_id = ? AND completed_at IS NOT NULL AND rewarded_at IS NULL`,
+		db.Timestamp(now),
+		companyID,
+		achievementID,
+	)
+}
+
+// UnmarkRewarded leaves the reward of an achievement pending again, used
+// when paying it failed.
+func (r *companyAchievementRepository) UnmarkRewarded(ctx context.Context, companyID, achievementID int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE company_achievements SET rewarded_at = NULL WHERE company_id = ? AND achievement_id = ?`,
+		companyID,
+		achievementID,
+	)
+	return err
+}
+
+func (r *companyAchievementRepository) query(ctx context.Context, query string, args ...interface{}) ([]db.CompanyAchievement, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var achievements []db.CompanyAchievement
+	for rows.Next() {
+		achievement, err := scanCompanyAchievement(rows)
+		if err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, *achievement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return achievements, nil
+}
+
+func (r *companyAchievementRepository) update(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCompanyAchievementChanged
+	}
+
+	return nil
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrCompanyBuildingNotFound = errors.New("company building not found")
+	ErrUpgradeInProgress       = errors.New("building upgrade already in progress")
+	ErrUpkeepAlreadyCharged    = errors.New("building upkeep already charged")
+	ErrWearChanged             = errors.New("building wear changed")
+	ErrConstructionChanged     = errors.New("building construction changed")
+)
+
+// CompanyBuildingRepository handles buildings owned by companies.
+type CompanyBuildingRepository interface {
+	Create(ctx context.Context, companyID, buildingID int64, constructionFinishesAt *time.Time) (*db.CompanyBuilding, error)
+	GetByID(ctx context.Context, id int64) (*db.CompanyBuilding, error)
+	GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyBuilding, error)
+	StartUpgrade(ctx context.Context, id int64, finishesAt time.Time) error
+	CompleteUpgrade(ctx context.Context, id int64) error
+	MarkUpkeepPaid(ctx context.Context, id, paidPeriods, newPaidPeriods int64) error
+	AddWear(ctx context.Context, id, points int64, brokenAt *time.Time) error
+	Repair(ctx context.Context, id, wear int64) error
+	FinishConstruction(ctx context.Context, id int64, finishesAt, now time.Time) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type companyBuildingRepository struct {
+	db *db.DB
+}
+
+// NewCompanyBuildingRepository creates a new company building repository.
+func NewCompanyBuildingRepository(database *db.DB) CompanyBuildingRepository {
+	return &companyBuildingRepository{db: database}
+}
+
+const companyBuildingColumns = `id, company_id, building_id, level, upgrade_finishes_at, construction_finishes_at,
+	wear, broken_at, upkeep_periods_paid, created_at, updated_at`
+
+func scanCompanyBuilding(scanner interface{ Scan(...interface{}) error }) (*db.CompanyBuilding, error) {
+	var building db.CompanyBuilding
+	var upgradeFinishesAt sql.NullTime
+	var constructionFinishesAt sql.NullTime
+	var brokenAt sql.NullTime
+	if err := scanner.Scan(
+		&building.ID,
+		&building.CompanyID,
+		&building.BuildingID,
+		&building.Level,
+		&upgradeFinishesAt,
+		&constructionFinishesAt,
+		&building.Wear,
+		&brokenAt,
+		&building.UpkeepPeriodsPaid,
+		&building.CreatedAt,
+		&building.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if upgradeFinishesAt.Valid {
+		value := upgradeFinishesAt.Time
+		building.UpgradeFinishesAt = &value
+	}
+	if constructionFinishesAt.Valid {
+		value := constructionFinishesAt.Time
+		building.ConstructionFinishesAt = &value
+	}
+	if brokenAt.Valid {
+		value := brokenAt.Time
+		building.BrokenAt = &value
+	}
+
+	return &building, nil
+}
+
+// Create adds a building to the company. Buildings with a construction
+// time can't be used until constructionFinishesAt.
+func (r *companyBuildingRepository) Create(
+	ctx context.Context,
+	companyID, buildingID int64,
+	constructionFinishesAt *time.Time,
+) (*db.CompanyBuilding, error) {
+	var finishesAt interface{}
+	if constructionFinishesAt != nil {
+		finishesAt = db.Timestamp(*constructionFinishesAt)
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO company_buildings (company_id, building_id, construction_finishes_at) VALUES (?, ?, ?)`,
+		companyID,
+		buildingID,
+		finishesAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *companyBuildingRepository) GetByID(ctx context.Context, id int64) (*db.CompanyBuilding, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT `+companyBuildingColumns+` FROM company_buildings WHERE id = ?`,
+		id,
+	)
+
+	building, err := scanCompanyBuilding(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCompanyBuildingNotFound
+		}
+		return nil, err
+	}
+
+	return building, nil
+}
+
+func (r *companyBuildingRepository) GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyBuilding, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+companyBuildingColumns+` FROM company_buildings WHERE company_id = ? ORDER BY id`,
+		companyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buildings []db.CompanyBuilding
+	for rows.Next() {
+		building, err := scanCompanyBuilding(rows)
+		if err != nil {
+			return nil, err
+		}
+		buildings = append(buildings, *building)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buildings, nil
+}
+
+// StartUpgrade marks the building as upgrading. It fails with
+// ErrUpgradeInProgress if another upgrade has not been completed yet.
+func (r *companyBuildingRepository) StartUpgrade(ctx context.Context, id int64, finishesAt time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE company_buildings
+		 SET upgrade_finishes_at = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND upgrade_finishes_at IS NULL`,
+		finishesAt.UTC(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUpgradeInProgress
+	}
+
+	return nil
+}
+
+// CompleteUpgrade raises the level by one and clears the pending upgrade.
+// Calling it when no upgrade is pending is a no-op, so concurrent callers
+// can't apply the same upgrade twice.
+func (r *companyBuildingRepository) CompleteUpgrade(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE company_buildings
+		 SET level = level + 1, upgrade_finishes_at = NULL, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND upgrade_finishes_at IS NOT NULL`,
+		id,
+	)
+	return err
+}
+
+// MarkUpkeepPaid moves the count of charged upkeep periods forward. It fails
+// with ErrUpkeepAlreadyCharged if the count changed since it was read, so the
+// same periods are never charged twice.
+func (r *companyBuildingRepository) MarkUpkeepPaid(ctx context.Context, id, paidPeriods, newPaidPeriods int64) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE company_buildings
+		 SET upkeep_periods_paid = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND upkeep_periods_paid = ?`,
+		newPaidPeriods,
+		id,
+		paidPeriods,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUpkeepAlreadyCharged
+	}
+
+	return nil
+}
+
+// AddWear adds lost durability points to the building. A non-nil brokenAt
+// breaks it down, keeping the time of an earlier breakdown if any.
+func (r *companyBuildingRepository) AddWear(ctx context.Context, id, points int64, brokenAt *time.Time) error {
+	var broken interface{}
+	if brokenAt != nil {
+		broken = db.Timestamp(*brokenAt)
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE company_buildings
+		 SET wear = wear + ?, broken_at = COALESCE(broken_at, ?), updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ?`,
+		points,
+		broken,
+		id,
+	)
+	return err
+}
+
+// Repair clears the wear and any breakdown of the building. It fails with
+// ErrWearChanged if the wear changed since it was read, so a repair is only
+// paid for the wear it actually removes.
+func (r *companyBuildingRepository) Repair(ctx context.Context, id, wear int64) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE company_buildings
+		 SET wear = 0, broken_at = NULL, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND wear = ?`,
+		id,
+		wear,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrWearChanged
+	}
+
+	return nil
+}
+
+// FinishConstruction makes the construction of the building finish now. It
+// fails with ErrConstructionChanged unless the construction still finishes
+// at finishesAt, so it is only finished early once.
+func (r *companyBuildingRepository) FinishConstruction(ctx context.Context, id int64, finishesAt, now time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE company_buildings
+		 SET construction_finishes_at = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND construction_finishes_at = ?`,
+		db.Timestamp(now),
+		id,
+		db.Timestamp(finishesAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrConstructionChanged
+	}
+
+	return nil
+}
+
+// Delete removes the building and its runs. It fails with
+// ErrCompanyBuildingNotFound if the building was already removed.
+func (r *companyBuildingRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM company_buildings WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCompanyBuildingNotFound
+	}
+
+	return nil
+}
@@ -12,6 +12,10 @@ import (
 var (
 	ErrCompanyAlreadyExists = errors.New("user already has a company")
 	ErrCompanyNotFound      = errors.New("company not found")
+	ErrInsufficientMoney    = errors.New("insufficient money")
+	ErrDebtAlreadyPaid      = errors.New("upkeep debt already paid")
+	ErrNotEnoughWorkers     = errors.New("not enough workers")
+	ErrWagesAlreadyPaid     = errors.New("wages already paid")
 )
 
 // CompanyRepository handles company data access
@@ -20,6 +24,11 @@ type CompanyRepository interface {
 	GetByUserID(ctx context.Context, userID int64) (*db.Company, error)
 	GetByID(ctx context.Context, id int64) (*db.Company, error)
 	UpdateMoney(ctx context.Context, id int64, newMoney int64) error
+	AdjustMoney(ctx context.Context, id int64, amount int64) (int64, error)
+	AdjustUpkeepDebt(ctx context.Context, id int64, amount int64) error
+	AdjustWorkers(ctx context.Context, id int64, amount int64, minWorkers int64) error
+	MarkWagesPaid(ctx context.Context, id int64, paidUntil *time.Time, newPaidUntil time.Time) error
+	GetAll(ctx context.Context) ([]db.Company, error)
 	Update(ctx context.Context, company *db.Company) error
 }
 
@@ -32,6 +41,32 @@ func NewCompanyRepository(database *db.DB) CompanyRepository {
 	return &companyRepository{db: database}
 }
 
+const companyColumns = `id, user_id, name, money, upkeep_debt, workers, wages_paid_until, created_at, updated_at`
+
+func scanCompany(scanner interface{ Scan(...interface{}) error }) (*db.Company, error) {
+	var company db.Company
+	var wagesPaidUntil sql.NullTime
+	if err := scanner.Scan(
+		&company.ID,
+		&company.UserID,
+		&company.Name,
+		&company.Money,
+		&company.UpkeepDebt,
+		&company.Workers,
+		&wagesPaidUntil,
+		&company.CreatedAt,
+		&company.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if wagesPaidUntil.Valid {
+		company.WagesPaidUntil = &wagesPaidUntil.Time
+	}
+
+	return &company, nil
+}
+
 func (r *companyRepository) Create(ctx context.Context, userID int64, name string, initialMoney int64) (*db.Company, error) {
 	result, err := r.db.ExecContext(
 		ctx,
@@ -62,12 +97,11 @@ func (r *companyRepository) Create(ctx context.Context, userID int64, name strin
 }
 
 func (r *companyRepository) GetByUserID(ctx context.Context, userID int64) (*db.Company, error) {
-	var company db.Company
-	err := r.db.QueryRowContext(
+	company, err := scanCompany(r.db.QueryRowContext(
 		ctx,
-		"SELECT id, user_id, name, money, created_at, updated_at FROM companies WHERE user_id = ?",
+		"SELECT "+companyColumns+" FROM companies WHERE user_id = ?",
 		userID,
-	).Scan(&company.ID, &company.UserID, &company.Name, &company.Money, &company.CreatedAt, &company.UpdatedAt)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, ErrCompanyNotFound
@@ -76,16 +110,15 @@ func (r *companyRepository) GetByUserID(ctx context.Context, userID int64) (*db.
 		return nil, err
 	}
 
-	return &company, nil
+	return company, nil
 }
 
 func (r *companyRepository) GetByID(ctx context.Context, id int64) (*db.Company, error) {
-	var company db.Company
-	err := r.db.QueryRowContext(
+	company, err := scanCompany(r.db.QueryRowContext(
 		ctx,
-		"SELECT id, user_id, name, money, created_at, updated_at FROM companies WHERE id = ?",
+		"SELECT "+companyColumns+" FROM companies WHERE id = ?",
 		id,
-	).Scan(&company.ID, &company.UserID, &company.Name, &company.Money, &company.CreatedAt, &company.UpdatedAt)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, ErrCompanyNotFound
@@ -94,7 +127,7 @@ func (r *companyRepository) GetByID(ctx context.Context, id int64) (*db.Company,
 		return nil, err
 	}
 
-	return &company, nil
+	return company, nil
 }
 
 func (r *companyRepository) UpdateMoney(ctx context.Context, id int64, newMoney int64) error {
@@ -106,6 +139,135 @@ func (r *companyRepository) UpdateMoney(ctx context.Context, id int64, newMoney
 	return err
 }
 
+// AdjustMoney adds a signed amount to the company's money in a single
+// statement and returns the new balance. It fails with ErrInsufficientMoney
+// instead of leaving the balance negative.
+func (r *companyRepository) AdjustMoney(ctx context.Context, id int64, amount int64) (int64, error) {
+	var balance int64
+	err := r.db.QueryRowContext(
+		ctx,
+		`UPDATE companies SET money = money + ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND money + ? >= 0
+		 RETURNING money`,
+		amount, id, amount,
+	).Scan(&balance)
+
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return 0, err
+		}
+		return 0, ErrInsufficientMoney
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// AdjustUpkeepDebt adds a signed amount to the company's upkeep debt. It
+// fails with ErrDebtAlreadyPaid if a payment would make the debt negative.
+func (r *companyRepository) AdjustUpkeepDebt(ctx context.Context, id int64, amount int64) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE companies SET upkeep_debt = upkeep_debt + ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND upkeep_debt + ? >= 0`,
+		amount, id, amount,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrDebtAlreadyPaid
+	}
+
+	return nil
+}
+
+// AdjustWorkers adds a signed amount to the company's hired workers. It
+// fails with ErrNotEnoughWorkers if fewer than minWorkers would be left.
+func (r *companyRepository) AdjustWorkers(ctx context.Context, id int64, amount int64, minWorkers int64) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE companies SET workers = workers + ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND workers + ? >= ?`,
+		amount, id, amount, minWorkers,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotEnoughWorkers
+	}
+
+	return nil
+}
+
+// MarkWagesPaid moves the wages paid date from paidUntil to newPaidUntil.
+// It fails with ErrWagesAlreadyPaid if the date was moved concurrently.
+func (r *companyRepository) MarkWagesPaid(ctx context.Context, id int64, paidUntil *time.Time, newPaidUntil time.Time) error {
+	var current interface{}
+	if paidUntil != nil {
+		current = db.Timestamp(*paidUntil)
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE companies SET wages_paid_until = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND wages_paid_until IS ?`,
+		db.Timestamp(newPaidUntil), id, current,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrWagesAlreadyPaid
+	}
+
+	return nil
+}
+
+func (r *companyRepository) GetAll(ctx context.Context) ([]db.Company, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+companyColumns+" FROM companies ORDER BY id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var companies []db.Company
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, err
+		}
+		companies = append(companies, *company)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return companies, nil
+}
+
 func (r *companyRepository) Update(ctx context.Context, company *db.Company) error {
 	_, err := r.db.ExecContext(
 		ctx,
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrResearchAlreadyStarted = errors.New("research already started")
+	ErrResearchInProgress     = errors.New("another research is in progress")
+)
+
+// CompanyResearchRepository handles the research nodes started by companies.
+type CompanyResearchRepository interface {
+	GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyResearch, error)
+	Start(ctx context.Context, companyID, nodeID int64, startedAt, finishesAt time.Time) error
+}
+
+type companyResearchRepository struct {
+	db *db.DB
+}
+
+// NewCompanyResearchRepository creates a new company research repository.
+func NewCompanyResearchRepository(database *db.DB) CompanyResearchRepository {
+	return &companyResearchRepository{db: database}
+}
+
+func (r *companyResearchRepository) GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyResearch, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT company_id, node_id, started_at, finishes_at
+		 FROM company_research
+		 WHERE company_id = ?
+		 ORDER BY started_at, node_id`,
+		companyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var research []db.CompanyResearch
+	for rows.Next() {
+		var item db.CompanyResearch
+		if err := rows.Scan(&item.CompanyID, &item.NodeID, &item.StartedAt, &item.FinishesAt); err != nil {
+			return nil, err
+		}
+		research = append(research, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return research, nil
+}
+
+// Start records a research node as started. Companies research one node at
+// a time, so it fails with ErrResearchInProgress while another node hasn't
+// finished, and with ErrResearchAlreadyStarted if the node was started before.
+func (r *companyResearchRepository) Start(ctx context.Context, companyID, nodeID int64, startedAt, finishesAt time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO company_research (company_id, node_id, started_at, finishes_at)
+		 SELECT ?, ?, ?, ?
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM company_research WHERE company_id = ? AND finishes_at > ?
+		 )`,
+		companyID,
+		nodeID,
+		db.Timestamp(startedAt),
+		db.Timestamp(finishesAt),
+		companyID,
+		db.Timestamp(startedAt),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrResearchAlreadyStarted
+		}
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResearchInProgress
+	}
+
+	return nil
+}
@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrContractNotFound = errors.New("contract not found")
+	ErrContractChanged  = errors.New("contract changed")
+)
+
+// ContractRepository handles the NPC orders offered to companies.
+type ContractRepository interface {
+	GetByID(ctx context.Context, id int64) (*db.Contract, error)
+	GetAllByCompany(ctx context.Context, companyID int64) ([]db.Contract, error)
+	GetOverdue(ctx context.Context, now time.Time) ([]db.Contract, error)
+	GetDueBetween(ctx context.Context, from, to time.Time) ([]db.Contract, error)
+	AddOffers(ctx context.Context, companyID int64, offers []db.Contract, maxOpen int64, now, since time.Time) (int64, error)
+	DeleteExpiredOffers(ctx context.Context, companyID int64, now time.Time) error
+	Accept(ctx context.Context, id int64, now, deadline time.Time) error
+	AddDelivered(ctx context.Context, id, quantity int64, now time.Time) error
+	UndoDelivered(ctx context.Context, id, quantity int64) error
+	Complete(ctx context.Context, id int64, now time.Time) error
+	Fail(ctx context.Context, id int64, now time.Time) error
+}
+
+type contractRepository struct {
+	db *db.DB
+}
+
+// NewContractRepository creates a new contract repository.
+func NewContractRepository(database *db.DB) ContractRepository {
+	return &contractRepository{db: database}
+}
+
+const contractColumns = `id, company_id, template_id, client, resource_id, quantity, delivered, reward, penalty,
+	duration_ms, status, offered_at, offer_expires_at, accepted_at, deadline, closed_at`
+
+func scanContract(scanner interface{ Scan(...interface{}) error }) (*db.Contract, error) {
+	var contract db.Contract
+	var acceptedAt sql.NullTime
+	var deadline sql.NullTime
+	var closedAt sql.NullTime
+	if err := scanner.Scan(
+		&contract.ID,
+		&contract.CompanyID,
+		&contract.TemplateID,
+		&contract.Client,
+		&contract.ResourceID,
+		&contract.Quantity,
+		&contract.Delivered,
+		&contract.Reward,
+		&contract.Penalty,
+		&contract.DurationMs,
+		&contract.Status,
+		&contract.OfferedAt,
+		&contract.OfferExpiresAt,
+		&acceptedAt,
+		&deadline,
+		&closedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if acceptedAt.Valid {
+		value := acceptedAt.Time
+		contract.AcceptedAt = &value
+	}
+	if deadline.Valid {
+		value := deadline.Time
+		contract.Deadline = &value
+	}
+	if closedAt.Valid {
+		value := closedAt.Time
+		contract.ClosedAt = &value
+	}
+
+	return &contract, nil
+}
+
+func (r *contractRepository) GetByID(ctx context.Context, id int64) (*db.Contract, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+contractColumns+` FROM contracts WHERE id = ?`, id)
+
+	contract, err := scanContract(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrContractNotFound
+		}
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// GetAllByCompany returns the contracts of a company, newest first.
+func (r *contractRepository) GetAllByCompany(ctx context.Context, companyID int64) ([]db.Contract, error) {
+	return r.query(
+		ctx,
+		`SELECT `+contractColumns+` FROM contracts WHERE company_id = ? ORDER BY offered_at DESC, id DESC`,
+		companyID,
+	)
+}
+
+// GetOverdue returns the accepted contracts of every company whose deadline
+// has passed.
+func (r *contractRepository) GetOverdue(ctx context.Context, now time.Time) ([]db.Contract, error) {
+	return r.query(
+		ctx,
+		`SELECT `+contractColumns+` FROM contracts WHERE status = ? AND deadline <= ? ORDER BY deadline, id`,
+		db.ContractAccepted,
+		db.Timestamp(now),
+	)
+}
+
+// GetDueBetween returns the accepted contracts of every company whose
+// deadline is after from and up to to.
+func (r *contractRepository) GetDueBetween(ctx context.Context, from, to time.Time) ([]db.Contract, error) {
+	return r.query(
+		ctx,
+		`SELECT `+contractColumns+` FROM contracts WHERE status = ? AND deadline > ? AND deadline <= ? ORDER BY deadline, id`,
+		db.ContractAccepted,
+		db.Timestamp(from),
+		db.Timestamp(to),
+	)
+}
+
+// AddOffers offers contracts to a company until it has maxOpen open offers,
+// unless it was already offered something after since. It returns how many
+// offers were added; checking and adding in one transaction keeps
+// concurrent refreshes from adding the same batch twice.
+func (r *contractRepository) AddOffers(
+	ctx context.Context,
+	companyID int64,
+	offers []db.Contract,
+	maxOpen int64,
+	now, since time.Time,
+) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var open int64
+	var recent bool
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT
+			(SELECT COUNT(*) FROM contracts WHERE company_id = ? AND status = ? AND offer_expires_at > ?),
+			EXISTS (SELECT 1 FROM contracts WHERE company_id = ? AND offered_at > ?)`,
+		companyID,
+		db.ContractOffered,
+		db.Timestamp(now),
+		companyID,
+		db.Timestamp(since),
+	).Scan(&open, &recent); err != nil {
+		return 0, err
+	}
+	if recent {
+		return 0, nil
+	}
+
+	var added int64
+	for _, offer := range offers {
+		if open+added >= maxOpen {
+			break
+		}
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO contracts (
+				company_id, template_id, client, resource_id, quantity, reward, penalty, duration_ms,
+				status, offered_at, offer_expires_at
+			 ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			companyID,
+			offer.TemplateID,
+			offer.Client,
+			offer.ResourceID,
+			offer.Quantity,
+			offer.Reward,
+			offer.Penalty,
+			offer.DurationMs,
+			db.ContractOffered,
+			db.Timestamp(offer.OfferedAt),
+			db.Timestamp(offer.OfferExpiresAt),
+		); err != nil {
+			return 0, err
+		}
+		added++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// DeleteExpiredOffers removes the offers of a company that can no longer be
+// accepted.
+func (r *contractRepository) DeleteExpiredOffers(ctx context.Context, companyID int64, now time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM contracts WHERE company_id = ? AND status = ? AND offer_expires_at <= ?`,
+		companyID,
+		db.ContractOffered,
+		db.Timestamp(now),
+	)
+	return err
+}
+
+// Accept starts an offered contract. It fails with ErrContractChanged if the
+// offer expired or was accepted since it was read.
+func (r *contractRepository) Accept(ctx context.Context, id int64, now, deadline time.Time) error {
+	return r.update(
+		ctx,
+		`UPDATE contracts SET status = ?, accepted_at = ?, deadline = ?
+		 WHERE id = ? AND status = ? AND offer_expires_at > ?`,
+		db.ContractAccepted,
+		db.Timestamp(now),
+		db.Timestamp(deadline),
+		id,
+		db.ContractOffered,
+		db.Timestamp(now),
+	)
+}
+
+// AddDelivered records units delivered to an accepted contract. It fails
+// with ErrContractChanged if the contract closed, passed its deadline or
+// would receive more than its quantity.
+func (r *contractRepository) AddDelivered(ctx context.Context, id, quantity int64, now time.Time) error {
+	return r.update(
+		ctx,
+		`UPDATE contracts SET delivered = delivered + ?
+		 WHERE id = ? AND status = ? AND deadline > ? AND delivered + ? <= quantity`,
+		quantity,
+		id,
+		db.ContractAccepted,
+		db.Timestamp(now),
+		quantity,
+	)
+}
+
+// UndoDelivered takes back units recorded by AddDelivered, used to roll
+// back a delivery whose goods couldn't be taken.
+func (r *contractRepository) UndoDelivered(ctx context.Context, id, quantity int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE contracts SET delivered = MAX(delivered - ?, 0) WHERE id = ?`,
+		quantity,
+		id,
+	)
+	return err
+}
+
+// Complete closes a fully delivered contract. It fails with
+// ErrContractChanged if it isn't fully delivered or was already closed, so
+// only one caller pays the reward.
+func (r *contractRepository) Complete(ctx context.Context, id int64, now time.Time) error {
+	return r.update(
+		ctx,
+		`UPDATE contracts SET status = ?, closed_at = ?
+		 WHERE id = ? AND status = ? AND delivered = quantity`,
+		db.ContractCompleted,
+		db.Timestamp(now),
+		id,
+		db.ContractAccepted,
+	)
+}
+
+// Fail closes an accepted contract past its deadline. It fails with
+// ErrContractChanged if it was already closed, so only one caller charges
+// the penalty.
+func (r *contractRepository) Fail(ctx context.Context, id int64, now time.Time) error {
+	return r.update(
+		ctx,
+		`UPDATE contracts SET status = ?, closed_at = ?
+		 WHERE id = ? AND status = ? AND deadline <= ?`,
+		db.ContractFailed,
+		db.Timestamp(now),
+		id,
+		db.ContractAccepted,
+		db.Timestamp(now),
+	)
+}
+
+func (r *contractRepository) query(ctx context.Context, query string, args ...interface{}) ([]db.Contract, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contracts []db.Contract
+	for rows.Next() {
+		contract, err := scanContract(rows)
+		if err != nil {
+			return nil, err
+		}
+		contracts = append(contracts, *contract)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contracts, nil
+}
+
+func (r *contractRepository) update(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrContractChanged
+	}
+
+	return nil
+}
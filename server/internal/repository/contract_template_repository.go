@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+
+	"yourownboss/internal/db"
+)
+
+// ContractTemplateRepository handles the NPC order templates.
+type ContractTemplateRepository interface {
+	GetAll(ctx context.Context) ([]db.ContractTemplate, error)
+	Upsert(ctx context.Context, template db.ContractTemplate) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type contractTemplateRepository struct {
+	db *db.DB
+}
+
+// NewContractTemplateRepository creates a new contract template repository.
+func NewContractTemplateRepository(database *db.DB) ContractTemplateRepository {
+	return &contractTemplateRepository{db: database}
+}
+
+func (r *contractTemplateRepository) GetAll(ctx context.Context) ([]db.ContractTemplate, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, client, resource_id, quantity, reward, penalty, duration_ms, offer_duration_ms,
+			min_buildings, scale_per_building
+		 FROM contract_templates
+		 ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []db.ContractTemplate
+	for rows.Next() {
+		var template db.ContractTemplate
+		if err := rows.Scan(
+			&template.ID,
+			&template.Client,
+			&template.ResourceID,
+			&template.Quantity,
+			&template.Reward,
+			&template.Penalty,
+			&template.DurationMs,
+			&template.OfferDurationMs,
+			&template.MinBuildings,
+			&template.ScalePerBuilding,
+		); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// Upsert creates or updates a template. Contracts already offered keep the
+// terms they were offered with.
+func (r *contractTemplateRepository) Upsert(ctx context.Context, template db.ContractTemplate) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO contract_templates (
+			id, client, resource_id, quantity, reward, penalty, duration_ms, offer_duration_ms,
+			min_buildings, scale_per_building
+		 ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(id)
+		 DO UPDATE SET client = excluded.client,
+			resource_id = excluded.resource_id,
+			quantity = excluded.quantity,
+			reward = excluded.reward,
+			penalty = excluded.penalty,
+			duration_ms = excluded.duration_ms,
+			offer_duration_ms = excluded.offer_duration_ms,
+			min_buildings = excluded.min_buildings,
+			scale_per_building = excluded.scale_per_building`,
+		template.ID,
+		template.Client,
+		template.ResourceID,
+		template.Quantity,
+		template.Reward,
+		template.Penalty,
+		template.DurationMs,
+		template.OfferDurationMs,
+		template.MinBuildings,
+		template.ScalePerBuilding,
+	)
+	return err
+}
+
+func (r *contractTemplateRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM contract_templates WHERE id = ?`, id)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"yourownboss/internal/db"
 )
@@ -12,24 +13,44 @@ var (
 	ErrInventoryNotFound = errors.New("inventory not found")
 	ErrResourceNotFound  = errors.New("resource not found")
 	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrStorageFull       = errors.New("not enough storage capacity")
 )
 
+// DefaultBaseStorageCapacity is the storage capacity of a company without
+// warehouses.
+const DefaultBaseStorageCapacity = 1000
+
+// StorageConfig is the optional storage capacity rule. With Limited unset
+// storage is unlimited.
+type StorageConfig struct {
+	Limited      bool
+	BaseCapacity int64 // Capacity of a company without warehouses
+}
+
 // ResourceRepository handles resource data access
 type ResourceRepository interface {
 	GetByID(ctx context.Context, id int64) (*db.Resource, error)
 	GetAll(ctx context.Context) ([]db.Resource, error)
-	Create(ctx context.Context, id int64, name string, price int64, packSize int64) (*db.Resource, error)
-	Update(ctx context.Context, id int64, name string, price int64, packSize int64) (*db.Resource, error)
+	Create(ctx context.Context, resource *db.Resource) (*db.Resource, error)
+	Update(ctx context.Context, resource *db.Resource) (*db.Resource, error)
 }
 
-// InventoryRepository handles company inventory data access
+// InventoryRepository handles company inventory data access. Stock of each
+// resource is kept per quality tier.
 type InventoryRepository interface {
-	GetByCompanyAndResource(ctx context.Context, companyID, resourceID int64) (*db.CompanyInventory, error)
+	GetByCompanyAndResource(ctx context.Context, companyID, resourceID, quality int64) (*db.CompanyInventory, error)
+	GetTotalQuantity(ctx context.Context, companyID, resourceID int64) (int64, error)
 	GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyInventory, error)
 	GetAllByCompanyWithDetails(ctx context.Context, companyID int64) ([]db.InventoryWithDetails, error)
-	AddItem(ctx context.Context, companyID, resourceID int64, quantity int64) error
-	RemoveItem(ctx context.Context, companyID, resourceID int64, quantity int64) error
-	SetQuantity(ctx context.Context, companyID, resourceID int64, quantity int64) error
+	AddItem(ctx context.Context, companyID, resourceID, quality int64, quantity int64) error
+	AddItemsAt(ctx context.Context, companyID int64, items []db.ResourceQuantity, acquiredAt time.Time) error
+	RemoveItem(ctx context.Context, companyID, resourceID, quality int64, quantity int64) error
+	ConsumeItem(ctx context.Context, companyID, resourceID int64, quantity int64, order string) ([]db.ResourceQuantity, error)
+	SetQuantity(ctx context.Context, companyID, resourceID, quality int64, quantity int64) error
+	GetLotsByCompany(ctx context.Context, companyID int64) ([]db.InventoryLot, error)
+	ExpireLots(ctx context.Context, companyID int64, now time.Time) (int64, error)
+	ExpireAllLots(ctx context.Context, now time.Time) (int64, error)
+	GetStorageUsage(ctx context.Context, companyID int64) (*db.StorageUsage, error)
 }
 
 // --- Resource Repository Implementation ---
@@ -45,12 +66,20 @@ func NewResourceRepository(database *db.DB) ResourceRepository {
 func (r *resourceRepository) GetByID(ctx context.Context, id int64) (*db.Resource, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, name, price, pack_size FROM resources WHERE id = ?`,
+		`SELECT id, name, type, price, pack_size, shelf_life_ms, volume FROM resources WHERE id = ?`,
 		id,
 	)
 
 	var resource db.Resource
-	if err := row.Scan(&resource.ID, &resource.Name, &resource.Price, &resource.PackSize); err != nil {
+	if err := row.Scan(
+		&resource.ID,
+		&resource.Name,
+		&resource.Type,
+		&resource.Price,
+		&resource.PackSize,
+		&resource.ShelfLifeMs,
+		&resource.Volume,
+	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrResourceNotFound
 		}
@@ -61,7 +90,7 @@ func (r *resourceRepository) GetByID(ctx context.Context, id int64) (*db.Resourc
 }
 
 func (r *resourceRepository) GetAll(ctx context.Context) ([]db.Resource, error) {
-	query := `SELECT id, name, price, pack_size FROM resources`
+	query := `SELECT id, name, type, price, pack_size, shelf_life_ms, volume FROM resources`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -72,7 +101,15 @@ func (r *resourceRepository) GetAll(ctx context.Context) ([]db.Resource, error)
 	var resources []db.Resource
 	for rows.Next() {
 		var resource db.Resource
-		if err := rows.Scan(&resource.ID, &resource.Name, &resource.Price, &resource.PackSize); err != nil {
+		if err := rows.Scan(
+			&resource.ID,
+			&resource.Name,
+			&resource.Type,
+			&resource.Price,
+			&resource.PackSize,
+			&resource.ShelfLifeMs,
+			&resource.Volume,
+		); err != nil {
 			return nil, err
 		}
 		resources = append(resources, resource)
@@ -85,51 +122,54 @@ func (r *resourceRepository) GetAll(ctx context.Context) ([]db.Resource, error)
 	return resources, nil
 }
 
-func (r *resourceRepository) Create(ctx context.Context, id int64, name string, price int64, packSize int64) (*db.Resource, error) {
+func (r *resourceRepository) Create(ctx context.Context, resource *db.Resource) (*db.Resource, error) {
 	_, err := r.db.ExecContext(
 		ctx,
-		`INSERT INTO resources (id, name, price, pack_size) VALUES (?, ?, ?, ?)`,
-		id, name, price, packSize,
+		`INSERT INTO resources (id, name, type, price, pack_size, shelf_life_ms, volume) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		resource.ID, resource.Name, resource.Type, resource.Price, resource.PackSize, resource.ShelfLifeMs, resource.Volume,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.GetByID(ctx, id)
+	return r.GetByID(ctx, resource.ID)
 }
 
-func (r *resourceRepository) Update(ctx context.Context, id int64, name string, price int64, packSize int64) (*db.Resource, error) {
+func (r *resourceRepository) Update(ctx context.Context, resource *db.Resource) (*db.Resource, error) {
 	_, err := r.db.ExecContext(
 		ctx,
-		`UPDATE resources SET name = ?, price = ?, pack_size = ? WHERE id = ?`,
-		name, price, packSize, id,
+		`UPDATE resources SET name = ?, type = ?, price = ?, pack_size = ?, shelf_life_ms = ?, volume = ? WHERE id = ?`,
+		resource.Name, resource.Type, resource.Price, resource.PackSize, resource.ShelfLifeMs, resource.Volume, resource.ID,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.GetByID(ctx, id)
+	return r.GetByID(ctx, resource.ID)
 }
 
 // --- Inventory Repository Implementation ---
 
 type inventoryRepository struct {
-	db *db.DB
+	db      *db.DB
+	storage StorageConfig
 }
 
-func NewInventoryRepository(database *db.DB) InventoryRepository {
-	return &inventoryRepository{db: database}
+func NewInventoryRepository(database *db.DB, storage StorageConfig) InventoryRepository {
+	return &inventoryRepository{db: database, storage: storage}
 }
 
-func (i *inventoryRepository) GetByCompanyAndResource(ctx context.Context, companyID, resourceID int64) (*db.CompanyInventory, error) {
+func (i *inventoryRepository) GetByCompanyAndResource(ctx context.Context, companyID, resourceID, quality int64) (*db.CompanyInventory, error) {
 	row := i.db.QueryRowContext(
 		ctx,
-		`SELECT id, company_id, resource_id, quantity, created_at, updated_at FROM company_inventory WHERE company_id = ? AND resource_id = ?`,
-		companyID, resourceID,
+		`SELECT id, company_id, resource_id, quality, quantity, created_at, updated_at
+		 FROM company_inventory
+		 WHERE company_id = ? AND resource_id = ? AND quality = ?`,
+		companyID, resourceID, quality,
 	)
 
 	var inv db.CompanyInventory
-	if err := row.Scan(&inv.ID, &inv.CompanyID, &inv.ResourceID, &inv.Quantity, &inv.CreatedAt, &inv.UpdatedAt); err != nil {
+	if err := row.Scan(&inv.ID, &inv.CompanyID, &inv.ResourceID, &inv.Quality, &inv.Quantity, &inv.CreatedAt, &inv.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrInventoryNotFound
 		}
@@ -139,10 +179,25 @@ func (i *inventoryRepository) GetByCompanyAndResource(ctx context.Context, compa
 	return &inv, nil
 }
 
+// GetTotalQuantity returns the company's stock of a resource across every
+// quality.
+func (i *inventoryRepository) GetTotalQuantity(ctx context.Context, companyID, resourceID int64) (int64, error) {
+	var quantity int64
+	err := i.db.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(quantity), 0) FROM company_inventory WHERE company_id = ? AND resource_id = ?`,
+		companyID, resourceID,
+	).Scan(&quantity)
+	return quantity, err
+}
+
 func (i *inventoryRepository) GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyInventory, error) {
 	rows, err := i.db.QueryContext(
 		ctx,
-		`SELECT id, company_id, resource_id, quantity, created_at, updated_at FROM company_inventory WHERE company_id = ? ORDER BY resource_id`,
+		`SELECT id, company_id, resource_id, quality, quantity, created_at, updated_at
+		 FROM company_inventory
+		 WHERE company_id = ?
+		 ORDER BY resource_id, quality`,
 		companyID,
 	)
 	if err != nil {
@@ -153,7 +208,7 @@ func (i *inventoryRepository) GetAllByCompany(ctx context.Context, companyID int
 	var inventories []db.CompanyInventory
 	for rows.Next() {
 		var inv db.CompanyInventory
-		if err := rows.Scan(&inv.ID, &inv.CompanyID, &inv.ResourceID, &inv.Quantity, &inv.CreatedAt, &inv.UpdatedAt); err != nil {
+		if err := rows.Scan(&inv.ID, &inv.CompanyID, &inv.ResourceID, &inv.Quality, &inv.Quantity, &inv.CreatedAt, &inv.UpdatedAt); err != nil {
 			return nil, err
 		}
 		inventories = append(inventories, inv)
@@ -165,12 +220,12 @@ func (i *inventoryRepository) GetAllByCompany(ctx context.Context, companyID int
 func (i *inventoryRepository) GetAllByCompanyWithDetails(ctx context.Context, companyID int64) ([]db.InventoryWithDetails, error) {
 	rows, err := i.db.QueryContext(
 		ctx,
-		`SELECT ci.id, ci.resource_id, r.name, ci.quantity, r.price, r.pack_size
+		`SELECT ci.id, ci.resource_id, r.name, ci.quality, ci.quantity, r.price, r.pack_size, r.shelf_life_ms
 		 FROM company_inventory ci
 		 JOIN resources r ON ci.resource_id = r.id
-		 WHERE ci.company_id = ?
-		 ORDER BY r.name`,
-		companyID,
+		 WHERE ci.company_id = ? AND (ci.quantity > 0 OR ci.quality = ?)
+		 ORDER BY r.name, ci.quality`,
+		companyID, db.DefaultQuality,
 	)
 	if err != nil {
 		return nil, err
@@ -180,7 +235,16 @@ func (i *inventoryRepository) GetAllByCompanyWithDetails(ctx context.Context, co
 	var details []db.InventoryWithDetails
 	for rows.Next() {
 		var item db.InventoryWithDetails
-		if err := rows.Scan(&item.ID, &item.ResourceID, &item.Name, &item.Quantity, &item.Price, &item.PackSize); err != nil {
+		if err := rows.Scan(
+			&item.ID,
+			&item.ResourceID,
+			&item.Name,
+			&item.Quality,
+			&item.Quantity,
+			&item.Price,
+			&item.PackSize,
+			&item.ShelfLifeMs,
+		); err != nil {
 			return nil, err
 		}
 		details = append(details, item)
@@ -189,50 +253,457 @@ func (i *inventoryRepository) GetAllByCompanyWithDetails(ctx context.Context, co
 	return details, rows.Err()
 }
 
-func (i *inventoryRepository) AddItem(ctx context.Context, companyID, resourceID int64, quantity int64) error {
-	_, err := i.db.ExecContext(
+func (i *inventoryRepository) AddItem(ctx context.Context, companyID, resourceID, quality int64, quantity int64) error {
+	item := db.ResourceQuantity{ResourceID: resourceID, Quality: quality, Quantity: quantity}
+	return i.AddItemsAt(ctx, companyID, []db.ResourceQuantity{item}, time.Now())
+}
+
+// AddItemsAt adds a lot acquired at the given time for each item. Lots of
+// resources with a shelf life expire that long after being acquired. With
+// storage limits on, nothing is added if the items don't fit.
+func (i *inventoryRepository) AddItemsAt(
+	ctx context.Context,
+	companyID int64,
+	items []db.ResourceQuantity,
+	acquiredAt time.Time,
+) error {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		if err := addLot(ctx, tx, companyID, item, acquiredAt); err != nil {
+			return err
+		}
+	}
+
+	if i.storage.Limited {
+		usage, err := i.storageUsage(ctx, tx, companyID)
+		if err != nil {
+			return err
+		}
+		if usage.Used > usage.Capacity {
+			return ErrStorageFull
+		}
+	}
+
+	return tx.Commit()
+}
+
+func addLot(ctx context.Context, tx *db.Tx, companyID int64, item db.ResourceQuantity, acquiredAt time.Time) error {
+	var shelfLifeMs int64
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT shelf_life_ms FROM resources WHERE id = ?`,
+		item.ResourceID,
+	).Scan(&shelfLifeMs); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrResourceNotFound
+		}
+		return err
+	}
+
+	quality := item.QualityOrDefault()
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO company_inventory (company_id, resource_id, quality, quantity, updated_at)
+		 VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(company_id, resource_id, quality) DO UPDATE SET quantity = quantity + ?, updated_at = CURRENT_TIMESTAMP`,
+		companyID, item.ResourceID, quality, item.Quantity, item.Quantity,
+	); err != nil {
+		return err
+	}
+
+	var expiresAt interface{}
+	if shelfLifeMs > 0 {
+		expiresAt = db.Timestamp(acquiredAt.Add(time.Duration(shelfLifeMs) * time.Millisecond))
+	}
+	_, err := tx.ExecContext(
 		ctx,
-		`INSERT INTO company_inventory (company_id, resource_id, quantity, updated_at)
-		 VALUES (?, ?, ?, CURRENT_TIMESTAMP)
-		 ON CONFLICT(company_id, resource_id) DO UPDATE SET quantity = quantity + ?, updated_at = CURRENT_TIMESTAMP`,
-		companyID, resourceID, quantity, quantity,
+		`INSERT INTO inventory_lots (company_id, resource_id, quality, quantity, acquired_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		companyID, item.ResourceID, quality, item.Quantity, db.Timestamp(acquiredAt), expiresAt,
 	)
 	return err
 }
 
-func (i *inventoryRepository) RemoveItem(ctx context.Context, companyID, resourceID int64, quantity int64) error {
-	// Check if we have enough stock first
-	inv, err := i.GetByCompanyAndResource(ctx, companyID, resourceID)
+// GetStorageUsage returns the storage used by the company and its capacity:
+// the base capacity plus the capacity of the warehouses it owns and has
+// finished building.
+func (i *inventoryRepository) GetStorageUsage(ctx context.Context, companyID int64) (*db.StorageUsage, error) {
+	return i.storageUsage(ctx, i.db, companyID)
+}
+
+func (i *inventoryRepository) storageUsage(
+	ctx context.Context,
+	tx interface {
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	},
+	companyID int64,
+) (*db.StorageUsage, error) {
+	usage := &db.StorageUsage{Limited: i.storage.Limited}
+
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(ci.quantity * r.volume), 0)
+		 FROM company_inventory ci
+		 JOIN resources r ON ci.resource_id = r.id
+		 WHERE ci.company_id = ?`,
+		companyID,
+	).Scan(&usage.Used); err != nil {
+		return nil, err
+	}
+
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(pb.storage_capacity), 0)
+		 FROM company_buildings cb
+		 JOIN production_buildings pb ON cb.building_id = pb.id
+		 WHERE cb.company_id = ?
+		   AND (cb.construction_finishes_at IS NULL OR cb.construction_finishes_at <= ?)`,
+		companyID,
+		db.Timestamp(time.Now()),
+	).Scan(&usage.Capacity); err != nil {
+		return nil, err
+	}
+	usage.Capacity += i.storage.BaseCapacity
+
+	return usage, nil
+}
+
+// RemoveItem removes stock of one quality oldest first, after dropping lots
+// that have already expired.
+func (i *inventoryRepository) RemoveItem(ctx context.Context, companyID, resourceID, quality int64, quantity int64) error {
+	tx, err := i.db.BeginTx(ctx, nil)
 	if err != nil {
-		if err == ErrInventoryNotFound {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := expireLots(
+		ctx,
+		tx,
+		`AND company_id = ? AND resource_id = ?`,
+		time.Now(),
+		companyID,
+		resourceID,
+	); err != nil {
+		return err
+	}
+
+	var available int64
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT quantity FROM company_inventory WHERE company_id = ? AND resource_id = ? AND quality = ?`,
+		companyID, resourceID, quality,
+	).Scan(&available); err != nil {
+		if err == sql.ErrNoRows {
 			return ErrInsufficientStock
 		}
 		return err
 	}
-
-	if inv.Quantity < quantity {
+	if available < quantity {
 		return ErrInsufficientStock
 	}
 
-	_, err = i.db.ExecContext(
+	if err := takeStock(ctx, tx, companyID, resourceID, quality, available, quantity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeItem removes stock of any quality, going through the qualities in
+// the given order (lowest first unless QualityHighestFirst), and returns how
+// much of each quality it removed.
+func (i *inventoryRepository) ConsumeItem(
+	ctx context.Context,
+	companyID, resourceID int64,
+	quantity int64,
+	order string,
+) ([]db.ResourceQuantity, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := expireLots(
+		ctx,
+		tx,
+		`AND company_id = ? AND resource_id = ?`,
+		time.Now(),
+		companyID,
+		resourceID,
+	); err != nil {
+		return nil, err
+	}
+
+	direction := "ASC"
+	if order == db.QualityHighestFirst {
+		direction = "DESC"
+	}
+	rows, err := tx.QueryContext(
 		ctx,
-		`UPDATE company_inventory SET quantity = quantity - ?, updated_at = CURRENT_TIMESTAMP WHERE company_id = ? AND resource_id = ?`,
-		quantity, companyID, resourceID,
+		`SELECT quality, quantity FROM company_inventory
+		 WHERE company_id = ? AND resource_id = ? AND quantity > 0
+		 ORDER BY quality `+direction,
+		companyID, resourceID,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+
+	var stock []db.ResourceQuantity
+	var available int64
+	for rows.Next() {
+		item := db.ResourceQuantity{ResourceID: resourceID}
+		if err := rows.Scan(&item.Quality, &item.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stock = append(stock, item)
+		available += item.Quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if available < quantity {
+		return nil, ErrInsufficientStock
+	}
+
+	var removed []db.ResourceQuantity
+	remaining := quantity
+	for _, item := range stock {
+		if remaining <= 0 {
+			break
+		}
+		taken := min(item.Quantity, remaining)
+		if err := takeStock(ctx, tx, companyID, resourceID, item.Quality, item.Quantity, taken); err != nil {
+			return nil, err
+		}
+		removed = append(removed, db.ResourceQuantity{ResourceID: resourceID, Quality: item.Quality, Quantity: taken})
+		remaining -= taken
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// takeStock removes quantity from the available stock of one quality and
+// trims its lots to match.
+func takeStock(ctx context.Context, tx *db.Tx, companyID, resourceID, quality, available, quantity int64) error {
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE company_inventory SET quantity = quantity - ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE company_id = ? AND resource_id = ? AND quality = ?`,
+		quantity, companyID, resourceID, quality,
+	); err != nil {
+		return err
+	}
+
+	return trimLots(ctx, tx, companyID, resourceID, quality, available-quantity)
 }
 
-func (i *inventoryRepository) SetQuantity(ctx context.Context, companyID, resourceID int64, quantity int64) error {
+func (i *inventoryRepository) SetQuantity(ctx context.Context, companyID, resourceID, quality int64, quantity int64) error {
 	if quantity < 0 {
 		return errors.New("quantity cannot be negative")
 	}
 
-	_, err := i.db.ExecContext(
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
 		ctx,
-		`INSERT INTO company_inventory (company_id, resource_id, quantity, updated_at)
-		 VALUES (?, ?, ?, CURRENT_TIMESTAMP)
-		 ON CONFLICT(company_id, resource_id) DO UPDATE SET quantity = ?, updated_at = CURRENT_TIMESTAMP`,
-		companyID, resourceID, quantity, quantity,
+		`INSERT INTO company_inventory (company_id, resource_id, quality, quantity, updated_at)
+		 VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(company_id, resource_id, quality) DO UPDATE SET quantity = ?, updated_at = CURRENT_TIMESTAMP`,
+		companyID, resourceID, quality, quantity, quantity,
+	); err != nil {
+		return err
+	}
+
+	if err := trimLots(ctx, tx, companyID, resourceID, quality, quantity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetLotsByCompany returns the company's lots, oldest first.
+func (i *inventoryRepository) GetLotsByCompany(ctx context.Context, companyID int64) ([]db.InventoryLot, error) {
+	rows, err := i.db.QueryContext(
+		ctx,
+		`SELECT id, company_id, resource_id, quality, quantity, acquired_at, expires_at
+		 FROM inventory_lots
+		 WHERE company_id = ?
+		 ORDER BY acquired_at, id`,
+		companyID,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lots []db.InventoryLot
+	for rows.Next() {
+		var lot db.InventoryLot
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&lot.ID, &lot.CompanyID, &lot.ResourceID, &lot.Quality, &lot.Quantity, &lot.AcquiredAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			value := expiresAt.Time
+			lot.ExpiresAt = &value
+		}
+		lots = append(lots, lot)
+	}
+
+	return lots, rows.Err()
+}
+
+// ExpireLots removes the company's lots that expired by now and returns the
+// number of units removed.
+func (i *inventoryRepository) ExpireLots(ctx context.Context, companyID int64, now time.Time) (int64, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	expired, err := expireLots(ctx, tx, `AND company_id = ?`, now, companyID)
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, tx.Commit()
+}
+
+// ExpireAllLots removes the expired lots of every company.
+func (i *inventoryRepository) ExpireAllLots(ctx context.Context, now time.Time) (int64, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	expired, err := expireLots(ctx, tx, ``, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, tx.Commit()
+}
+
+// expireLots deletes expired lots matching the extra filter and takes their
+// quantity out of the inventory totals.
+func expireLots(ctx context.Context, tx *db.Tx, filter string, now time.Time, args ...interface{}) (int64, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, company_id, resource_id, quality, quantity
+		 FROM inventory_lots
+		 WHERE expires_at IS NOT NULL AND expires_at <= ? `+filter,
+		append([]interface{}{db.Timestamp(now)}, args...)...,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var lots []db.InventoryLot
+	for rows.Next() {
+		var lot db.InventoryLot
+		if err := rows.Scan(&lot.ID, &lot.CompanyID, &lot.ResourceID, &lot.Quality, &lot.Quantity); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lots = append(lots, lot)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	for _, lot := range lots {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM inventory_lots WHERE id = ?`, lot.ID); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE company_inventory
+			 SET quantity = MAX(quantity - ?, 0), updated_at = CURRENT_TIMESTAMP
+			 WHERE company_id = ? AND resource_id = ? AND quality = ?`,
+			lot.Quantity, lot.CompanyID, lot.ResourceID, lot.Quality,
+		); err != nil {
+			return 0, err
+		}
+		expired += lot.Quantity
+	}
+
+	return expired, nil
+}
+
+// trimLots shrinks the oldest lots of a quality until they hold at most
+// remaining units. Stock without a lot is older than any lot, so it's
+// consumed first and lots only shrink once it's gone.
+func trimLots(ctx context.Context, tx *db.Tx, companyID, resourceID, quality int64, remaining int64) error {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, quantity FROM inventory_lots
+		 WHERE company_id = ? AND resource_id = ? AND quality = ?
+		 ORDER BY acquired_at, id`,
+		companyID, resourceID, quality,
+	)
+	if err != nil {
+		return err
+	}
+
+	var lots []db.InventoryLot
+	var tracked int64
+	for rows.Next() {
+		var lot db.InventoryLot
+		if err := rows.Scan(&lot.ID, &lot.Quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		lots = append(lots, lot)
+		tracked += lot.Quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	excess := tracked - remaining
+	for _, lot := range lots {
+		if excess <= 0 {
+			break
+		}
+
+		if lot.Quantity <= excess {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM inventory_lots WHERE id = ?`, lot.ID); err != nil {
+				return err
+			}
+			excess -= lot.Quantity
+			continue
+		}
+
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE inventory_lots SET quantity = quantity - ? WHERE id = ?`,
+			excess, lot.ID,
+		); err != nil {
+			return err
+		}
+		excess = 0
+	}
+
+	return nil
 }
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"yourownboss/internal/db"
+)
+
+var ErrLeaderboardEntryNotFound = errors.New("leaderboard entry not found")
+
+// LeaderboardRepository handles the precomputed leaderboard rankings.
+type LeaderboardRepository interface {
+	ReplaceAll(ctx context.Context, entries []db.LeaderboardEntry) error
+	GetPage(ctx context.Context, board, period string, resourceID int64, limit, offset int) ([]db.LeaderboardEntry, error)
+	GetByCompany(ctx context.Context, board, period string, resourceID, companyID int64) (*db.LeaderboardEntry, error)
+	Count(ctx context.Context, board, period string, resourceID int64) (int64, error)
+}
+
+type leaderboardRepository struct {
+	db *db.DB
+}
+
+// NewLeaderboardRepository creates a new leaderboard repository.
+func NewLeaderboardRepository(database *db.DB) LeaderboardRepository {
+	return &leaderboardRepository{db: database}
+}
+
+const leaderboardColumns = `e.board, e.period, e.resource_id, e.rank, e.company_id, c.name, e.value, e.computed_at`
+
+func scanLeaderboardEntry(scanner interface{ Scan(...interface{}) error }) (*db.LeaderboardEntry, error) {
+	var entry db.LeaderboardEntry
+	if err := scanner.Scan(
+		&entry.Board,
+		&entry.Period,
+		&entry.ResourceID,
+		&entry.Rank,
+		&entry.CompanyID,
+		&entry.CompanyName,
+		&entry.Value,
+		&entry.ComputedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ReplaceAll swaps every ranking for the given entries in one transaction,
+// so readers never see a board half refreshed.
+func (r *leaderboardRepository) ReplaceAll(ctx context.Context, entries []db.LeaderboardEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM leaderboard_entries`); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO leaderboard_entries (board, period, resource_id, company_id, rank, value, computed_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			entry.Board,
+			entry.Period,
+			entry.ResourceID,
+			entry.CompanyID,
+			entry.Rank,
+			entry.Value,
+			db.Timestamp(entry.ComputedAt),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetPage returns a page of a board, best ranked first.
+func (r *leaderboardRepository) GetPage(ctx context.Context, board, period string, resourceID int64, limit, offset int) ([]db.LeaderboardEntry, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+leaderboardColumns+`
+		 FROM leaderboard_entries e
+		 JOIN companies c ON c.id = e.company_id
+		 WHERE e.board = ? AND e.period = ? AND e.resource_id = ?
+		 ORDER BY e.rank, e.company_id
+		 LIMIT ? OFFSET ?`,
+		board,
+		period,
+		resourceID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []db.LeaderboardEntry
+	for rows.Next() {
+		entry, err := scanLeaderboardEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetByCompany returns the position of a company on a board.
+func (r *leaderboardRepository) GetByCompany(ctx context.Context, board, period string, resourceID, companyID int64) (*db.LeaderboardEntry, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT `+leaderboardColumns+`
+		 FROM leaderboard_entries e
+		 JOIN companies c ON c.id = e.company_id
+		 WHERE e.board = ? AND e.period = ? AND e.resource_id = ? AND e.company_id = ?`,
+		board,
+		period,
+		resourceID,
+		companyID,
+	)
+
+	entry, err := scanLeaderboardEntry(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLeaderboardEntryNotFound
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Count returns how many companies are ranked on a board.
+func (r *leaderboardRepository) Count(ctx context.Context, board, period string, resourceID int64) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM leaderboard_entries WHERE board = ? AND period = ? AND resource_id = ?`,
+		board,
+		period,
+		resourceID,
+	).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+// LedgerRepository handles the history of company money changes.
+type LedgerRepository interface {
+	Create(ctx context.Context, entry *db.LedgerEntry) error
+	GetAllByCompany(ctx context.Context, companyID int64, limit, offset int) ([]db.LedgerEntry, error)
+	GetTotals(ctx context.Context, kinds []string, since time.Time) (map[int64]int64, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type ledgerRepository struct {
+	db *db.DB
+}
+
+// NewLedgerRepository creates a new ledger repository.
+func NewLedgerRepository(database *db.DB) LedgerRepository {
+	return &ledgerRepository{db: database}
+}
+
+func (r *ledgerRepository) Create(ctx context.Context, entry *db.LedgerEntry) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO company_ledger (company_id, amount, balance, kind, description, reference_id)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.CompanyID,
+		entry.Amount,
+		entry.Balance,
+		entry.Kind,
+		entry.Description,
+		nullableInt64(entry.ReferenceID),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+
+	return nil
+}
+
+// GetAllByCompany returns the most recent entries first.
+func (r *ledgerRepository) GetAllByCompany(ctx context.Context, companyID int64, limit, offset int) ([]db.LedgerEntry, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, company_id, amount, balance, kind, description, reference_id, created_at
+		 FROM company_ledger
+		 WHERE company_id = ?
+		 ORDER BY id DESC
+		 LIMIT ? OFFSET ?`,
+		companyID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []db.LedgerEntry
+	for rows.Next() {
+		var entry db.LedgerEntry
+		var referenceID sql.NullInt64
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.CompanyID,
+			&entry.Amount,
+			&entry.Balance,
+			&entry.Kind,
+			&entry.Description,
+			&referenceID,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if referenceID.Valid {
+			value := referenceID.Int64
+			entry.ReferenceID = &value
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetTotals returns the sum of the entries of the given kinds recorded
+// after since, by company. Companies without such entries are left out. A
+// zero since sums everything.
+func (r *ledgerRepository) GetTotals(ctx context.Context, kinds []string, since time.Time) (map[int64]int64, error) {
+	totals := make(map[int64]int64)
+	if len(kinds) == 0 {
+		return totals, nil
+	}
+
+	args := make([]interface{}, 0, len(kinds)+1)
+	for _, kind := range kinds {
+		args = append(args, kind)
+	}
+	args = append(args, db.Timestamp(since))
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT company_id, SUM(amount)
+		 FROM company_ledger
+		 WHERE kind IN (?`+strings.Repeat(", ?", len(kinds)-1)+`) AND created_at > ?
+		 GROUP BY company_id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var companyID, total int64
+		if err := rows.Scan(&companyID, &total); err != nil {
+			return nil, err
+		}
+		totals[companyID] = total
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+func (r *ledgerRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM company_ledger WHERE id = ?`, id)
+	return err
+}
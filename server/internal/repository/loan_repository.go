@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrLoanNotFound        = errors.New("loan not found")
+	ErrLoanChanged         = errors.New("loan changed")
+	ErrCreditLimitExceeded = errors.New("credit limit exceeded")
+)
+
+// LoanRepository handles the loans taken by companies.
+type LoanRepository interface {
+	Create(ctx context.Context, loan *db.Loan, creditLimit int64) (*db.Loan, error)
+	GetByID(ctx context.Context, id int64) (*db.Loan, error)
+	GetAllByCompany(ctx context.Context, companyID int64) ([]db.Loan, error)
+	GetActive(ctx context.Context) ([]db.Loan, error)
+	HasDefaulted(ctx context.Context, companyID int64) (bool, error)
+	SettlePeriod(ctx context.Context, id, periodsPaid, balance, missedPayments int64) error
+	Repay(ctx context.Context, id, balance, newBalance int64) error
+	Close(ctx context.Context, id int64, status string, now time.Time) error
+	SettleDefault(ctx context.Context, id, balance, newBalance int64) error
+}
+
+type loanRepository struct {
+	db *db.DB
+}
+
+// NewLoanRepository creates a new loan repository.
+func NewLoanRepository(database *db.DB) LoanRepository {
+	return &loanRepository{db: database}
+}
+
+const loanColumns = `id, company_id, principal, balance, interest_percent, periods, periods_paid,
+	missed_payments, status, taken_at, closed_at`
+
+func scanLoan(scanner interface{ Scan(...interface{}) error }) (*db.Loan, error) {
+	var loan db.Loan
+	var closedAt sql.NullTime
+	if err := scanner.Scan(
+		&loan.ID,
+		&loan.CompanyID,
+		&loan.Principal,
+		&loan.Balance,
+		&loan.InterestPercent,
+		&loan.Periods,
+		&loan.PeriodsPaid,
+		&loan.MissedPayments,
+		&loan.Status,
+		&loan.TakenAt,
+		&closedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if closedAt.Valid {
+		value := closedAt.Time
+		loan.ClosedAt = &value
+	}
+
+	return &loan, nil
+}
+
+// Create records a new active loan. It fails with ErrCreditLimitExceeded if
+// the balance of the company's active loans would go over creditLimit, so
+// concurrent requests can't borrow past the limit together.
+func (r *loanRepository) Create(ctx context.Context, loan *db.Loan, creditLimit int64) (*db.Loan, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO loans (company_id, principal, balance, interest_percent, periods, status, taken_at)
+		 SELECT ?, ?, ?, ?, ?, ?, ?
+		 WHERE (SELECT COALESCE(SUM(balance), 0) FROM loans WHERE company_id = ? AND status = ?) + ? <= ?`,
+		loan.CompanyID,
+		loan.Principal,
+		loan.Principal,
+		loan.InterestPercent,
+		loan.Periods,
+		db.LoanActive,
+		db.Timestamp(loan.TakenAt),
+		loan.CompanyID,
+		db.LoanActive,
+		loan.Principal,
+		creditLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, ErrCreditLimitExceeded
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *loanRepository) GetByID(ctx context.Context, id int64) (*db.Loan, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+loanColumns+` FROM loans WHERE id = ?`, id)
+
+	loan, err := scanLoan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLoanNotFound
+		}
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+// GetAllByCompany returns the loans of a company, newest first.
+func (r *loanRepository) GetAllByCompany(ctx context.Context, companyID int64) ([]db.Loan, error) {
+	return r.query(ctx, `SELECT `+loanColumns+` FROM loans WHERE company_id = ? ORDER BY id DESC`, companyID)
+}
+
+// GetActive returns the active loans of every company.
+func (r *loanRepository) GetActive(ctx context.Context) ([]db.Loan, error) {
+	return r.query(ctx, `SELECT `+loanColumns+` FROM loans WHERE status = ? ORDER BY id`, db.LoanActive)
+}
+
+// HasDefaulted reports whether the company ever defaulted on a loan.
+func (r *loanRepository) HasDefaulted(ctx context.Context, companyID int64) (bool, error) {
+	var defaulted bool
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM loans WHERE company_id = ? AND status = ?)`,
+		companyID,
+		db.LoanDefaulted,
+	).Scan(&defaulted)
+	return defaulted, err
+}
+
+// SettlePeriod records the next period of an active loan as settled with
+// the resulting balance and missed payments. It fails with ErrLoanChanged
+// if the period was settled concurrently.
+func (r *loanRepository) SettlePeriod(ctx context.Context, id, periodsPaid, balance, missedPayments int64) error {
+	return r.update(
+		ctx,
+		`UPDATE loans SET periods_paid = periods_paid + 1, balance = ?, missed_payments = ?
+		 WHERE id = ? AND status = ? AND periods_paid = ?`,
+		balance,
+		missedPayments,
+		id,
+		db.LoanActive,
+		periodsPaid,
+	)
+}
+
+// Repay lowers the balance of an active loan. It fails with ErrLoanChanged
+// if the balance changed since it was read.
+func (r *loanRepository) Repay(ctx context.Context, id, balance, newBalance int64) error {
+	return r.update(
+		ctx,
+		`UPDATE loans SET balance = ? WHERE id = ? AND status = ? AND balance = ?`,
+		newBalance,
+		id,
+		db.LoanActive,
+		balance,
+	)
+}
+
+// Close marks an active loan as repaid or defaulted. It fails with
+// ErrLoanChanged if the loan was already closed.
+func (r *loanRepository) Close(ctx context.Context, id int64, status string, now time.Time) error {
+	return r.update(
+		ctx,
+		`UPDATE loans SET status = ?, closed_at = ? WHERE id = ? AND status = ?`,
+		status,
+		db.Timestamp(now),
+		id,
+		db.LoanActive,
+	)
+}
+
+// SettleDefault lowers the balance of a defaulted loan to what the bank
+// couldn't collect. It fails with ErrLoanChanged if the balance changed
+// since it was read.
+func (r *loanRepository) SettleDefault(ctx context.Context, id, balance, newBalance int64) error {
+	return r.update(
+		ctx,
+		`UPDATE loans SET balance = ? WHERE id = ? AND status = ? AND balance = ?`,
+		newBalance,
+		id,
+		db.LoanDefaulted,
+		balance,
+	)
+}
+
+func (r *loanRepository) query(ctx context.Context, query string, args ...interface{}) ([]db.Loan, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []db.Loan
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, *loan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return loans, nil
+}
+
+func (r *loanRepository) update(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLoanChanged
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationRepository handles the notifications of companies.
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *db.Notification) (bool, error)
+	GetAllByCompany(ctx context.Context, companyID int64, unreadOnly bool, limit, offset int) ([]db.Notification, error)
+	CountUnread(ctx context.Context, companyID int64) (int64, error)
+	MarkRead(ctx context.Context, companyID, id int64, now time.Time) error
+	MarkAllRead(ctx context.Context, companyID int64, now time.Time) (int64, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+type notificationRepository struct {
+	db *db.DB
+}
+
+// NewNotificationRepository creates a new notification repository.
+func NewNotificationRepository(database *db.DB) NotificationRepository {
+	return &notificationRepository{db: database}
+}
+
+const notificationColumns = `id, company_id, kind, message, reference_id, dedupe_key, read_at, created_at`
+
+func scanNotification(scanner interface{ Scan(...interface{}) error }) (*db.Notification, error) {
+	var notification db.Notification
+	var referenceID sql.NullInt64
+	var dedupeKey sql.NullString
+	var readAt sql.NullTime
+	if err := scanner.Scan(
+		&notification.ID,
+		&notification.CompanyID,
+		&notification.Kind,
+		&notification.Message,
+		&referenceID,
+		&dedupeKey,
+		&readAt,
+		&notification.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if referenceID.Valid {
+		value := referenceID.Int64
+		notification.ReferenceID = &value
+	}
+	if dedupeKey.Valid {
+		value := dedupeKey.String
+		notification.DedupeKey = &value
+	}
+	if readAt.Valid {
+		value := readAt.Time
+		notification.ReadAt = &value
+	}
+
+	return &notification, nil
+}
+
+// Create stores a new unread notification. It reports false without error
+// if a notification with the same dedupe key already exists.
+func (r *notificationRepository) Create(ctx context.Context, notification *db.Notification) (bool, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO notifications (company_id, kind, message, reference_id, dedupe_key, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (dedupe_key) DO NOTHING`,
+		notification.CompanyID,
+		notification.Kind,
+		notification.Message,
+		notification.ReferenceID,
+		notification.DedupeKey,
+		db.Timestamp(notification.CreatedAt),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// GetAllByCompany returns the notifications of a company, newest first.
+func (r *notificationRepository) GetAllByCompany(ctx context.Context, companyID int64, unreadOnly bool, limit, offset int) ([]db.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE company_id = ?`
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.QueryContext(ctx, query, companyID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []db.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, companyID int64) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM notifications WHERE company_id = ? AND read_at IS NULL`,
+		companyID,
+	).Scan(&count)
+	return count, err
+}
+
+// MarkRead marks a notification of the company as read. Marking one already
+// read does nothing; one that doesn't exist or belongs to another company
+// fails with ErrNotificationNotFound.
+func (r *notificationRepository) MarkRead(ctx context.Context, companyID, id int64, now time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE notifications SET read_at = COALESCE(read_at, ?) WHERE id = ? AND company_id = ?`,
+		db.Timestamp(now),
+		id,
+		companyID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the company as read and
+// returns how many there were.
+func (r *notificationRepository) MarkAllRead(ctx context.Context, companyID int64, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE notifications SET read_at = ? WHERE company_id = ? AND read_at IS NULL`,
+		db.Timestamp(now),
+		companyID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteOlderThan removes the notifications created before the given time,
+// read or not, and returns how many were removed.
+func (r *notificationRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notifications WHERE created_at < ?`, db.Timestamp(before))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrProductionBuildingLevelNotFound = errors.New("production building level not found")
+)
+
+// ProductionBuildingLevelRepository handles building level data access.
+type ProductionBuildingLevelRepository interface {
+	GetAllByBuilding(ctx context.Context, buildingID int64) ([]db.ProductionBuildingLevel, error)
+	GetByBuildingAndLevel(ctx context.Context, buildingID, level int64) (*db.ProductionBuildingLevel, error)
+	Upsert(ctx context.Context, level db.ProductionBuildingLevel) error
+	Delete(ctx context.Context, buildingID, level int64) error
+}
+
+type productionBuildingLevelRepository struct {
+	db *db.DB
+}
+
+// NewProductionBuildingLevelRepository creates a new building level repository.
+func NewProductionBuildingLevelRepository(database *db.DB) ProductionBuildingLevelRepository {
+	return &productionBuildingLevelRepository{db: database}
+}
+
+func (r *productionBuildingLevelRepository) GetAllByBuilding(ctx context.Context, buildingID int64) ([]db.ProductionBuildingLevel, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT building_id, level, upgrade_cost, upgrade_time_ms, speed_percent, output_percent, max_batches, slots
+		 FROM production_building_levels
+		 WHERE building_id = ?
+		 ORDER BY level`,
+		buildingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var levels []db.ProductionBuildingLevel
+	for rows.Next() {
+		var level db.ProductionBuildingLevel
+		if err := rows.Scan(
+			&level.BuildingID,
+			&level.Level,
+			&level.UpgradeCost,
+			&level.UpgradeTimeMs,
+			&level.SpeedPercent,
+			&level.OutputPercent,
+			&level.MaxBatches,
+			&level.Slots,
+		); err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range levels {
+		resources, err := r.getResources(ctx, levels[i].BuildingID, levels[i].Level)
+		if err != nil {
+			return nil, err
+		}
+		levels[i].Resources = resources
+	}
+
+	return levels, nil
+}
+
+func (r *productionBuildingLevelRepository) GetByBuildingAndLevel(ctx context.Context, buildingID, level int64) (*db.ProductionBuildingLevel, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT building_id, level, upgrade_cost, upgrade_time_ms, speed_percent, output_percent, max_batches, slots
+		 FROM production_building_levels
+		 WHERE building_id = ? AND level = ?`,
+		buildingID,
+		level,
+	)
+
+	var result db.ProductionBuildingLevel
+	if err := row.Scan(
+		&result.BuildingID,
+		&result.Level,
+		&result.UpgradeCost,
+		&result.UpgradeTimeMs,
+		&result.SpeedPercent,
+		&result.OutputPercent,
+		&result.MaxBatches,
+		&result.Slots,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrProductionBuildingLevelNotFound
+		}
+		return nil, err
+	}
+
+	resources, err := r.getResources(ctx, buildingID, level)
+	if err != nil {
+		return nil, err
+	}
+	result.Resources = resources
+
+	return &result, nil
+}
+
+// Upsert creates or updates a level and replaces its upgrade resources.
+func (r *productionBuildingLevelRepository) Upsert(ctx context.Context, level db.ProductionBuildingLevel) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO production_building_levels (
+			building_id,
+			level,
+			upgrade_cost,
+			upgrade_time_ms,
+			speed_percent,
+			output_percent,
+			max_batches,
+			slots
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(building_id, level)
+		 DO UPDATE SET upgrade_cost = excluded.upgrade_cost,
+			upgrade_time_ms = excluded.upgrade_time_ms,
+			speed_percent = excluded.speed_percent,
+			output_percent = excluded.output_percent,
+			max_batches = excluded.max_batches,
+			slots = excluded.slots`,
+		level.BuildingID,
+		level.Level,
+		level.UpgradeCost,
+		level.UpgradeTimeMs,
+		level.SpeedPercent,
+		level.OutputPercent,
+		level.MaxBatches,
+		level.Slots,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM production_building_level_resources WHERE building_id = ? AND level = ?`,
+		level.BuildingID,
+		level.Level,
+	); err != nil {
+		return err
+	}
+
+	for _, resource := range level.Resources {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO production_building_level_resources (building_id, level, resource_id, quantity)
+			 VALUES (?, ?, ?, ?)`,
+			level.BuildingID,
+			level.Level,
+			resource.ResourceID,
+			resource.Quantity,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *productionBuildingLevelRepository) Delete(ctx context.Context, buildingID, level int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM production_building_levels WHERE building_id = ? AND level = ?`,
+		buildingID,
+		level,
+	)
+	return err
+}
+
+func (r *productionBuildingLevelRepository) getResources(ctx context.Context, buildingID, level int64) ([]db.ProductionBuildingLevelResource, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT resource_id, quantity
+		 FROM production_building_level_resources
+		 WHERE building_id = ? AND level = ?
+		 ORDER BY resource_id`,
+		buildingID,
+		level,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resources []db.ProductionBuildingLevelResource
+	for rows.Next() {
+		var resource db.ProductionBuildingLevelResource
+		if err := rows.Scan(&resource.ResourceID, &resource.Quantity); err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
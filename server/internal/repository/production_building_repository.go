@@ -16,8 +16,8 @@ var (
 type ProductionBuildingRepository interface {
 	GetByID(ctx context.Context, id int64) (*db.ProductionBuilding, error)
 	GetAll(ctx context.Context) ([]db.ProductionBuilding, error)
-	Create(ctx context.Context, id int64, name string, cost int64) (*db.ProductionBuilding, error)
-	Update(ctx context.Context, id int64, name string, cost int64) (*db.ProductionBuilding, error)
+	Create(ctx context.Context, building *db.ProductionBuilding) (*db.ProductionBuilding, error)
+	Update(ctx context.Context, building *db.ProductionBuilding) (*db.ProductionBuilding, error)
 }
 
 type productionBuildingRepository struct {
@@ -29,26 +29,58 @@ func NewProductionBuildingRepository(database *db.DB) ProductionBuildingReposito
 	return &productionBuildingRepository{db: database}
 }
 
+const productionBuildingColumns = `id, name, cost, depreciation_curve, depreciation_initial_percent,
+	depreciation_percent_per_day, depreciation_floor_percent, upkeep, storage_capacity, construction_time_ms,
+	max_durability, wear_per_batch, breakdown_below_percent, repair_cost, slots`
+
+func scanProductionBuilding(scanner interface{ Scan(...interface{}) error }) (*db.ProductionBuilding, error) {
+	var building db.ProductionBuilding
+	if err := scanner.Scan(
+		&building.ID,
+		&building.Name,
+		&building.Cost,
+		&building.DepreciationCurve,
+		&building.DepreciationInitialPercent,
+		&building.DepreciationPercentPerDay,
+		&building.DepreciationFloorPercent,
+		&building.Upkeep,
+		&building.StorageCapacity,
+		&building.ConstructionTimeMs,
+		&building.MaxDurability,
+		&building.WearPerBatch,
+		&building.BreakdownBelowPercent,
+		&building.RepairCost,
+		&building.Slots,
+	); err != nil {
+		return nil, err
+	}
+	return &building, nil
+}
+
 func (r *productionBuildingRepository) GetByID(ctx context.Context, id int64) (*db.ProductionBuilding, error) {
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, name, cost FROM production_buildings WHERE id = ?`,
+		`SELECT `+productionBuildingColumns+` FROM production_buildings WHERE id = ?`,
 		id,
 	)
 
-	var building db.ProductionBuilding
-	if err := row.Scan(&building.ID, &building.Name, &building.Cost); err != nil {
+	building, err := scanProductionBuilding(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrProductionBuildingNotFound
 		}
 		return nil, err
 	}
 
-	return &building, nil
+	if err := r.loadResources(ctx, building); err != nil {
+		return nil, err
+	}
+
+	return building, nil
 }
 
 func (r *productionBuildingRepository) GetAll(ctx context.Context) ([]db.ProductionBuilding, error) {
-	rows, err := r.db.QueryContext(ctx, `SELECT id, name, cost FROM production_buildings ORDER BY id`)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+productionBuildingColumns+` FROM production_buildings ORDER BY id`)
 	if err != nil {
 		return nil, err
 	}
@@ -56,42 +88,198 @@ func (r *productionBuildingRepository) GetAll(ctx context.Context) ([]db.Product
 
 	var buildings []db.ProductionBuilding
 	for rows.Next() {
-		var building db.ProductionBuilding
-		if err := rows.Scan(&building.ID, &building.Name, &building.Cost); err != nil {
+		building, err := scanProductionBuilding(rows)
+		if err != nil {
 			return nil, err
 		}
-		buildings = append(buildings, building)
+		buildings = append(buildings, *building)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
+	for i := range buildings {
+		if err := r.loadResources(ctx, &buildings[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	return buildings, nil
 }
 
-func (r *productionBuildingRepository) Create(ctx context.Context, id int64, name string, cost int64) (*db.ProductionBuilding, error) {
-	_, err := r.db.ExecContext(
+// Create inserts a building type with its construction and repair resources.
+func (r *productionBuildingRepository) Create(ctx context.Context, building *db.ProductionBuilding) (*db.ProductionBuilding, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO production_buildings (
+			id,
+			name,
+			cost,
+			depreciation_curve,
+			depreciation_initial_percent,
+			depreciation_percent_per_day,
+			depreciation_floor_percent,
+			upkeep,
+			storage_capacity,
+			construction_time_ms,
+			max_durability,
+			wear_per_batch,
+			breakdown_below_percent,
+			repair_cost,
+			slots
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		building.ID,
+		building.Name,
+		building.Cost,
+		building.DepreciationCurve,
+		building.DepreciationInitialPercent,
+		building.DepreciationPercentPerDay,
+		building.DepreciationFloorPercent,
+		building.Upkeep,
+		building.StorageCapacity,
+		building.ConstructionTimeMs,
+		building.MaxDurability,
+		building.WearPerBatch,
+		building.BreakdownBelowPercent,
+		building.RepairCost,
+		building.Slots,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := setResources(ctx, tx, building); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, building.ID)
+}
+
+// Update updates a building type and replaces its construction and repair
+// resources.
+func (r *productionBuildingRepository) Update(ctx context.Context, building *db.ProductionBuilding) (*db.ProductionBuilding, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
 		ctx,
-		`INSERT INTO production_buildings (id, name, cost) VALUES (?, ?, ?)`,
-		id, name, cost,
+		`UPDATE production_buildings
+		 SET name = ?,
+			cost = ?,
+			depreciation_curve = ?,
+			depreciation_initial_percent = ?,
+			depreciation_percent_per_day = ?,
+			depreciation_floor_percent = ?,
+			upkeep = ?,
+			storage_capacity = ?,
+			construction_time_ms = ?,
+			max_durability = ?,
+			wear_per_batch = ?,
+			breakdown_below_percent = ?,
+			repair_cost = ?,
+			slots = ?
+		 WHERE id = ?`,
+		building.Name,
+		building.Cost,
+		building.DepreciationCurve,
+		building.DepreciationInitialPercent,
+		building.DepreciationPercentPerDay,
+		building.DepreciationFloorPercent,
+		building.Upkeep,
+		building.StorageCapacity,
+		building.ConstructionTimeMs,
+		building.MaxDurability,
+		building.WearPerBatch,
+		building.BreakdownBelowPercent,
+		building.RepairCost,
+		building.Slots,
+		building.ID,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err := setResources(ctx, tx, building); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, building.ID)
+}
+
+// Construction and repair resources of the building types.
+const (
+	constructionResourcesTable = "production_building_construction_resources"
+	repairResourcesTable       = "production_building_repair_resources"
+)
 
-	return r.GetByID(ctx, id)
+func (r *productionBuildingRepository) loadResources(ctx context.Context, building *db.ProductionBuilding) error {
+	var err error
+	building.ConstructionResources, err = r.getResources(ctx, constructionResourcesTable, building.ID)
+	if err != nil {
+		return err
+	}
+	building.RepairResources, err = r.getResources(ctx, repairResourcesTable, building.ID)
+	return err
 }
 
-func (r *productionBuildingRepository) Update(ctx context.Context, id int64, name string, cost int64) (*db.ProductionBuilding, error) {
-	_, err := r.db.ExecContext(
+func (r *productionBuildingRepository) getResources(ctx context.Context, table string, buildingID int64) ([]db.ResourceQuantity, error) {
+	rows, err := r.db.QueryContext(
 		ctx,
-		`UPDATE production_buildings SET name = ?, cost = ? WHERE id = ?`,
-		name, cost, id,
+		`SELECT resource_id, quantity FROM `+table+` WHERE building_id = ? ORDER BY resource_id`,
+		buildingID,
 	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var resources []db.ResourceQuantity
+	for rows.Next() {
+		var resource db.ResourceQuantity
+		if err := rows.Scan(&resource.ResourceID, &resource.Quantity); err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
 
-	return r.GetByID(ctx, id)
+	return resources, rows.Err()
+}
+
+func setResources(ctx context.Context, tx *db.Tx, building *db.ProductionBuilding) error {
+	tables := map[string][]db.ResourceQuantity{
+		constructionResourcesTable: building.ConstructionResources,
+		repairResourcesTable:       building.RepairResources,
+	}
+	for table, resources := range tables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE building_id = ?`, building.ID); err != nil {
+			return err
+		}
+
+		for _, resource := range resources {
+			if _, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO `+table+` (building_id, resource_id, quantity) VALUES (?, ?, ?)`,
+				building.ID,
+				resource.ResourceID,
+				resource.Quantity,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+// ProductionOutputRepository handles the record of units collected from
+// production runs.
+type ProductionOutputRepository interface {
+	Create(ctx context.Context, companyID int64, outputs []db.ResourceQuantity, producedAt time.Time) error
+	GetTotals(ctx context.Context, since time.Time) ([]db.ProductionOutput, error)
+}
+
+type productionOutputRepository struct {
+	db *db.DB
+}
+
+// NewProductionOutputRepository creates a new production output repository.
+func NewProductionOutputRepository(database *db.DB) ProductionOutputRepository {
+	return &productionOutputRepository{db: database}
+}
+
+// Create records the outputs of a run. Qualities are not kept, production
+// is counted in units.
+func (r *productionOutputRepository) Create(ctx context.Context, companyID int64, outputs []db.ResourceQuantity, producedAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, output := range outputs {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO production_outputs (company_id, resource_id, quantity, produced_at) VALUES (?, ?, ?, ?)`,
+			companyID,
+			output.ResourceID,
+			output.Quantity,
+			db.Timestamp(producedAt),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTotals returns the units of each resource every company produced
+// after since, one row per company and resource. A zero since counts
+// everything.
+func (r *productionOutputRepository) GetTotals(ctx context.Context, since time.Time) ([]db.ProductionOutput, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT company_id, resource_id, SUM(quantity)
+		 FROM production_outputs
+		 WHERE produced_at > ?
+		 GROUP BY company_id, resource_id`,
+		db.Timestamp(since),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []db.ProductionOutput
+	for rows.Next() {
+		var total db.ProductionOutput
+		if err := rows.Scan(&total.CompanyID, &total.ResourceID, &total.Quantity); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
@@ -16,24 +16,8 @@ var (
 type ProductionProcessRepository interface {
 	GetByID(ctx context.Context, id int64) (*db.ProductionProcess, error)
 	GetAllByBuilding(ctx context.Context, buildingID int64) ([]db.ProductionProcess, error)
-	Create(
-		ctx context.Context,
-		id int64,
-		name string,
-		processingTimeMs int64,
-		buildingID int64,
-		windowStartHour *int64,
-		windowEndHour *int64,
-	) (*db.ProductionProcess, error)
-	Update(
-		ctx context.Context,
-		id int64,
-		name string,
-		processingTimeMs int64,
-		buildingID int64,
-		windowStartHour *int64,
-		windowEndHour *int64,
-	) (*db.ProductionProcess, error)
+	Create(ctx context.Context, process *db.ProductionProcess) (*db.ProductionProcess, error)
+	Update(ctx context.Context, process *db.ProductionProcess) (*db.ProductionProcess, error)
 }
 
 type productionProcessRepository struct {
@@ -45,29 +29,21 @@ func NewProductionProcessRepository(database *db.DB) ProductionProcessRepository
 	return &productionProcessRepository{db: database}
 }
 
-func (r *productionProcessRepository) GetByID(ctx context.Context, id int64) (*db.ProductionProcess, error) {
-	row := r.db.QueryRowContext(
-		ctx,
-		`SELECT id, name, processing_time_ms, building_id, window_start_hour, window_end_hour
-		 FROM production_processes
-		 WHERE id = ?`,
-		id,
-	)
+const productionProcessColumns = `id, name, processing_time_ms, building_id, window_start_hour, window_end_hour, workers`
 
+func scanProductionProcess(scanner interface{ Scan(...interface{}) error }) (*db.ProductionProcess, error) {
 	var process db.ProductionProcess
 	var startHour sql.NullInt64
 	var endHour sql.NullInt64
-	if err := row.Scan(
+	if err := scanner.Scan(
 		&process.ID,
 		&process.Name,
 		&process.ProcessingTimeMs,
 		&process.BuildingID,
 		&startHour,
 		&endHour,
+		&process.Workers,
 	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, ErrProductionProcessNotFound
-		}
 		return nil, err
 	}
 
@@ -83,10 +59,30 @@ func (r *productionProcessRepository) GetByID(ctx context.Context, id int64) (*d
 	return &process, nil
 }
 
+func (r *productionProcessRepository) GetByID(ctx context.Context, id int64) (*db.ProductionProcess, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT `+productionProcessColumns+`
+		 FROM production_processes
+		 WHERE id = ?`,
+		id,
+	)
+
+	process, err := scanProductionProcess(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrProductionProcessNotFound
+		}
+		return nil, err
+	}
+
+	return process, nil
+}
+
 func (r *productionProcessRepository) GetAllByBuilding(ctx context.Context, buildingID int64) ([]db.ProductionProcess, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		`SELECT id, name, processing_time_ms, building_id, window_start_hour, window_end_hour
+		`SELECT `+productionProcessColumns+`
 		 FROM production_processes
 		 WHERE building_id = ?
 		 ORDER BY id`,
@@ -99,30 +95,11 @@ func (r *productionProcessRepository) GetAllByBuilding(ctx context.Context, buil
 
 	var processes []db.ProductionProcess
 	for rows.Next() {
-		var process db.ProductionProcess
-		var startHour sql.NullInt64
-		var endHour sql.NullInt64
-		if err := rows.Scan(
-			&process.ID,
-			&process.Name,
-			&process.ProcessingTimeMs,
-			&process.BuildingID,
-			&startHour,
-			&endHour,
-		); err != nil {
+		process, err := scanProductionProcess(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		if startHour.Valid {
-			value := startHour.Int64
-			process.WindowStartHour = &value
-		}
-		if endHour.Valid {
-			value := endHour.Int64
-			process.WindowEndHour = &value
-		}
-
-		processes = append(processes, process)
+		processes = append(processes, *process)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -132,15 +109,7 @@ func (r *productionProcessRepository) GetAllByBuilding(ctx context.Context, buil
 	return processes, nil
 }
 
-func (r *productionProcessRepository) Create(
-	ctx context.Context,
-	id int64,
-	name string,
-	processingTimeMs int64,
-	buildingID int64,
-	windowStartHour *int64,
-	windowEndHour *int64,
-) (*db.ProductionProcess, error) {
+func (r *productionProcessRepository) Create(ctx context.Context, process *db.ProductionProcess) (*db.ProductionProcess, error) {
 	_, err := r.db.ExecContext(
 		ctx,
 		`INSERT INTO production_processes (
@@ -149,31 +118,25 @@ func (r *productionProcessRepository) Create(
 			processing_time_ms,
 			building_id,
 			window_start_hour,
-			window_end_hour
-		) VALUES (?, ?, ?, ?, ?, ?)`,
-		id,
-		name,
-		processingTimeMs,
-		buildingID,
-		nullableInt64(windowStartHour),
-		nullableInt64(windowEndHour),
+			window_end_hour,
+			workers
+		) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		process.ID,
+		process.Name,
+		process.ProcessingTimeMs,
+		process.BuildingID,
+		nullableInt64(process.WindowStartHour),
+		nullableInt64(process.WindowEndHour),
+		process.Workers,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.GetByID(ctx, id)
+	return r.GetByID(ctx, process.ID)
 }
 
-func (r *productionProcessRepository) Update(
-	ctx context.Context,
-	id int64,
-	name string,
-	processingTimeMs int64,
-	buildingID int64,
-	windowStartHour *int64,
-	windowEndHour *int64,
-) (*db.ProductionProcess, error) {
+func (r *productionProcessRepository) Update(ctx context.Context, process *db.ProductionProcess) (*db.ProductionProcess, error) {
 	_, err := r.db.ExecContext(
 		ctx,
 		`UPDATE production_processes
@@ -181,20 +144,22 @@ func (r *productionProcessRepository) Update(
 			processing_time_ms = ?,
 			building_id = ?,
 			window_start_hour = ?,
-			window_end_hour = ?
+			window_end_hour = ?,
+			workers = ?
 		 WHERE id = ?`,
-		name,
-		processingTimeMs,
-		buildingID,
-		nullableInt64(windowStartHour),
-		nullableInt64(windowEndHour),
-		id,
+		process.Name,
+		process.ProcessingTimeMs,
+		process.BuildingID,
+		nullableInt64(process.WindowStartHour),
+		nullableInt64(process.WindowEndHour),
+		process.Workers,
+		process.ID,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.GetByID(ctx, id)
+	return r.GetByID(ctx, process.ID)
 }
 
 func nullableInt64(value *int64) sql.NullInt64 {
@@ -9,7 +9,7 @@ import (
 // ProductionProcessResourceRepository handles process resource data access.
 type ProductionProcessResourceRepository interface {
 	GetAllByProcess(ctx context.Context, processID int64) ([]db.ProductionProcessResource, error)
-	Upsert(ctx context.Context, processID, resourceID int64, direction string, quantity int64) error
+	Upsert(ctx context.Context, resource *db.ProductionProcessResource) error
 	Delete(ctx context.Context, processID, resourceID int64, direction string) error
 }
 
@@ -25,7 +25,7 @@ func NewProductionProcessResourceRepository(database *db.DB) ProductionProcessRe
 func (r *productionProcessResourceRepository) GetAllByProcess(ctx context.Context, processID int64) ([]db.ProductionProcessResource, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		`SELECT process_id, resource_id, direction, quantity
+		`SELECT process_id, resource_id, direction, quantity, input_group, max_quantity, chance
 		 FROM production_process_resources
 		 WHERE process_id = ?
 		 ORDER BY resource_id, direction`,
@@ -39,7 +39,15 @@ func (r *productionProcessResourceRepository) GetAllByProcess(ctx context.Contex
 	var resources []db.ProductionProcessResource
 	for rows.Next() {
 		var resource db.ProductionProcessResource
-		if err := rows.Scan(&resource.ProcessID, &resource.ResourceID, &resource.Direction, &resource.Quantity); err != nil {
+		if err := rows.Scan(
+			&resource.ProcessID,
+			&resource.ResourceID,
+			&resource.Direction,
+			&resource.Quantity,
+			&resource.Group,
+			&resource.MaxQuantity,
+			&resource.Chance,
+		); err != nil {
 			return nil, err
 		}
 		resources = append(resources, resource)
@@ -52,23 +60,23 @@ func (r *productionProcessResourceRepository) GetAllByProcess(ctx context.Contex
 	return resources, nil
 }
 
-func (r *productionProcessResourceRepository) Upsert(
-	ctx context.Context,
-	processID int64,
-	resourceID int64,
-	direction string,
-	quantity int64,
-) error {
+func (r *productionProcessResourceRepository) Upsert(ctx context.Context, resource *db.ProductionProcessResource) error {
 	_, err := r.db.ExecContext(
 		ctx,
-		`INSERT INTO production_process_resources (process_id, resource_id, direction, quantity)
-		 VALUES (?, ?, ?, ?)
+		`INSERT INTO production_process_resources (process_id, resource_id, direction, quantity, input_group, max_quantity, chance)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
 		 ON CONFLICT(process_id, resource_id, direction)
-		 DO UPDATE SET quantity = excluded.quantity`,
-		processID,
-		resourceID,
-		direction,
-		quantity,
+		 DO UPDATE SET quantity = excluded.quantity,
+			input_group = excluded.input_group,
+			max_quantity = excluded.max_quantity,
+			chance = excluded.chance`,
+		resource.ProcessID,
+		resource.ResourceID,
+		resource.Direction,
+		resource.Quantity,
+		resource.Group,
+		resource.MaxQuantity,
+		resource.Chance,
 	)
 	return err
 }
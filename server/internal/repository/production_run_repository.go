@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrProductionRunNotFound = errors.New("production run not found")
+	ErrRunAlreadyCollected   = errors.New("production run already collected")
+	ErrSlotTaken             = errors.New("production slot already taken")
+	ErrRunChanged            = errors.New("production run changed")
+)
+
+// ProductionRunRepository handles production runs of company buildings.
+type ProductionRunRepository interface {
+	Create(ctx context.Context, run *db.ProductionRun) (*db.ProductionRun, error)
+	GetByID(ctx context.Context, id int64) (*db.ProductionRun, error)
+	GetActiveByCompanyBuilding(ctx context.Context, companyBuildingID int64) ([]db.ProductionRun, error)
+	GetUncollectedByCompany(ctx context.Context, companyID int64) ([]db.ProductionRun, error)
+	GetFinishedBetween(ctx context.Context, from, to time.Time) ([]db.ProductionRun, error)
+	GetAllByCompanyBuilding(ctx context.Context, companyBuildingID int64, limit int) ([]db.ProductionRun, error)
+	MarkCollected(ctx context.Context, id int64, collectedAt time.Time) error
+	Rush(ctx context.Context, id int64, finishesAt, now time.Time) error
+	Reschedule(ctx context.Context, id int64, finishesAt, newFinishesAt time.Time) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type productionRunRepository struct {
+	db *db.DB
+}
+
+// NewProductionRunRepository creates a new production run repository.
+func NewProductionRunRepository(database *db.DB) ProductionRunRepository {
+	return &productionRunRepository{db: database}
+}
+
+const productionRunColumns = `id, company_building_id, process_id, slot, batches, workers, quality, seed, work_ms, output_percent, started_at, finishes_at, collected_at`
+
+func scanProductionRun(scanner interface{ Scan(...interface{}) error }) (*db.ProductionRun, error) {
+	var run db.ProductionRun
+	var collectedAt sql.NullTime
+	if err := scanner.Scan(
+		&run.ID,
+		&run.CompanyBuildingID,
+		&run.ProcessID,
+		&run.Slot,
+		&run.Batches,
+		&run.Workers,
+		&run.Quality,
+		&run.Seed,
+		&run.WorkMs,
+		&run.OutputPercent,
+		&run.StartedAt,
+		&run.FinishesAt,
+		&collectedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if collectedAt.Valid {
+		value := collectedAt.Time
+		run.CollectedAt = &value
+	}
+
+	return &run, nil
+}
+
+// Create inserts a run in its slot of the building. It fails with
+// ErrSlotTaken if an uncollected run already holds the slot, so concurrent
+// starts can't share one.
+func (r *productionRunRepository) Create(ctx context.Context, run *db.ProductionRun) (*db.ProductionRun, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO production_runs (company_building_id, process_id, slot, batches, workers, quality, seed, work_ms, output_percent, started_at, finishes_at)
+		 SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM production_runs
+			WHERE company_building_id = ? AND slot = ? AND collected_at IS NULL
+		 )`,
+		run.CompanyBuildingID,
+		run.ProcessID,
+		run.Slot,
+		run.Batches,
+		run.Workers,
+		run.Quality,
+		run.Seed,
+		run.WorkMs,
+		run.OutputPercent,
+		run.StartedAt.UTC(),
+		run.FinishesAt.UTC(),
+		run.CompanyBuildingID,
+		run.Slot,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, ErrSlotTaken
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *productionRunRepository) GetByID(ctx context.Context, id int64) (*db.ProductionRun, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT `+productionRunColumns+` FROM production_runs WHERE id = ?`,
+		id,
+	)
+
+	run, err := scanProductionRun(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrProductionRunNotFound
+		}
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// GetActiveByCompanyBuilding returns runs that have not been collected yet.
+func (r *productionRunRepository) GetActiveByCompanyBuilding(ctx context.Context, companyBuildingID int64) ([]db.ProductionRun, error) {
+	return r.query(
+		ctx,
+		`SELECT `+productionRunColumns+`
+		 FROM production_runs
+		 WHERE company_building_id = ? AND collected_at IS NULL
+		 ORDER BY id`,
+		companyBuildingID,
+	)
+}
+
+// GetUncollectedByCompany returns the runs not collected yet in any of the
+// company's buildings.
+func (r *productionRunRepository) GetUncollectedByCompany(ctx context.Context, companyID int64) ([]db.ProductionRun, error) {
+	return r.query(
+		ctx,
+		`SELECT `+productionRunColumns+`
+		 FROM production_runs
+		 WHERE collected_at IS NULL
+		   AND company_building_id IN (SELECT id FROM company_buildings WHERE company_id = ?)
+		 ORDER BY id`,
+		companyID,
+	)
+}
+
+// GetFinishedBetween returns the runs of every company that finished after
+// from and up to to, and have not been collected yet.
+func (r *productionRunRepository) GetFinishedBetween(ctx context.Context, from, to time.Time) ([]db.ProductionRun, error) {
+	return r.query(
+		ctx,
+		`SELECT `+productionRunColumns+`
+		 FROM production_runs
+		 WHERE collected_at IS NULL AND finishes_at > ? AND finishes_at <= ?
+		 ORDER BY finishes_at, id`,
+		db.Timestamp(from),
+		db.Timestamp(to),
+	)
+}
+
+// GetAllByCompanyBuilding returns the most recent runs first.
+func (r *productionRunRepository) GetAllByCompanyBuilding(ctx context.Context, companyBuildingID int64, limit int) ([]db.ProductionRun, error) {
+	return r.query(
+		ctx,
+		`SELECT `+productionRunColumns+`
+		 FROM production_runs
+		 WHERE company_building_id = ?
+		 ORDER BY id DESC
+		 LIMIT ?`,
+		companyBuildingID,
+		limit,
+	)
+}
+
+// MarkCollected flags the run as collected. Only one caller can succeed,
+// the others get ErrRunAlreadyCollected.
+func (r *productionRunRepository) MarkCollected(ctx context.Context, id int64, collectedAt time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE production_runs SET collected_at = ? WHERE id = ? AND collected_at IS NULL`,
+		collectedAt.UTC(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRunAlreadyCollected
+	}
+
+	return nil
+}
+
+// Rush makes an active run finish now. It fails with ErrRunChanged unless
+// the run still finishes at finishesAt, so a run is only rushed once and
+// never after it was collected.
+func (r *productionRunRepository) Rush(ctx context.Context, id int64, finishesAt, now time.Time) error {
+	return r.Reschedule(ctx, id, finishesAt, now)
+}
+
+// Reschedule moves the finish time of an uncollected run. It fails with
+// ErrRunChanged unless the run still finishes at finishesAt.
+func (r *productionRunRepository) Reschedule(ctx context.Context, id int64, finishesAt, newFinishesAt time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE production_runs SET finishes_at = ? WHERE id = ? AND finishes_at = ? AND collected_at IS NULL`,
+		newFinishesAt.UTC(),
+		id,
+		finishesAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRunChanged
+	}
+
+	return nil
+}
+
+func (r *productionRunRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM production_runs WHERE id = ?`, id)
+	return err
+}
+
+func (r *productionRunRepository) query(ctx context.Context, query string, args ...interface{}) ([]db.ProductionRun, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []db.ProductionRun
+	for rows.Next() {
+		run, err := scanProductionRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
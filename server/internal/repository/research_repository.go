@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrResearchNodeNotFound = errors.New("research node not found")
+)
+
+// ResearchRepository handles research node data access.
+type ResearchRepository interface {
+	GetAll(ctx context.Context) ([]db.ResearchNode, error)
+	GetByID(ctx context.Context, id int64) (*db.ResearchNode, error)
+	Upsert(ctx context.Context, node db.ResearchNode) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type researchRepository struct {
+	db *db.DB
+}
+
+// NewResearchRepository creates a new research repository.
+func NewResearchRepository(database *db.DB) ResearchRepository {
+	return &researchRepository{db: database}
+}
+
+func (r *researchRepository) GetAll(ctx context.Context) ([]db.ResearchNode, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, cost, duration_ms FROM research_nodes ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []db.ResearchNode
+	for rows.Next() {
+		var node db.ResearchNode
+		if err := rows.Scan(&node.ID, &node.Name, &node.Cost, &node.DurationMs); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range nodes {
+		if err := r.loadDetails(ctx, &nodes[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
+
+func (r *researchRepository) GetByID(ctx context.Context, id int64) (*db.ResearchNode, error) {
+	var node db.ResearchNode
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT id, name, cost, duration_ms FROM research_nodes WHERE id = ?`,
+		id,
+	).Scan(&node.ID, &node.Name, &node.Cost, &node.DurationMs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrResearchNodeNotFound
+		}
+		return nil, err
+	}
+
+	if err := r.loadDetails(ctx, &node); err != nil {
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+// Upsert creates or updates a node and replaces its prerequisites,
+// resources and unlocks.
+func (r *researchRepository) Upsert(ctx context.Context, node db.ResearchNode) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO research_nodes (id, name, cost, duration_ms) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(id)
+		 DO UPDATE SET name = excluded.name,
+			cost = excluded.cost,
+			duration_ms = excluded.duration_ms`,
+		node.ID,
+		node.Name,
+		node.Cost,
+		node.DurationMs,
+	); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"research_node_prerequisites", "research_node_resources", "research_node_unlocks"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE node_id = ?`, node.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, prerequisite := range node.Prerequisites {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR IGNORE INTO research_node_prerequisites (node_id, prerequisite_id) VALUES (?, ?)`,
+			node.ID,
+			prerequisite,
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, resource := range node.Resources {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO research_node_resources (node_id, resource_id, quantity) VALUES (?, ?, ?)
+			 ON CONFLICT(node_id, resource_id) DO UPDATE SET quantity = quantity + excluded.quantity`,
+			node.ID,
+			resource.ResourceID,
+			resource.Quantity,
+		); err != nil {
+			return err
+		}
+	}
+
+	unlocks := map[string][]int64{
+		db.UnlockBuilding: node.UnlockBuildings,
+		db.UnlockProcess:  node.UnlockProcesses,
+	}
+	for kind, targets := range unlocks {
+		for _, target := range targets {
+			if _, err := tx.ExecContext(
+				ctx,
+				`INSERT OR IGNORE INTO research_node_unlocks (node_id, kind, target_id) VALUES (?, ?, ?)`,
+				node.ID,
+				kind,
+				target,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *researchRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM research_nodes WHERE id = ?`, id)
+	return err
+}
+
+func (r *researchRepository) loadDetails(ctx context.Context, node *db.ResearchNode) error {
+	prerequisites, err := r.queryIDs(
+		ctx,
+		`SELECT prerequisite_id FROM research_node_prerequisites WHERE node_id = ? ORDER BY prerequisite_id`,
+		node.ID,
+	)
+	if err != nil {
+		return err
+	}
+	node.Prerequisites = prerequisites
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT resource_id, quantity FROM research_node_resources WHERE node_id = ? ORDER BY resource_id`,
+		node.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	node.Resources = nil
+	for rows.Next() {
+		var resource db.ResourceQuantity
+		if err := rows.Scan(&resource.ResourceID, &resource.Quantity); err != nil {
+			return err
+		}
+		node.Resources = append(node.Resources, resource)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	const unlockQuery = `SELECT target_id FROM research_node_unlocks WHERE node_id = ? AND kind = ? ORDER BY target_id`
+	if node.UnlockBuildings, err = r.queryIDs(ctx, unlockQuery, node.ID, db.UnlockBuilding); err != nil {
+		return err
+	}
+	if node.UnlockProcesses, err = r.queryIDs(ctx, unlockQuery, node.ID, db.UnlockProcess); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *researchRepository) queryIDs(ctx context.Context, query string, args ...interface{}) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
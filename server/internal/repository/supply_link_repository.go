@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"yourownboss/internal/db"
+)
+
+var (
+	ErrSupplyLinkNotFound = errors.New("supply link not found")
+	ErrSupplyLinkExists   = errors.New("resource is already linked from this building")
+)
+
+// SupplyLinkRepository handles supply links between company buildings.
+type SupplyLinkRepository interface {
+	Create(ctx context.Context, link *db.SupplyLink) (*db.SupplyLink, error)
+	GetByID(ctx context.Context, id int64) (*db.SupplyLink, error)
+	GetAllByCompany(ctx context.Context, companyID int64) ([]db.SupplyLink, error)
+	GetAllBySource(ctx context.Context, sourceBuildingID int64) ([]db.SupplyLink, error)
+	GetAllByTarget(ctx context.Context, targetBuildingID int64) ([]db.SupplyLink, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type supplyLinkRepository struct {
+	db *db.DB
+}
+
+// NewSupplyLinkRepository creates a new supply link repository.
+func NewSupplyLinkRepository(database *db.DB) SupplyLinkRepository {
+	return &supplyLinkRepository{db: database}
+}
+
+const supplyLinkColumns = `id, company_id, source_building_id, target_building_id, resource_id, target_process_id, created_at`
+
+func scanSupplyLink(scanner interface{ Scan(...interface{}) error }) (*db.SupplyLink, error) {
+	var link db.SupplyLink
+	if err := scanner.Scan(
+		&link.ID,
+		&link.CompanyID,
+		&link.SourceBuildingID,
+		&link.TargetBuildingID,
+		&link.ResourceID,
+		&link.TargetProcessID,
+		&link.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *supplyLinkRepository) Create(ctx context.Context, link *db.SupplyLink) (*db.SupplyLink, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO supply_links (company_id, source_building_id, target_building_id, resource_id, target_process_id)
+		 VALUES (?, ?, ?, ?, ?)`,
+		link.CompanyID,
+		link.SourceBuildingID,
+		link.TargetBuildingID,
+		link.ResourceID,
+		link.TargetProcessID,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, ErrSupplyLinkExists
+		}
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *supplyLinkRepository) GetByID(ctx context.Context, id int64) (*db.SupplyLink, error) {
+	link, err := scanSupplyLink(r.db.QueryRowContext(
+		ctx,
+		`SELECT `+supplyLinkColumns+` FROM supply_links WHERE id = ?`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrSupplyLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (r *supplyLinkRepository) GetAllByCompany(ctx context.Context, companyID int64) ([]db.SupplyLink, error) {
+	return r.query(ctx, `SELECT `+supplyLinkColumns+` FROM supply_links WHERE company_id = ? ORDER BY id`, companyID)
+}
+
+func (r *supplyLinkRepository) GetAllBySource(ctx context.Context, sourceBuildingID int64) ([]db.SupplyLink, error) {
+	return r.query(ctx, `SELECT `+supplyLinkColumns+` FROM supply_links WHERE source_building_id = ? ORDER BY id`, sourceBuildingID)
+}
+
+func (r *supplyLinkRepository) GetAllByTarget(ctx context.Context, targetBuildingID int64) ([]db.SupplyLink, error) {
+	return r.query(ctx, `SELECT `+supplyLinkColumns+` FROM supply_links WHERE target_building_id = ? ORDER BY id`, targetBuildingID)
+}
+
+// Delete removes a link. It fails with ErrSupplyLinkNotFound if it was
+// already removed.
+func (r *supplyLinkRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM supply_links WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSupplyLinkNotFound
+	}
+
+	return nil
+}
+
+func (r *supplyLinkRepository) query(ctx context.Context, query string, args ...interface{}) ([]db.SupplyLink, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make([]db.SupplyLink, 0)
+	for rows.Next() {
+		link, err := scanSupplyLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"yourownboss/internal/db"
+)
+
+// Transactor runs a function in a database transaction, committed only if
+// it succeeds. Repository calls made with the context it passes to the
+// function run inside the transaction.
+type Transactor interface {
+	Transact(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// NewTransactor creates a transactor for the database.
+func NewTransactor(database *db.DB) Transactor {
+	return database
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"yourownboss/internal/db"
+)
+
+// ValuationRepository handles the net worth snapshots of companies.
+type ValuationRepository interface {
+	Create(ctx context.Context, valuation *db.Valuation) error
+	GetAllByCompany(ctx context.Context, companyID int64, limit, offset int) ([]db.Valuation, error)
+}
+
+type valuationRepository struct {
+	db *db.DB
+}
+
+// NewValuationRepository creates a new valuation repository.
+func NewValuationRepository(database *db.DB) ValuationRepository {
+	return &valuationRepository{db: database}
+}
+
+func (r *valuationRepository) Create(ctx context.Context, valuation *db.Valuation) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO company_valuations (company_id, cash, inventory, buildings, liabilities, net_worth, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		valuation.CompanyID,
+		valuation.Cash,
+		valuation.Inventory,
+		valuation.Buildings,
+		valuation.Liabilities,
+		valuation.NetWorth,
+		db.Timestamp(valuation.CreatedAt),
+	)
+	return err
+}
+
+// GetAllByCompany returns the most recent snapshots first.
+func (r *valuationRepository) GetAllByCompany(ctx context.Context, companyID int64, limit, offset int) ([]db.Valuation, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT company_id, cash, inventory, buildings, liabilities, net_worth, created_at
+		 FROM company_valuations
+		 WHERE company_id = ?
+		 ORDER BY id DESC
+		 LIMIT ? OFFSET ?`,
+		companyID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var valuations []db.Valuation
+	for rows.Next() {
+		var valuation db.Valuation
+		if err := rows.Scan(
+			&valuation.CompanyID,
+			&valuation.Cash,
+			&valuation.Inventory,
+			&valuation.Buildings,
+			&valuation.Liabilities,
+			&valuation.NetWorth,
+			&valuation.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		valuations = append(valuations, valuation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return valuations, nil
+}
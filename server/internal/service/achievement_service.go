@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+// AchievementService tracks the progress of companies on achievements and
+// pays their rewards. Game events are recorded by the event bus
+// subscribers registered at startup.
+type AchievementService interface {
+	GetAchievements(ctx context.Context, companyID int64) ([]AchievementDetails, error)
+	Record(ctx context.Context, companyID int64, event AchievementEvent) error
+}
+
+// AchievementEvent is something a company did that may count towards
+// achievements. Amount is ignored by count conditions.
+type AchievementEvent struct {
+	Type       string
+	ResourceID int64
+	Amount     int64
+}
+
+// AchievementDetails is an achievement with the company's progress on it.
+type AchievementDetails struct {
+	ID              int64
+	Name            string
+	Description     string
+	Event           string
+	ResourceID      int64
+	ResourceName    string
+	Condition       string
+	Threshold       int64
+	Progress        int64 // Capped to the threshold
+	RewardMoney     int64
+	RewardResources []ProductionBuildingLevelResourceDetails
+	CompletedAt     *time.Time
+	RewardedAt      *time.Time
+}
+
+type achievementService struct {
+	achievementRepo        repository.AchievementRepository
+	companyAchievementRepo repository.CompanyAchievementRepository
+	resourceRepo           repository.ResourceRepository
+	inventoryRepo          repository.InventoryRepository
+	ledgerService          LedgerService
+}
+
+// NewAchievementService creates a new achievement service.
+func NewAchievementService(
+	achievementRepo repository.AchievementRepository,
+	companyAchievementRepo repository.CompanyAchievementRepository,
+	resourceRepo repository.ResourceRepository,
+	inventoryRepo repository.InventoryRepository,
+	ledgerService LedgerService,
+) AchievementService {
+	return &achievementService{
+		achievementRepo:        achievementRepo,
+		companyAchievementRepo: companyAchievementRepo,
+		resourceRepo:           resourceRepo,
+		inventoryRepo:          inventoryRepo,
+		ledgerService:          ledgerService,
+	}
+}
+
+// GetAchievements lists every achievement with the company's progress.
+// Rewards that couldn't be paid when completed are retried first.
+func (s *achievementService) GetAchievements(ctx context.Context, companyID int64) ([]AchievementDetails, error) {
+	achievements, err := s.achievementRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.payPending(ctx, companyID, achievements); err != nil {
+		return nil, err
+	}
+
+	progress, err := s.companyAchievementRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	byAchievement := make(map[int64]db.CompanyAchievement, len(progress))
+	for _, entry := range progress {
+		byAchievement[entry.AchievementID] = entry
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := resourceNames(resources)
+
+	result := make([]AchievementDetails, 0, len(achievements))
+	for _, achievement := range achievements {
+		details := AchievementDetails{
+			ID:              achievement.ID,
+			Name:            achievement.Name,
+			Description:     achievement.Description,
+			Event:           achievement.Event,
+			ResourceID:      achievement.ResourceID,
+			ResourceName:    names[achievement.ResourceID],
+			Condition:       achievement.Condition,
+			Threshold:       achievement.Threshold,
+			RewardMoney:     achievement.RewardMoney,
+			RewardResources: make([]ProductionBuildingLevelResourceDetails, 0, len(achievement.RewardResources)),
+		}
+		for _, resource := range achievement.RewardResources {
+			details.RewardResources = append(details.RewardResources, ProductionBuildingLevelResourceDetails{
+				ResourceID:   resource.ResourceID,
+				ResourceName: names[resource.ResourceID],
+				Quantity:     resource.Quantity,
+			})
+		}
+		if entry, ok := byAchievement[achievement.ID]; ok {
+			details.Progress = min(entry.Progress, achievement.Threshold)
+			details.CompletedAt = entry.CompletedAt
+			details.RewardedAt = entry.RewardedAt
+		}
+		result = append(result, details)
+	}
+
+	return result, nil
+}
+
+// Record adds an event to the progress of the company on every achievement
+// it counts towards, completing and rewarding those that reach their
+// threshold. Events with a non-positive amount only count for count
+// conditions.
+func (s *achievementService) Record(ctx context.Context, companyID int64, event AchievementEvent) error {
+	achievements, err := s.achievementRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, achievement := range achievements {
+		if achievement.Event != event.Type {
+			continue
+		}
+		if achievement.ResourceID != 0 && achievement.ResourceID != event.ResourceID {
+			continue
+		}
+		if achievement.Condition != db.ConditionCount && event.Amount <= 0 {
+			continue
+		}
+
+		if err := s.companyAchievementRepo.AddProgress(ctx, companyID, achievement, event.Amount); err != nil {
+			return err
+		}
+
+		if err := s.companyAchievementRepo.Complete(ctx, companyID, achievement, now); err != nil {
+			if err == repository.ErrCompanyAchievementChanged {
+				continue
+			}
+			return err
+		}
+
+		// A reward that can't be paid now stays pending and is retried
+		// when the company lists its achievements
+		_ = s.reward(ctx, companyID, achievement, now)
+	}
+
+	return nil
+}
+
+func (s *achievementService) payPending(ctx context.Context, companyID int64, achievements []db.Achievement) error {
+	pending, err := s.companyAchievementRepo.GetUnrewarded(ctx, companyID)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	byID := make(map[int64]db.Achievement, len(achievements))
+	for _, achievement := range achievements {
+		byID[achievement.ID] = achievement
+	}
+
+	now := time.Now()
+	for _, entry := range pending {
+		if achievement, ok := byID[entry.AchievementID]; ok {
+			_ = s.reward(ctx, companyID, achievement, now)
+		}
+	}
+
+	return nil
+}
+
+// reward pays the money and resources of a completed achievement. Marking
+// it first keeps concurrent callers from paying it twice.
+func (s *achievementService) reward(ctx context.Context, companyID int64, achievement db.Achievement, now time.Time) error {
+	if err := s.companyAchievementRepo.MarkRewarded(ctx, companyID, achievement.ID, now); err != nil {
+		if err == repository.ErrCompanyAchievementChanged {
+			return nil
+		}
+		return err
+	}
+
+	entry, err := s.ledgerService.Apply(
+		ctx,
+		companyID,
+		achievement.RewardMoney,
+		db.LedgerAchievementReward,
+		"Achievement: "+achievement.Name,
+		&achievement.ID,
+	)
+	if err != nil {
+		_ = s.companyAchievementRepo.UnmarkRewarded(ctx, companyID, achievement.ID)
+		return err
+	}
+
+	if err := s.inventoryRepo.AddItemsAt(ctx, companyID, achievement.RewardResources, now); err != nil {
+		// Rollback: the whole reward is paid again on the next attempt
+		_ = s.ledgerService.Revert(ctx, entry)
+		_ = s.companyAchievementRepo.UnmarkRewarded(ctx, companyID, achievement.ID)
+		return err
+	}
+
+	return nil
+}
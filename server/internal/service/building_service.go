@@ -0,0 +1,673 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/events"
+	"yourownboss/internal/repository"
+)
+
+var (
+	ErrBuildingNotFound          = errors.New("production building not found")
+	ErrCompanyBuildingNotFound   = errors.New("company building not found")
+	ErrMaxLevelReached           = errors.New("building is already at max level")
+	ErrBuildingUpgrading         = errors.New("building is being upgraded")
+	ErrBuildingBusy              = errors.New("building has an active production run")
+	ErrStorageInUse              = errors.New("selling the building would leave stock without storage")
+	ErrBufferNotEmpty            = errors.New("building has buffered resources")
+	ErrBuildingUnderConstruction = errors.New("building is under construction")
+	ErrBuildingBroken            = errors.New("building is broken down")
+	ErrNothingToRepair           = errors.New("building has no wear to repair")
+	ErrRepairConflict            = errors.New("building wear changed during the repair")
+)
+
+// BuildingService handles production buildings owned by companies.
+type BuildingService interface {
+	BuyBuilding(ctx context.Context, companyID, buildingID int64) (*CompanyBuildingDetails, error)
+	GetCompanyBuildings(ctx context.Context, companyID int64) ([]CompanyBuildingDetails, error)
+	GetCompanyBuilding(ctx context.Context, companyID, companyBuildingID int64) (*CompanyBuildingDetails, error)
+	UpgradeBuilding(ctx context.Context, companyID, companyBuildingID int64) (*CompanyBuildingDetails, error)
+	SellBuilding(ctx context.Context, companyID, companyBuildingID int64, force bool) (*BuildingSale, error)
+	RepairBuilding(ctx context.Context, companyID, companyBuildingID int64) (*CompanyBuildingDetails, error)
+}
+
+// CompanyBuildingDetails represents an owned building with the bonuses of
+// its current level and the requirements of the next one.
+type CompanyBuildingDetails struct {
+	ID                int64
+	BuildingID        int64
+	Name              string
+	Level             int64
+	UpgradeFinishesAt *time.Time
+	// Set while under construction, with the share of the construction time
+	// that has passed
+	ConstructionFinishesAt *time.Time
+	ConstructionPercent    int64
+	// Set for buildings that wear down, with the cost of repairing them now
+	Durability      *int64
+	MaxDurability   int64
+	BrokenAt        *time.Time
+	RepairCost      int64
+	RepairResources []ProductionBuildingLevelResourceDetails
+	SpeedPercent    int64
+	OutputPercent   int64
+	MaxBatches      int64
+	Slots           int64                           // Processes that can run at once
+	NextLevel       *ProductionBuildingLevelDetails // Nil at max level
+	SaleValue       int64                           // Refund if sold now
+	Upkeep          int64                           // Charged every upkeep period
+	CreatedAt       time.Time
+}
+
+// BuildingSale is the result of selling an owned building.
+type BuildingSale struct {
+	Refund  int64
+	Balance int64 // Company money after the refund
+}
+
+type buildingService struct {
+	buildingRepo        repository.ProductionBuildingRepository
+	levelRepo           repository.ProductionBuildingLevelRepository
+	companyBuildingRepo repository.CompanyBuildingRepository
+	runRepo             repository.ProductionRunRepository
+	inventoryRepo       repository.InventoryRepository
+	resourceRepo        repository.ResourceRepository
+	bufferRepo          repository.BuildingBufferRepository
+	ledgerService       LedgerService
+	upkeepService       UpkeepService
+	researchService     ResearchService
+	publisher           events.Publisher
+	transactor          repository.Transactor
+}
+
+// NewBuildingService creates a new building service.
+func NewBuildingService(
+	buildingRepo repository.ProductionBuildingRepository,
+	levelRepo repository.ProductionBuildingLevelRepository,
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	runRepo repository.ProductionRunRepository,
+	inventoryRepo repository.InventoryRepository,
+	resourceRepo repository.ResourceRepository,
+	bufferRepo repository.BuildingBufferRepository,
+	ledgerService LedgerService,
+	upkeepService UpkeepService,
+	researchService ResearchService,
+	publisher events.Publisher,
+	transactor repository.Transactor,
+) BuildingService {
+	return &buildingService{
+		buildingRepo:        buildingRepo,
+		levelRepo:           levelRepo,
+		companyBuildingRepo: companyBuildingRepo,
+		runRepo:             runRepo,
+		inventoryRepo:       inventoryRepo,
+		resourceRepo:        resourceRepo,
+		bufferRepo:          bufferRepo,
+		ledgerService:       ledgerService,
+		upkeepService:       upkeepService,
+		researchService:     researchService,
+		publisher:           publisher,
+		transactor:          transactor,
+	}
+}
+
+func (s *buildingService) BuyBuilding(ctx context.Context, companyID, buildingID int64) (*CompanyBuildingDetails, error) {
+	building, err := s.buildingRepo.GetByID(ctx, buildingID)
+	if err != nil {
+		if err == repository.ErrProductionBuildingNotFound {
+			return nil, ErrBuildingNotFound
+		}
+		return nil, err
+	}
+
+	locks, err := s.researchService.GetLocks(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if locks.BuildingLocked(building.ID) {
+		return nil, ErrBuildingLocked
+	}
+
+	now := time.Now()
+	if len(building.ConstructionResources) > 0 {
+		if _, err := s.inventoryRepo.ExpireLots(ctx, companyID, now); err != nil {
+			return nil, err
+		}
+
+		// Check every resource before removing any of them
+		for _, resource := range building.ConstructionResources {
+			stored, err := s.inventoryRepo.GetTotalQuantity(ctx, companyID, resource.ResourceID)
+			if err != nil {
+				return nil, err
+			}
+			if stored < resource.Quantity {
+				return nil, repository.ErrInsufficientStock
+			}
+		}
+	}
+
+	var constructionFinishesAt *time.Time
+	if building.ConstructionTimeMs > 0 {
+		finishesAt := now.Add(time.Duration(building.ConstructionTimeMs) * time.Millisecond)
+		constructionFinishesAt = &finishesAt
+	}
+
+	// The building, its payment and its construction resources commit
+	// together
+	var owned *db.CompanyBuilding
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		owned, err = s.companyBuildingRepo.Create(ctx, companyID, buildingID, constructionFinishesAt)
+		if err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Bought %s", building.Name)
+		if _, err := s.ledgerService.Apply(ctx, companyID, -building.Cost, db.LedgerBuildingPurchase, description, &owned.ID); err != nil {
+			return err
+		}
+
+		// Lowest quality stock is spent first
+		for _, resource := range building.ConstructionResources {
+			if _, err := s.inventoryRepo.ConsumeItem(ctx, companyID, resource.ResourceID, resource.Quantity, db.QualityLowestFirst); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publisher.Publish(ctx, events.BuildingPurchased{
+		CompanyID:         companyID,
+		CompanyBuildingID: owned.ID,
+		BuildingID:        building.ID,
+		Cost:              building.Cost,
+	})
+
+	return s.toDetails(ctx, owned, building)
+}
+
+func (s *buildingService) GetCompanyBuildings(ctx context.Context, companyID int64) ([]CompanyBuildingDetails, error) {
+	if _, err := s.upkeepService.Settle(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	owned, err := s.companyBuildingRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]CompanyBuildingDetails, 0, len(owned))
+	for i := range owned {
+		if err := completeUpgrade(ctx, s.companyBuildingRepo, &owned[i], now); err != nil {
+			return nil, err
+		}
+
+		building, err := s.buildingRepo.GetByID(ctx, owned[i].BuildingID)
+		if err != nil {
+			return nil, err
+		}
+
+		details, err := s.toDetails(ctx, &owned[i], building)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *details)
+	}
+
+	return result, nil
+}
+
+func (s *buildingService) GetCompanyBuilding(ctx context.Context, companyID, companyBuildingID int64) (*CompanyBuildingDetails, error) {
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDetails(ctx, owned, building)
+}
+
+// UpgradeBuilding pays the requirements of the next level and starts the
+// upgrade. The building can't run processes until the upgrade finishes.
+func (s *buildingService) UpgradeBuilding(ctx context.Context, companyID, companyBuildingID int64) (*CompanyBuildingDetails, error) {
+	now := time.Now()
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+	if owned.UnderConstruction(now) {
+		return nil, ErrBuildingUnderConstruction
+	}
+	if owned.UpgradeFinishesAt != nil {
+		return nil, ErrBuildingUpgrading
+	}
+
+	activeRuns, err := s.runRepo.GetActiveByCompanyBuilding(ctx, owned.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(activeRuns) > 0 {
+		return nil, ErrBuildingBusy
+	}
+
+	next, err := s.levelRepo.GetByBuildingAndLevel(ctx, owned.BuildingID, owned.Level+1)
+	if err != nil {
+		if err == repository.ErrProductionBuildingLevelNotFound {
+			return nil, ErrMaxLevelReached
+		}
+		return nil, err
+	}
+
+	if _, err := s.inventoryRepo.ExpireLots(ctx, companyID, now); err != nil {
+		return nil, err
+	}
+
+	// Check every resource before removing any of them
+	for _, resource := range next.Resources {
+		stored, err := s.inventoryRepo.GetTotalQuantity(ctx, companyID, resource.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		if stored < resource.Quantity {
+			return nil, repository.ErrInsufficientStock
+		}
+	}
+
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failure takes nothing: the stock keeps its lots as they were
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		// Starting first so two concurrent upgrades can't both be paid
+		if err := s.companyBuildingRepo.StartUpgrade(ctx, owned.ID, now.Add(time.Duration(next.UpgradeTimeMs)*time.Millisecond)); err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Upgraded %s to level %d", building.Name, next.Level)
+		if _, err := s.ledgerService.Apply(ctx, companyID, -next.UpgradeCost, db.LedgerBuildingUpgrade, description, &owned.ID); err != nil {
+			return err
+		}
+
+		// Lowest quality stock is spent first
+		for _, resource := range next.Resources {
+			if _, err := s.inventoryRepo.ConsumeItem(ctx, companyID, resource.ResourceID, resource.Quantity, db.QualityLowestFirst); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err == repository.ErrUpgradeInProgress {
+			return nil, ErrBuildingUpgrading
+		}
+		return nil, err
+	}
+
+	return s.GetCompanyBuilding(ctx, companyID, owned.ID)
+}
+
+// SellBuilding removes an owned building and refunds its depreciated value.
+// Buildings with an active run or an upgrade in progress are only sold when
+// forced; the run and the upgrade payment are then lost.
+func (s *buildingService) SellBuilding(ctx context.Context, companyID, companyBuildingID int64, force bool) (*BuildingSale, error) {
+	// Charge the upkeep owed by the building before it disappears
+	if _, err := s.upkeepService.Settle(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		if owned.UpgradeFinishesAt != nil {
+			return nil, ErrBuildingUpgrading
+		}
+
+		activeRuns, err := s.runRepo.GetActiveByCompanyBuilding(ctx, owned.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(activeRuns) > 0 {
+			return nil, ErrBuildingBusy
+		}
+
+		// Resources delivered by supply links are lost with the building
+		buffer, err := s.bufferRepo.GetByBuilding(ctx, owned.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(buffer) > 0 {
+			return nil, ErrBufferNotEmpty
+		}
+	}
+
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.levelRepo.GetAllByBuilding(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+	refund := saleValue(building, levels, owned, now)
+
+	// A warehouse can't be sold while its space is needed
+	if building.StorageCapacity > 0 && !owned.UnderConstruction(now) {
+		usage, err := s.inventoryRepo.GetStorageUsage(ctx, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if usage.Limited && usage.Used > usage.Capacity-building.StorageCapacity {
+			return nil, ErrStorageInUse
+		}
+	}
+
+	// The building is deleted and refunded together. Deleting first makes a
+	// concurrent sale of the same building fail instead of refunding it twice
+	sale := &BuildingSale{Refund: refund}
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		if err := s.companyBuildingRepo.Delete(ctx, owned.ID); err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Sold %s (level %d)", building.Name, owned.Level)
+		entry, err := s.ledgerService.Apply(ctx, companyID, refund, db.LedgerBuildingSale, description, &owned.ID)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			sale.Balance = entry.Balance
+		}
+		return nil
+	})
+	if err != nil {
+		if err == repository.ErrCompanyBuildingNotFound {
+			return nil, ErrCompanyBuildingNotFound
+		}
+		return nil, err
+	}
+
+	return sale, nil
+}
+
+// RepairBuilding restores the full durability of an owned building and
+// clears a breakdown. It costs the repair cost of every point lost and the
+// repair resources scaled to the share of durability lost.
+func (s *buildingService) RepairBuilding(ctx context.Context, companyID, companyBuildingID int64) (*CompanyBuildingDetails, error) {
+	now := time.Now()
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+	if building.MaxDurability <= 0 || owned.Wear <= 0 {
+		return nil, ErrNothingToRepair
+	}
+
+	activeRuns, err := s.runRepo.GetActiveByCompanyBuilding(ctx, owned.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(activeRuns) > 0 {
+		return nil, ErrBuildingBusy
+	}
+
+	cost, resources := repairCost(building, owned.Wear)
+	if len(resources) > 0 {
+		if _, err := s.inventoryRepo.ExpireLots(ctx, companyID, now); err != nil {
+			return nil, err
+		}
+
+		// Check every resource before removing any of them
+		for _, resource := range resources {
+			stored, err := s.inventoryRepo.GetTotalQuantity(ctx, companyID, resource.ResourceID)
+			if err != nil {
+				return nil, err
+			}
+			if stored < resource.Quantity {
+				return nil, repository.ErrInsufficientStock
+			}
+		}
+	}
+
+	// A failure takes nothing: the stock keeps its lots as they were
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		// Repairing first so two concurrent repairs can't both be paid
+		if err := s.companyBuildingRepo.Repair(ctx, owned.ID, owned.Wear); err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Repaired %s", building.Name)
+		if _, err := s.ledgerService.Apply(ctx, companyID, -cost, db.LedgerBuildingRepair, description, &owned.ID); err != nil {
+			return err
+		}
+
+		// Lowest quality stock is spent first
+		for _, resource := range resources {
+			if _, err := s.inventoryRepo.ConsumeItem(ctx, companyID, resource.ResourceID, resource.Quantity, db.QualityLowestFirst); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err == repository.ErrWearChanged {
+			return nil, ErrRepairConflict
+		}
+		return nil, err
+	}
+
+	return s.GetCompanyBuilding(ctx, companyID, owned.ID)
+}
+
+// repairCost returns the money and resources needed to repair wear points,
+// rounding resource quantities up.
+func repairCost(building *db.ProductionBuilding, wear int64) (int64, []db.ResourceQuantity) {
+	wear = min(wear, building.MaxDurability)
+	resources := make([]db.ResourceQuantity, 0, len(building.RepairResources))
+	for _, resource := range building.RepairResources {
+		quantity := (resource.Quantity*wear + building.MaxDurability - 1) / building.MaxDurability
+		if quantity > 0 {
+			resources = append(resources, db.ResourceQuantity{ResourceID: resource.ResourceID, Quantity: quantity})
+		}
+	}
+	return wear * building.RepairCost, resources
+}
+
+func (s *buildingService) toDetails(ctx context.Context, owned *db.CompanyBuilding, building *db.ProductionBuilding) (*CompanyBuildingDetails, error) {
+	levels, err := s.levelRepo.GetAllByBuilding(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := levelStats(levels, owned.Level)
+	details := &CompanyBuildingDetails{
+		ID:                owned.ID,
+		BuildingID:        owned.BuildingID,
+		Name:              building.Name,
+		Level:             owned.Level,
+		UpgradeFinishesAt: owned.UpgradeFinishesAt,
+		SpeedPercent:      stats.SpeedPercent,
+		OutputPercent:     stats.OutputPercent,
+		MaxBatches:        stats.MaxBatches,
+		Slots:             levelSlots(building, stats),
+		SaleValue:         saleValue(building, levels, owned, time.Now()),
+		Upkeep:            building.Upkeep,
+		CreatedAt:         owned.CreatedAt,
+	}
+
+	now := time.Now()
+	if owned.UnderConstruction(now) {
+		details.ConstructionFinishesAt = owned.ConstructionFinishesAt
+		total := owned.ConstructionFinishesAt.Sub(owned.CreatedAt)
+		if total > 0 {
+			details.ConstructionPercent = max(int64(now.Sub(owned.CreatedAt)*100/total), 0)
+		}
+	} else {
+		details.ConstructionPercent = 100
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := resourceNames(resources)
+
+	if building.MaxDurability > 0 {
+		durability := max(building.MaxDurability-owned.Wear, 0)
+		cost, repairResources := repairCost(building, owned.Wear)
+		details.Durability = &durability
+		details.MaxDurability = building.MaxDurability
+		details.BrokenAt = owned.BrokenAt
+		details.RepairCost = cost
+		details.RepairResources = toResourceDetails(repairResources, names)
+	}
+
+	for _, level := range levels {
+		if level.Level == owned.Level+1 {
+			next := toLevelDetails(level, names)
+			details.NextLevel = &next
+			break
+		}
+	}
+
+	return details, nil
+}
+
+// getOwnedBuilding loads a building owned by the company and applies a
+// finished upgrade. Buildings of other companies are reported as not found.
+func getOwnedBuilding(
+	ctx context.Context,
+	repo repository.CompanyBuildingRepository,
+	companyID int64,
+	companyBuildingID int64,
+	now time.Time,
+) (*db.CompanyBuilding, error) {
+	owned, err := repo.GetByID(ctx, companyBuildingID)
+	if err != nil {
+		if err == repository.ErrCompanyBuildingNotFound {
+			return nil, ErrCompanyBuildingNotFound
+		}
+		return nil, err
+	}
+	if owned.CompanyID != companyID {
+		return nil, ErrCompanyBuildingNotFound
+	}
+
+	if err := completeUpgrade(ctx, repo, owned, now); err != nil {
+		return nil, err
+	}
+
+	return owned, nil
+}
+
+// completeUpgrade lazily applies an upgrade whose time has passed.
+func completeUpgrade(ctx context.Context, repo repository.CompanyBuildingRepository, owned *db.CompanyBuilding, now time.Time) error {
+	if owned.UpgradeFinishesAt == nil || owned.Upgrading(now) {
+		return nil
+	}
+
+	if err := repo.CompleteUpgrade(ctx, owned.ID); err != nil {
+		return err
+	}
+
+	refreshed, err := repo.GetByID(ctx, owned.ID)
+	if err != nil {
+		return err
+	}
+	*owned = *refreshed
+	return nil
+}
+
+// saleValue returns the refund for selling a building: the depreciated sum
+// of its cost and the upgrades paid to reach its current level.
+func saleValue(building *db.ProductionBuilding, levels []db.ProductionBuildingLevel, owned *db.CompanyBuilding, now time.Time) int64 {
+	invested := building.Cost
+	for _, level := range levels {
+		if level.Level > 1 && level.Level <= owned.Level {
+			invested += level.UpgradeCost
+		}
+	}
+
+	return int64(float64(invested) * depreciationPercent(building, now.Sub(owned.CreatedAt)) / 100)
+}
+
+// depreciationPercent returns the share of the invested money a building of
+// the given age is worth.
+func depreciationPercent(building *db.ProductionBuilding, age time.Duration) float64 {
+	days := age.Hours() / 24
+	if days < 0 {
+		days = 0
+	}
+
+	percent := building.DepreciationInitialPercent
+	switch building.DepreciationCurve {
+	case db.DepreciationExponential:
+		percent *= math.Pow(1-building.DepreciationPercentPerDay/100, days)
+	default:
+		percent -= building.DepreciationPercentPerDay * days
+	}
+
+	return math.Max(percent, building.DepreciationFloorPercent)
+}
+
+// levelSlots returns the processes a building can run at once at the level
+// of stats.
+func levelSlots(building *db.ProductionBuilding, stats db.ProductionBuildingLevel) int64 {
+	if stats.Slots > 0 {
+		return stats.Slots
+	}
+	return max(building.Slots, 1)
+}
+
+// levelStats returns the bonuses for a level. A building without level
+// rules behaves as level 1: normal speed and output, unlimited batches.
+func levelStats(levels []db.ProductionBuildingLevel, level int64) db.ProductionBuildingLevel {
+	stats := db.ProductionBuildingLevel{
+		Level:         level,
+		SpeedPercent:  100,
+		OutputPercent: 100,
+	}
+	for _, candidate := range levels {
+		if candidate.Level == level {
+			stats = candidate
+			break
+		}
+	}
+	if stats.SpeedPercent <= 0 {
+		stats.SpeedPercent = 100
+	}
+	if stats.OutputPercent <= 0 {
+		stats.OutputPercent = 100
+	}
+	return stats
+}
+
+func resourceNames(resources []db.Resource) map[int64]string {
+	names := make(map[int64]string, len(resources))
+	for _, res := range resources {
+		names[res.ID] = res.Name
+	}
+	return names
+}
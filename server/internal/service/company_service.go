@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"yourownboss/internal/db"
+	"yourownboss/internal/events"
 	"yourownboss/internal/repository"
 )
 
@@ -29,15 +30,24 @@ type CompanyService interface {
 }
 
 type companyService struct {
-	companyRepo  repository.CompanyRepository
-	initialMoney int64
+	companyRepo   repository.CompanyRepository
+	upkeepService UpkeepService
+	publisher     events.Publisher
+	initialMoney  int64
 }
 
 // NewCompanyService creates a new company service
-func NewCompanyService(companyRepo repository.CompanyRepository, initialMoney int64) CompanyService {
+func NewCompanyService(
+	companyRepo repository.CompanyRepository,
+	upkeepService UpkeepService,
+	publisher events.Publisher,
+	initialMoney int64,
+) CompanyService {
 	return &companyService{
-		companyRepo:  companyRepo,
-		initialMoney: initialMoney,
+		companyRepo:   companyRepo,
+		upkeepService: upkeepService,
+		publisher:     publisher,
+		initialMoney:  initialMoney,
 	}
 }
 
@@ -65,6 +75,8 @@ func (s *companyService) CreateCompany(ctx context.Context, userID int64, name s
 		return nil, err
 	}
 
+	s.publisher.Publish(ctx, events.CompanyCreated{CompanyID: company.ID, UserID: userID, Name: company.Name})
+
 	return company, nil
 }
 
@@ -76,7 +88,9 @@ func (s *companyService) GetCompanyByUserID(ctx context.Context, userID int64) (
 		}
 		return nil, err
 	}
-	return company, nil
+
+	// Charge upkeep missed while the player was away
+	return s.upkeepService.Settle(ctx, company.ID)
 }
 
 func (s *companyService) AddMoney(ctx context.Context, companyID int64, amount int64) error {
@@ -0,0 +1,455 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/events"
+	"yourownboss/internal/repository"
+)
+
+// DefaultContractInterval is how often companies are offered new contracts.
+const DefaultContractInterval = time.Hour
+
+// MaxContractOffers is how many open offers a company can have at once.
+const MaxContractOffers = 3
+
+var (
+	ErrContractNotFound   = errors.New("contract not found")
+	ErrContractNotOffered = errors.New("contract is no longer offered")
+	ErrContractNotActive  = errors.New("contract is not being delivered")
+	ErrInvalidDelivery    = errors.New("delivery quantity cannot be negative")
+	ErrDeliveryConflict   = errors.New("contract changed during the delivery")
+)
+
+// ContractService handles NPC orders. Companies are offered contracts made
+// from the seeded templates, scaled to the buildings they own; accepted
+// contracts are delivered from the inventory, in one go or in parts, and
+// pay their reward once complete. Contracts not complete by their deadline
+// fail and charge the penalty of the undelivered part.
+type ContractService interface {
+	GetContracts(ctx context.Context, companyID int64) ([]ContractDetails, error)
+	AcceptContract(ctx context.Context, companyID, contractID int64) (*ContractDetails, error)
+	DeliverContract(ctx context.Context, companyID, contractID, quantity int64) (*ContractDelivery, error)
+	OfferAll(ctx context.Context) error
+	FailOverdue(ctx context.Context) error
+}
+
+// ContractDetails represents a contract of a company.
+type ContractDetails struct {
+	ID             int64
+	Client         string
+	ResourceID     int64
+	ResourceName   string
+	Quantity       int64
+	Delivered      int64
+	Reward         int64
+	Penalty        int64
+	DurationMs     int64
+	Status         string
+	OfferedAt      time.Time
+	OfferExpiresAt time.Time
+	AcceptedAt     *time.Time
+	Deadline       *time.Time
+	ClosedAt       *time.Time
+}
+
+// ContractDelivery is the result of delivering goods to a contract.
+type ContractDelivery struct {
+	Contract ContractDetails
+	Quantity int64 // Units delivered now
+	Reward   int64 // Paid if the delivery completed the contract
+}
+
+type contractService struct {
+	templateRepo        repository.ContractTemplateRepository
+	contractRepo        repository.ContractRepository
+	companyRepo         repository.CompanyRepository
+	companyBuildingRepo repository.CompanyBuildingRepository
+	resourceRepo        repository.ResourceRepository
+	inventoryRepo       repository.InventoryRepository
+	ledgerService       LedgerService
+	upkeepService       UpkeepService
+	publisher           events.Publisher
+	interval            time.Duration
+}
+
+// NewContractService creates a new contract service. A non-positive
+// interval uses DefaultContractInterval.
+func NewContractService(
+	templateRepo repository.ContractTemplateRepository,
+	contractRepo repository.ContractRepository,
+	companyRepo repository.CompanyRepository,
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	resourceRepo repository.ResourceRepository,
+	inventoryRepo repository.InventoryRepository,
+	ledgerService LedgerService,
+	upkeepService UpkeepService,
+	publisher events.Publisher,
+	interval time.Duration,
+) ContractService {
+	if interval <= 0 {
+		interval = DefaultContractInterval
+	}
+
+	return &contractService{
+		templateRepo:        templateRepo,
+		contractRepo:        contractRepo,
+		companyRepo:         companyRepo,
+		companyBuildingRepo: companyBuildingRepo,
+		resourceRepo:        resourceRepo,
+		inventoryRepo:       inventoryRepo,
+		ledgerService:       ledgerService,
+		upkeepService:       upkeepService,
+		publisher:           publisher,
+		interval:            interval,
+	}
+}
+
+// GetContracts settles the company's overdue contracts, tops up its offers
+// if they are due and returns every contract it has, newest first.
+func (s *contractService) GetContracts(ctx context.Context, companyID int64) ([]ContractDetails, error) {
+	now := time.Now()
+	if err := s.refresh(ctx, companyID, now); err != nil {
+		return nil, err
+	}
+
+	contracts, err := s.contractRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := s.resourceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ContractDetails, 0, len(contracts))
+	for _, contract := range contracts {
+		result = append(result, toContractDetails(contract, names))
+	}
+
+	return result, nil
+}
+
+// AcceptContract starts an offered contract. Its deadline counts from now.
+func (s *contractService) AcceptContract(ctx context.Context, companyID, contractID int64) (*ContractDetails, error) {
+	contract, err := s.getCompanyContract(ctx, companyID, contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if contract.Status != db.ContractOffered || !now.Before(contract.OfferExpiresAt) {
+		return nil, ErrContractNotOffered
+	}
+
+	deadline := now.Add(time.Duration(contract.DurationMs) * time.Millisecond)
+	if err := s.contractRepo.Accept(ctx, contract.ID, now, deadline); err != nil {
+		if err == repository.ErrContractChanged {
+			return nil, ErrContractNotOffered
+		}
+		return nil, err
+	}
+
+	return s.details(ctx, contract.ID)
+}
+
+// DeliverContract takes goods from the inventory, lowest quality first, and
+// delivers them to an accepted contract. A zero quantity delivers everything
+// left, and larger quantities are capped to it. The delivery that completes
+// the contract pays the reward.
+func (s *contractService) DeliverContract(ctx context.Context, companyID, contractID, quantity int64) (*ContractDelivery, error) {
+	if quantity < 0 {
+		return nil, ErrInvalidDelivery
+	}
+
+	contract, err := s.getCompanyContract(ctx, companyID, contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if contract.Overdue(now) {
+		if err := s.fail(ctx, contract, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrContractNotActive
+	}
+	if contract.Status != db.ContractAccepted {
+		return nil, ErrContractNotActive
+	}
+
+	remaining := contract.Quantity - contract.Delivered
+	if quantity == 0 || quantity > remaining {
+		quantity = remaining
+	}
+
+	// Recording the units first keeps concurrent deliveries from going over
+	// the contract quantity
+	if err := s.contractRepo.AddDelivered(ctx, contract.ID, quantity, now); err != nil {
+		if err == repository.ErrContractChanged {
+			return nil, ErrDeliveryConflict
+		}
+		return nil, err
+	}
+
+	if _, err := s.inventoryRepo.ConsumeItem(ctx, companyID, contract.ResourceID, quantity, db.QualityLowestFirst); err != nil {
+		// Rollback: the goods were not delivered
+		_ = s.contractRepo.UndoDelivered(ctx, contract.ID, quantity)
+		return nil, err
+	}
+
+	var reward int64
+	if contract.Delivered+quantity == contract.Quantity {
+		reward, err = s.complete(ctx, contract, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	details, err := s.details(ctx, contract.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContractDelivery{Contract: *details, Quantity: quantity, Reward: reward}, nil
+}
+
+// OfferAll tops up the offers of every company. It's run by the contracts
+// job so that offers keep coming while nobody is looking.
+func (s *contractService) OfferAll(ctx context.Context) error {
+	companies, err := s.companyRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, company := range companies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.offer(ctx, company.ID, now); err != nil {
+			return fmt.Errorf("offer contracts to company %d: %w", company.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// FailOverdue fails every accepted contract past its deadline and charges
+// the penalties.
+func (s *contractService) FailOverdue(ctx context.Context) error {
+	now := time.Now()
+	overdue, err := s.contractRepo.GetOverdue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for i := range overdue {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.fail(ctx, &overdue[i], now); err != nil {
+			return fmt.Errorf("fail contract %d: %w", overdue[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// refresh fails the overdue contracts of a company and tops up its offers.
+func (s *contractService) refresh(ctx context.Context, companyID int64, now time.Time) error {
+	contracts, err := s.contractRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return err
+	}
+
+	for i := range contracts {
+		if contracts[i].Overdue(now) {
+			if err := s.fail(ctx, &contracts[i], now); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.offer(ctx, companyID, now)
+}
+
+// offer removes the expired offers of a company and, if it wasn't offered
+// anything during the last interval, fills its open offers up to
+// MaxContractOffers from the templates it qualifies for.
+func (s *contractService) offer(ctx context.Context, companyID int64, now time.Time) error {
+	if err := s.contractRepo.DeleteExpiredOffers(ctx, companyID, now); err != nil {
+		return err
+	}
+
+	templates, err := s.templateRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	owned, err := s.companyBuildingRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return err
+	}
+	buildings := int64(len(owned))
+
+	var eligible []db.ContractTemplate
+	for _, template := range templates {
+		if buildings >= template.MinBuildings {
+			eligible = append(eligible, template)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+
+	// Templates are drawn without replacement, so offers don't repeat
+	count := min(len(eligible), MaxContractOffers)
+	offers := make([]db.Contract, 0, count)
+	for _, i := range rand.Perm(len(eligible))[:count] {
+		offers = append(offers, newContractOffer(eligible[i], buildings, now))
+	}
+
+	_, err = s.contractRepo.AddOffers(ctx, companyID, offers, MaxContractOffers, now, now.Add(-s.interval))
+	return err
+}
+
+// complete closes a fully delivered contract and pays its reward. It
+// returns the reward paid, none if the contract was closed concurrently.
+func (s *contractService) complete(ctx context.Context, contract *db.Contract, now time.Time) (int64, error) {
+	if err := s.contractRepo.Complete(ctx, contract.ID, now); err != nil {
+		if err == repository.ErrContractChanged {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	description := fmt.Sprintf("Contract for %s completed", contract.Client)
+	if _, err := s.ledgerService.Apply(ctx, contract.CompanyID, contract.Reward, db.LedgerContractReward, description, &contract.ID); err != nil {
+		return 0, err
+	}
+
+	s.publisher.Publish(ctx, events.ContractCompleted{
+		CompanyID:  contract.CompanyID,
+		ContractID: contract.ID,
+		ResourceID: contract.ResourceID,
+		Reward:     contract.Reward,
+	})
+
+	return contract.Reward, nil
+}
+
+// fail closes an overdue contract and charges the penalty of the part not
+// delivered. Penalties the company can't afford go to its upkeep debt.
+func (s *contractService) fail(ctx context.Context, contract *db.Contract, now time.Time) error {
+	if err := s.contractRepo.Fail(ctx, contract.ID, now); err != nil {
+		if err == repository.ErrContractChanged {
+			// Closed concurrently
+			return nil
+		}
+		return err
+	}
+
+	penalty := contractPenalty(contract)
+	if penalty <= 0 {
+		return nil
+	}
+
+	description := fmt.Sprintf("Penalty for the contract for %s", contract.Client)
+	return s.upkeepService.Charge(ctx, contract.CompanyID, penalty, db.LedgerContractPenalty, description, &contract.ID)
+}
+
+func (s *contractService) getCompanyContract(ctx context.Context, companyID, contractID int64) (*db.Contract, error) {
+	contract, err := s.contractRepo.GetByID(ctx, contractID)
+	if err != nil {
+		if err == repository.ErrContractNotFound {
+			return nil, ErrContractNotFound
+		}
+		return nil, err
+	}
+	if contract.CompanyID != companyID {
+		return nil, ErrContractNotFound
+	}
+	return contract, nil
+}
+
+func (s *contractService) details(ctx context.Context, contractID int64) (*ContractDetails, error) {
+	contract, err := s.contractRepo.GetByID(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := s.resourceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	details := toContractDetails(*contract, names)
+	return &details, nil
+}
+
+func (s *contractService) resourceNames(ctx context.Context) (map[int64]string, error) {
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resourceNames(resources), nil
+}
+
+// newContractOffer makes an offer from a template. Quantity, reward and
+// penalty grow by the template's scale for every building owned.
+func newContractOffer(template db.ContractTemplate, buildings int64, now time.Time) db.Contract {
+	factor := 1 + template.ScalePerBuilding*float64(buildings)
+	scale := func(value int64) int64 {
+		return int64(math.Round(float64(value) * factor))
+	}
+
+	return db.Contract{
+		TemplateID:     template.ID,
+		Client:         template.Client,
+		ResourceID:     template.ResourceID,
+		Quantity:       max(scale(template.Quantity), 1),
+		Reward:         scale(template.Reward),
+		Penalty:        scale(template.Penalty),
+		DurationMs:     template.DurationMs,
+		Status:         db.ContractOffered,
+		OfferedAt:      now,
+		OfferExpiresAt: now.Add(time.Duration(template.OfferDurationMs) * time.Millisecond),
+	}
+}
+
+// contractPenalty returns the penalty of a failed contract, prorated to the
+// units not delivered.
+func contractPenalty(contract *db.Contract) int64 {
+	if contract.Quantity <= 0 {
+		return contract.Penalty
+	}
+	return contract.Penalty * (contract.Quantity - contract.Delivered) / contract.Quantity
+}
+
+func toContractDetails(contract db.Contract, names map[int64]string) ContractDetails {
+	return ContractDetails{
+		ID:             contract.ID,
+		Client:         contract.Client,
+		ResourceID:     contract.ResourceID,
+		ResourceName:   names[contract.ResourceID],
+		Quantity:       contract.Quantity,
+		Delivered:      contract.Delivered,
+		Reward:         contract.Reward,
+		Penalty:        contract.Penalty,
+		DurationMs:     contract.DurationMs,
+		Status:         contract.Status,
+		OfferedAt:      contract.OfferedAt,
+		OfferExpiresAt: contract.OfferExpiresAt,
+		AcceptedAt:     contract.AcceptedAt,
+		Deadline:       contract.Deadline,
+		ClosedAt:       contract.ClosedAt,
+	}
+}
@@ -3,38 +3,53 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"yourownboss/internal/db"
+	"yourownboss/internal/events"
 	"yourownboss/internal/repository"
 )
 
+// ExpiringSoonWindow is how far ahead the inventory reports expiring stock.
+const ExpiringSoonWindow = 24 * time.Hour
+
 var (
 	ErrMarketInsufficientFunds = errors.New("insufficient funds to buy")
 	ErrResourceDoesNotExist    = errors.New("resource does not exist")
+	ErrInvalidQuality          = errors.New("invalid quality")
 )
 
+// qualityBonusPercent is how much more each quality tier above standard
+// sells for on the market.
+const qualityBonusPercent = 25
+
 // InventoryService handles inventory business logic
 type InventoryService interface {
 	GetInventory(ctx context.Context, companyID int64) ([]db.InventoryWithDetails, error)
 	GetResource(ctx context.Context, resourceID int64) (*db.Resource, error)
 	GetAllResources(ctx context.Context) ([]db.Resource, error)
+	RemoveExpired(ctx context.Context) (int64, error)
+	GetStorage(ctx context.Context, companyID int64) (*db.StorageUsage, error)
 }
 
 // MarketService handles buying and selling
 type MarketService interface {
 	BuyResource(ctx context.Context, companyID, resourceID int64, packCount int64) error
-	SellResource(ctx context.Context, companyID, resourceID int64, packCount int64) error
+	SellResource(ctx context.Context, companyID, resourceID, quality int64, packCount int64) error
 }
 
 type inventoryService struct {
-	resourceRepo   repository.ResourceRepository
-	inventoryRepo  repository.InventoryRepository
+	resourceRepo  repository.ResourceRepository
+	inventoryRepo repository.InventoryRepository
 }
 
 type marketService struct {
 	resourceRepo  repository.ResourceRepository
-	companyRepo   repository.CompanyRepository
 	inventoryRepo repository.InventoryRepository
+	ledgerService LedgerService
+	publisher     events.Publisher
+	transactor    repository.Transactor
 }
 
 // NewInventoryService creates a new inventory service
@@ -43,28 +58,65 @@ func NewInventoryService(
 	inventoryRepo repository.InventoryRepository,
 ) InventoryService {
 	return &inventoryService{
-		resourceRepo:   resourceRepo,
-		inventoryRepo:  inventoryRepo,
+		resourceRepo:  resourceRepo,
+		inventoryRepo: inventoryRepo,
 	}
 }
 
 // NewMarketService creates a new market service
 func NewMarketService(
 	resourceRepo repository.ResourceRepository,
-	companyRepo repository.CompanyRepository,
 	inventoryRepo repository.InventoryRepository,
+	ledgerService LedgerService,
+	publisher events.Publisher,
+	transactor repository.Transactor,
 ) MarketService {
 	return &marketService{
 		resourceRepo:  resourceRepo,
-		companyRepo:   companyRepo,
 		inventoryRepo: inventoryRepo,
+		ledgerService: ledgerService,
+		publisher:     publisher,
+		transactor:    transactor,
 	}
 }
 
 // --- Inventory Service Implementation ---
 
+// GetInventory returns the company inventory after removing expired stock,
+// with the quantity of each resource that expires soon.
 func (s *inventoryService) GetInventory(ctx context.Context, companyID int64) ([]db.InventoryWithDetails, error) {
-	return s.inventoryRepo.GetAllByCompanyWithDetails(ctx, companyID)
+	now := time.Now()
+	if _, err := s.inventoryRepo.ExpireLots(ctx, companyID, now); err != nil {
+		return nil, err
+	}
+
+	items, err := s.inventoryRepo.GetAllByCompanyWithDetails(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	lots, err := s.inventoryRepo.GetLotsByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	soon := now.Add(ExpiringSoonWindow)
+	for i := range items {
+		for _, lot := range lots {
+			if lot.ResourceID != items[i].ResourceID || lot.Quality != items[i].Quality || lot.ExpiresAt == nil {
+				continue
+			}
+			if lot.ExpiresAt.Before(soon) {
+				items[i].ExpiringSoon += lot.Quantity
+			}
+			if items[i].NextExpiryAt == nil || lot.ExpiresAt.Before(*items[i].NextExpiryAt) {
+				expiresAt := *lot.ExpiresAt
+				items[i].NextExpiryAt = &expiresAt
+			}
+		}
+	}
+
+	return items, nil
 }
 
 func (s *inventoryService) GetResource(ctx context.Context, resourceID int64) (*db.Resource, error) {
@@ -75,10 +127,21 @@ func (s *inventoryService) GetAllResources(ctx context.Context) ([]db.Resource,
 	return s.resourceRepo.GetAll(ctx)
 }
 
+func (s *inventoryService) GetStorage(ctx context.Context, companyID int64) (*db.StorageUsage, error) {
+	return s.inventoryRepo.GetStorageUsage(ctx, companyID)
+}
+
+// RemoveExpired removes expired stock from every company and returns the
+// number of units removed.
+func (s *inventoryService) RemoveExpired(ctx context.Context) (int64, error) {
+	return s.inventoryRepo.ExpireAllLots(ctx, time.Now())
+}
+
 // --- Market Service Implementation ---
 
 // BuyResource buys packCount number of packs of a resource
-// Each pack contains resource.PackSize units and costs resource.Price
+// Each pack contains resource.PackSize units of standard quality and costs
+// resource.Price
 func (s *marketService) BuyResource(ctx context.Context, companyID, resourceID int64, packCount int64) error {
 	if packCount <= 0 {
 		return errors.New("pack count must be positive")
@@ -93,38 +156,48 @@ func (s *marketService) BuyResource(ctx context.Context, companyID, resourceID i
 	totalCost := resource.Price * packCount
 	totalUnits := resource.PackSize * packCount
 
-	// Get company
-	company, err := s.companyRepo.GetByID(ctx, companyID)
+	// Deduct money from company and add the items to its inventory
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		description := fmt.Sprintf("Bought %d packs of %s", packCount, resource.Name)
+		if _, err := s.ledgerService.Apply(ctx, companyID, -totalCost, db.LedgerMarketBuy, description, &resourceID); err != nil {
+			return err
+		}
+		return s.inventoryRepo.AddItem(ctx, companyID, resourceID, db.DefaultQuality, totalUnits)
+	})
 	if err != nil {
+		if err == ErrInsufficientFunds {
+			return ErrMarketInsufficientFunds
+		}
 		return err
 	}
 
-	// Check if company has enough funds
-	if company.Money < totalCost {
-		return ErrMarketInsufficientFunds
-	}
-
-	// Deduct money from company
-	if err := s.companyRepo.UpdateMoney(ctx, companyID, company.Money-totalCost); err != nil {
-		return err
-	}
-
-	// Add items to inventory
-	if err := s.inventoryRepo.AddItem(ctx, companyID, resourceID, totalUnits); err != nil {
-		// Rollback: return money if inventory add fails
-		_ = s.companyRepo.UpdateMoney(ctx, companyID, company.Money)
-		return err
-	}
+	s.publisher.Publish(ctx, events.TradeExecuted{
+		CompanyID:  companyID,
+		Side:       events.SideBuy,
+		ResourceID: resourceID,
+		Quality:    db.DefaultQuality,
+		Packs:      packCount,
+		Units:      totalUnits,
+		Amount:     totalCost,
+	})
 
 	return nil
 }
 
-// SellResource sells packCount number of packs of a resource
-// Each pack contains resource.PackSize units and is sold for resource.Price
-func (s *marketService) SellResource(ctx context.Context, companyID, resourceID int64, packCount int64) error {
+// SellResource sells packCount number of packs of a resource of the given
+// quality (standard when 0). Each pack contains resource.PackSize units and
+// is sold for resource.Price, plus qualityBonusPercent for every tier above
+// standard.
+func (s *marketService) SellResource(ctx context.Context, companyID, resourceID, quality int64, packCount int64) error {
 	if packCount <= 0 {
 		return errors.New("pack count must be positive")
 	}
+	if quality == 0 {
+		quality = db.DefaultQuality
+	}
+	if quality < db.DefaultQuality || quality > db.MaxQuality {
+		return ErrInvalidQuality
+	}
 
 	// Get the resource
 	resource, err := s.resourceRepo.GetByID(ctx, resourceID)
@@ -132,11 +205,16 @@ func (s *marketService) SellResource(ctx context.Context, companyID, resourceID
 		return err
 	}
 
-	totalRevenue := resource.Price * packCount
+	totalRevenue := qualityPrice(resource.Price, quality) * packCount
 	totalUnits := resource.PackSize * packCount
 
+	// Expired stock can't be sold
+	if _, err := s.inventoryRepo.ExpireLots(ctx, companyID, time.Now()); err != nil {
+		return err
+	}
+
 	// Check if company has enough items to sell
-	inv, err := s.inventoryRepo.GetByCompanyAndResource(ctx, companyID, resourceID)
+	inv, err := s.inventoryRepo.GetByCompanyAndResource(ctx, companyID, resourceID, quality)
 	if err != nil {
 		if err == repository.ErrInventoryNotFound {
 			return repository.ErrInsufficientStock
@@ -148,23 +226,35 @@ func (s *marketService) SellResource(ctx context.Context, companyID, resourceID
 		return repository.ErrInsufficientStock
 	}
 
-	// Get company current money
-	company, err := s.companyRepo.GetByID(ctx, companyID)
-	if err != nil {
-		return err
+	// Add money to company and remove the items from its inventory
+	description := fmt.Sprintf("Sold %d packs of %s", packCount, resource.Name)
+	if quality != db.DefaultQuality {
+		description = fmt.Sprintf("Sold %d packs of %s (quality %d)", packCount, resource.Name, quality)
 	}
-
-	// Add money to company
-	if err := s.companyRepo.UpdateMoney(ctx, companyID, company.Money+totalRevenue); err != nil {
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		if _, err := s.ledgerService.Apply(ctx, companyID, totalRevenue, db.LedgerMarketSell, description, &resourceID); err != nil {
+			return err
+		}
+		return s.inventoryRepo.RemoveItem(ctx, companyID, resourceID, quality, totalUnits)
+	})
+	if err != nil {
 		return err
 	}
 
-	// Remove items from inventory
-	if err := s.inventoryRepo.RemoveItem(ctx, companyID, resourceID, totalUnits); err != nil {
-		// Rollback: return money if removal fails
-		_ = s.companyRepo.UpdateMoney(ctx, companyID, company.Money)
-		return err
-	}
+	s.publisher.Publish(ctx, events.TradeExecuted{
+		CompanyID:  companyID,
+		Side:       events.SideSell,
+		ResourceID: resourceID,
+		Quality:    quality,
+		Packs:      packCount,
+		Units:      totalUnits,
+		Amount:     totalRevenue,
+	})
 
 	return nil
 }
+
+// qualityPrice returns the market price of a pack of the given quality.
+func qualityPrice(price, quality int64) int64 {
+	return price * (100 + (quality-db.DefaultQuality)*qualityBonusPercent) / 100
+}
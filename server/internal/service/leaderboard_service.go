@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+// DefaultLeaderboardInterval is how often the leaderboards are recomputed.
+const DefaultLeaderboardInterval = 5 * time.Minute
+
+const (
+	DefaultLeaderboardPageSize = 50
+	MaxLeaderboardPageSize     = 200
+)
+
+var (
+	ErrUnknownBoard          = errors.New("unknown leaderboard")
+	ErrInvalidPeriod         = errors.New("period not available for the leaderboard")
+	ErrInvalidBoardResource  = errors.New("production leaderboards need an item resource")
+	ErrUnexpectedBoardFilter = errors.New("only production leaderboards take a resource")
+)
+
+// periodLengths are the windows of the leaderboard periods, counting back
+// from the refresh. All-time boards have no window.
+var periodLengths = map[string]time.Duration{
+	db.PeriodDaily:  24 * time.Hour,
+	db.PeriodWeekly: 7 * 24 * time.Hour,
+	db.PeriodAll:    0,
+}
+
+// LeaderboardService ranks companies on public leaderboards. Rankings are
+// computed by the leaderboard job and stored, so reading a board never scans
+// the companies.
+type LeaderboardService interface {
+	GetLeaderboard(ctx context.Context, query LeaderboardQuery, companyID int64) (*Leaderboard, error)
+	RecordProduction(ctx context.Context, companyID int64, outputs []db.ResourceQuantity, producedAt time.Time) error
+	Refresh(ctx context.Context) error
+}
+
+// LeaderboardQuery selects a page of a board. Period defaults to all-time
+// and ResourceID is only set for production boards.
+type LeaderboardQuery struct {
+	Board      string
+	Period     string
+	ResourceID int64
+	Limit      int
+	Offset     int
+}
+
+// Leaderboard is a page of a board. Mine is the position of the requesting
+// company, nil when it's anonymous or not ranked.
+type Leaderboard struct {
+	Board      string
+	Period     string
+	ResourceID int64
+	Total      int64 // Companies ranked
+	ComputedAt *time.Time
+	Entries    []db.LeaderboardEntry
+	Mine       *db.LeaderboardEntry
+}
+
+type leaderboardService struct {
+	leaderboardRepo  repository.LeaderboardRepository
+	companyRepo      repository.CompanyRepository
+	resourceRepo     repository.ResourceRepository
+	ledgerRepo       repository.LedgerRepository
+	outputRepo       repository.ProductionOutputRepository
+	valuationService ValuationService
+}
+
+// NewLeaderboardService creates a new leaderboard service.
+func NewLeaderboardService(
+	leaderboardRepo repository.LeaderboardRepository,
+	companyRepo repository.CompanyRepository,
+	resourceRepo repository.ResourceRepository,
+	ledgerRepo repository.LedgerRepository,
+	outputRepo repository.ProductionOutputRepository,
+	valuationService ValuationService,
+) LeaderboardService {
+	return &leaderboardService{
+		leaderboardRepo:  leaderboardRepo,
+		companyRepo:      companyRepo,
+		resourceRepo:     resourceRepo,
+		ledgerRepo:       ledgerRepo,
+		outputRepo:       outputRepo,
+		valuationService: valuationService,
+	}
+}
+
+func (s *leaderboardService) GetLeaderboard(ctx context.Context, query LeaderboardQuery, companyID int64) (*Leaderboard, error) {
+	if query.Period == "" {
+		query.Period = db.PeriodAll
+	}
+	if err := s.validate(ctx, query); err != nil {
+		return nil, err
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = DefaultLeaderboardPageSize
+	}
+	if query.Limit > MaxLeaderboardPageSize {
+		query.Limit = MaxLeaderboardPageSize
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	entries, err := s.leaderboardRepo.GetPage(ctx, query.Board, query.Period, query.ResourceID, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.leaderboardRepo.Count(ctx, query.Board, query.Period, query.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := &Leaderboard{
+		Board:      query.Board,
+		Period:     query.Period,
+		ResourceID: query.ResourceID,
+		Total:      total,
+		Entries:    entries,
+	}
+	if len(entries) > 0 {
+		leaderboard.ComputedAt = &entries[0].ComputedAt
+	}
+
+	if companyID != 0 {
+		mine, err := s.leaderboardRepo.GetByCompany(ctx, query.Board, query.Period, query.ResourceID, companyID)
+		switch err {
+		case nil:
+			leaderboard.Mine = mine
+			leaderboard.ComputedAt = &mine.ComputedAt
+		case repository.ErrLeaderboardEntryNotFound:
+		default:
+			return nil, err
+		}
+	}
+
+	return leaderboard, nil
+}
+
+func (s *leaderboardService) validate(ctx context.Context, query LeaderboardQuery) error {
+	if _, ok := periodLengths[query.Period]; !ok {
+		return ErrInvalidPeriod
+	}
+
+	switch query.Board {
+	case db.BoardNetWorth, db.BoardCash:
+		if query.Period != db.PeriodAll {
+			return ErrInvalidPeriod
+		}
+	case db.BoardMarketProfit:
+	case db.BoardProduction:
+		resource, err := s.resourceRepo.GetByID(ctx, query.ResourceID)
+		if err != nil {
+			if err == repository.ErrResourceNotFound {
+				return ErrInvalidBoardResource
+			}
+			return err
+		}
+		if resource.IsFlow() {
+			return ErrInvalidBoardResource
+		}
+		return nil
+	default:
+		return ErrUnknownBoard
+	}
+
+	if query.ResourceID != 0 {
+		return ErrUnexpectedBoardFilter
+	}
+	return nil
+}
+
+// RecordProduction records units collected from a run for the production
+// boards. Qualities are not told apart.
+func (s *leaderboardService) RecordProduction(ctx context.Context, companyID int64, outputs []db.ResourceQuantity, producedAt time.Time) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+	return s.outputRepo.Create(ctx, companyID, outputs, producedAt)
+}
+
+// Refresh recomputes every board and replaces the stored rankings. It's run
+// by the leaderboard job.
+func (s *leaderboardService) Refresh(ctx context.Context) error {
+	now := time.Now()
+	var entries []db.LeaderboardEntry
+
+	valuations, err := s.valuationService.ValueAll(ctx)
+	if err != nil {
+		return err
+	}
+	netWorth := make(map[int64]int64, len(valuations))
+	cash := make(map[int64]int64, len(valuations))
+	for _, valuation := range valuations {
+		netWorth[valuation.CompanyID] = valuation.NetWorth
+		cash[valuation.CompanyID] = valuation.Cash
+	}
+	entries = append(entries, rankBoard(db.BoardNetWorth, db.PeriodAll, 0, netWorth, now)...)
+	entries = append(entries, rankBoard(db.BoardCash, db.PeriodAll, 0, cash, now)...)
+
+	for _, period := range []string{db.PeriodDaily, db.PeriodWeekly, db.PeriodAll} {
+		var since time.Time
+		if length := periodLengths[period]; length > 0 {
+			since = now.Add(-length)
+		}
+
+		profits, err := s.ledgerRepo.GetTotals(ctx, []string{db.LedgerMarketBuy, db.LedgerMarketSell}, since)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, rankBoard(db.BoardMarketProfit, period, 0, profits, now)...)
+
+		totals, err := s.outputRepo.GetTotals(ctx, since)
+		if err != nil {
+			return err
+		}
+		produced := make(map[int64]map[int64]int64)
+		for _, total := range totals {
+			if produced[total.ResourceID] == nil {
+				produced[total.ResourceID] = make(map[int64]int64)
+			}
+			produced[total.ResourceID][total.CompanyID] = total.Quantity
+		}
+		for resourceID, values := range produced {
+			entries = append(entries, rankBoard(db.BoardProduction, period, resourceID, values, now)...)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.leaderboardRepo.ReplaceAll(ctx, entries)
+}
+
+// rankBoard orders the values of a board, highest first. Ties share a rank
+// and the next value skips the shared places (1, 2, 2, 4).
+func rankBoard(board, period string, resourceID int64, values map[int64]int64, now time.Time) []db.LeaderboardEntry {
+	entries := make([]db.LeaderboardEntry, 0, len(values))
+	for companyID, value := range values {
+		entries = append(entries, db.LeaderboardEntry{
+			Board:      board,
+			Period:     period,
+			ResourceID: resourceID,
+			CompanyID:  companyID,
+			Value:      value,
+			ComputedAt: now,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].CompanyID < entries[j].CompanyID
+	})
+
+	for i := range entries {
+		if i > 0 && entries[i].Value == entries[i-1].Value {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = int64(i + 1)
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"yourownboss/internal/db"
+)
+
+func TestRankBoard(t *testing.T) {
+	type ranked struct {
+		CompanyID int64
+		Rank      int64
+	}
+	tests := []struct {
+		name   string
+		values map[int64]int64
+		want   []ranked
+	}{
+		{
+			name:   "empty",
+			values: map[int64]int64{},
+			want:   []ranked{},
+		},
+		{
+			name:   "highest first",
+			values: map[int64]int64{1: 10, 2: 30, 3: 20},
+			want:   []ranked{{2, 1}, {3, 2}, {1, 3}},
+		},
+		{
+			name:   "ties share a rank and skip the next",
+			values: map[int64]int64{1: 50, 2: 40, 3: 40, 4: 10},
+			want:   []ranked{{1, 1}, {2, 2}, {3, 2}, {4, 4}},
+		},
+		{
+			name:   "tie for first",
+			values: map[int64]int64{5: 7, 3: 7, 4: 7, 1: 2},
+			want:   []ranked{{3, 1}, {4, 1}, {5, 1}, {1, 4}},
+		},
+		{
+			name:   "negative values",
+			values: map[int64]int64{1: -5, 2: 0, 3: -5},
+			want:   []ranked{{2, 1}, {1, 2}, {3, 2}},
+		},
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := rankBoard(db.BoardProduction, db.PeriodDaily, 9, tt.values, now)
+
+			got := make([]ranked, 0, len(entries))
+			for _, entry := range entries {
+				if entry.Board != db.BoardProduction || entry.Period != db.PeriodDaily || entry.ResourceID != 9 || !entry.ComputedAt.Equal(now) {
+					t.Fatalf("entry %+v doesn't describe the board", entry)
+				}
+				if entry.Value != tt.values[entry.CompanyID] {
+					t.Fatalf("company %d has value %d, want %d", entry.CompanyID, entry.Value, tt.values[entry.CompanyID])
+				}
+				got = append(got, ranked{entry.CompanyID, entry.Rank})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ranks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
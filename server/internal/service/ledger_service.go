@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+const (
+	DefaultLedgerPageSize = 50
+	MaxLedgerPageSize     = 200
+)
+
+// LedgerService applies changes to company money and keeps their history.
+// Every money movement should go through Apply so the ledger stays complete.
+type LedgerService interface {
+	Apply(ctx context.Context, companyID, amount int64, kind, description string, referenceID *int64) (*db.LedgerEntry, error)
+	Revert(ctx context.Context, entry *db.LedgerEntry) error
+	GetHistory(ctx context.Context, companyID int64, limit, offset int) ([]db.LedgerEntry, error)
+}
+
+type ledgerService struct {
+	companyRepo repository.CompanyRepository
+	ledgerRepo  repository.LedgerRepository
+}
+
+// NewLedgerService creates a new ledger service.
+func NewLedgerService(companyRepo repository.CompanyRepository, ledgerRepo repository.LedgerRepository) LedgerService {
+	return &ledgerService{
+		companyRepo: companyRepo,
+		ledgerRepo:  ledgerRepo,
+	}
+}
+
+// Apply adds a signed amount to the company's money and records it. Charges
+// that would leave the company with negative money fail with
+// ErrInsufficientFunds. A zero amount is a no-op and returns a nil entry.
+func (s *ledgerService) Apply(
+	ctx context.Context,
+	companyID int64,
+	amount int64,
+	kind string,
+	description string,
+	referenceID *int64,
+) (*db.LedgerEntry, error) {
+	if amount == 0 {
+		return nil, nil
+	}
+
+	balance, err := s.companyRepo.AdjustMoney(ctx, companyID, amount)
+	if err != nil {
+		if err == repository.ErrInsufficientMoney {
+			return nil, ErrInsufficientFunds
+		}
+		return nil, err
+	}
+
+	entry := &db.LedgerEntry{
+		CompanyID:   companyID,
+		Amount:      amount,
+		Balance:     balance,
+		Kind:        kind,
+		Description: description,
+		ReferenceID: referenceID,
+	}
+	if err := s.ledgerRepo.Create(ctx, entry); err != nil {
+		// Rollback: don't keep a money change without its history
+		_, _ = s.companyRepo.AdjustMoney(ctx, companyID, -amount)
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Revert undoes an entry applied as part of an operation that failed later.
+func (s *ledgerService) Revert(ctx context.Context, entry *db.LedgerEntry) error {
+	if entry == nil {
+		return nil
+	}
+
+	if _, err := s.companyRepo.AdjustMoney(ctx, entry.CompanyID, -entry.Amount); err != nil {
+		return err
+	}
+	return s.ledgerRepo.Delete(ctx, entry.ID)
+}
+
+func (s *ledgerService) GetHistory(ctx context.Context, companyID int64, limit, offset int) ([]db.LedgerEntry, error) {
+	if limit <= 0 {
+		limit = DefaultLedgerPageSize
+	}
+	if limit > MaxLedgerPageSize {
+		limit = MaxLedgerPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.ledgerRepo.GetAllByCompany(ctx, companyID, limit, offset)
+}
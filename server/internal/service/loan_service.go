@@ -0,0 +1,511 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+// MaxLoanPeriods is the longest repayment schedule a loan can have.
+const MaxLoanPeriods = 168
+
+// MissedPaymentsToDefault is how many installments in a row a company can
+// miss before its loan defaults.
+const MissedPaymentsToDefault = 3
+
+var (
+	ErrLoanNotFound       = errors.New("loan not found")
+	ErrLoanClosed         = errors.New("loan is already closed")
+	ErrInvalidLoanAmount  = errors.New("loan amount must be positive")
+	ErrInvalidLoanPeriods = errors.New("loan periods out of range")
+	ErrCreditLimit        = errors.New("loan exceeds the credit limit")
+	ErrLoansBlocked       = errors.New("company defaulted on a loan")
+	ErrRepayConflict      = errors.New("loan changed during the repayment")
+)
+
+// LoanTerms sets the interest and credit limit of bank loans.
+type LoanTerms struct {
+	InterestPercent    float64 // Per upkeep period, on the balance
+	CreditLimitPercent float64 // Of net worth, for all active loans together
+}
+
+// DefaultLoanTerms charges 0.5% per period and lends up to half of the
+// company's net worth.
+var DefaultLoanTerms = LoanTerms{
+	InterestPercent:    0.5,
+	CreditLimitPercent: 50,
+}
+
+// LoanService handles bank loans. Loans are paid back in installments, one
+// every upkeep period: an equal share of the balance plus the interest on
+// it. Installments the company can't afford are missed and their interest is
+// added to the balance; after MissedPaymentsToDefault in a row the loan
+// defaults, the bank seizes buildings until their value covers the balance
+// and the company can't borrow again.
+type LoanService interface {
+	GetLoans(ctx context.Context, companyID int64) (*LoanOverview, error)
+	TakeLoan(ctx context.Context, companyID, amount, periods int64) (*LoanDetails, error)
+	RepayLoan(ctx context.Context, companyID, loanID, amount int64) (*LoanDetails, error)
+	SettleAll(ctx context.Context) error
+}
+
+// LoanOverview is the credit of a company and its loans.
+type LoanOverview struct {
+	NetWorth        int64
+	CreditLimit     int64
+	Available       int64 // Left to borrow
+	InterestPercent float64
+	Blocked         bool // Defaulted before, can't borrow
+	Loans           []LoanDetails
+}
+
+// LoanDetails represents a loan with its next installment.
+type LoanDetails struct {
+	ID              int64
+	Principal       int64
+	Balance         int64
+	InterestPercent float64
+	Periods         int64
+	PeriodsPaid     int64
+	MissedPayments  int64
+	Status          string
+	NextPayment     int64      // Installment due next, 0 when closed
+	NextPaymentAt   *time.Time // Nil when closed
+	TakenAt         time.Time
+	ClosedAt        *time.Time
+}
+
+type loanService struct {
+	loanRepo            repository.LoanRepository
+	companyRepo         repository.CompanyRepository
+	buildingRepo        repository.ProductionBuildingRepository
+	levelRepo           repository.ProductionBuildingLevelRepository
+	companyBuildingRepo repository.CompanyBuildingRepository
+	ledgerService       LedgerService
+	upkeepService       UpkeepService
+	valuationService    ValuationService
+	transactor          repository.Transactor
+	terms               LoanTerms
+	interval            time.Duration
+}
+
+// NewLoanService creates a new loan service. Installments are due every
+// interval, which should be the upkeep interval; a non-positive one uses
+// DefaultUpkeepInterval.
+func NewLoanService(
+	loanRepo repository.LoanRepository,
+	companyRepo repository.CompanyRepository,
+	buildingRepo repository.ProductionBuildingRepository,
+	levelRepo repository.ProductionBuildingLevelRepository,
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	ledgerService LedgerService,
+	upkeepService UpkeepService,
+	valuationService ValuationService,
+	transactor repository.Transactor,
+	terms LoanTerms,
+	interval time.Duration,
+) LoanService {
+	if interval <= 0 {
+		interval = DefaultUpkeepInterval
+	}
+
+	return &loanService{
+		loanRepo:            loanRepo,
+		companyRepo:         companyRepo,
+		buildingRepo:        buildingRepo,
+		levelRepo:           levelRepo,
+		companyBuildingRepo: companyBuildingRepo,
+		ledgerService:       ledgerService,
+		upkeepService:       upkeepService,
+		valuationService:    valuationService,
+		transactor:          transactor,
+		terms:               terms,
+		interval:            interval,
+	}
+}
+
+// GetLoans settles the installments due and returns the company's credit
+// and loans, newest first.
+func (s *loanService) GetLoans(ctx context.Context, companyID int64) (*LoanOverview, error) {
+	now := time.Now()
+	if err := s.settleCompany(ctx, companyID, now); err != nil {
+		return nil, err
+	}
+
+	loans, err := s.loanRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	overview, err := s.credit(ctx, companyID, loans)
+	if err != nil {
+		return nil, err
+	}
+
+	overview.Loans = make([]LoanDetails, 0, len(loans))
+	for _, loan := range loans {
+		overview.Loans = append(overview.Loans, s.toDetails(loan))
+	}
+
+	return overview, nil
+}
+
+// TakeLoan lends money to the company, to be paid back in the given number
+// of installments at the current interest.
+func (s *loanService) TakeLoan(ctx context.Context, companyID, amount, periods int64) (*LoanDetails, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidLoanAmount
+	}
+	if periods < 1 || periods > MaxLoanPeriods {
+		return nil, ErrInvalidLoanPeriods
+	}
+
+	now := time.Now()
+	if err := s.settleCompany(ctx, companyID, now); err != nil {
+		return nil, err
+	}
+
+	loans, err := s.loanRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	credit, err := s.credit(ctx, companyID, loans)
+	if err != nil {
+		return nil, err
+	}
+	if credit.Blocked {
+		return nil, ErrLoansBlocked
+	}
+
+	// The loan only exists once its money is lent
+	var loan *db.Loan
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		loan, err = s.loanRepo.Create(ctx, &db.Loan{
+			CompanyID:       companyID,
+			Principal:       amount,
+			InterestPercent: s.terms.InterestPercent,
+			Periods:         periods,
+			TakenAt:         now,
+		}, credit.CreditLimit)
+		if err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Loan #%d granted, %d installments", loan.ID, periods)
+		_, err = s.ledgerService.Apply(ctx, companyID, amount, db.LedgerLoan, description, &loan.ID)
+		return err
+	})
+	if err != nil {
+		if err == repository.ErrCreditLimitExceeded {
+			return nil, ErrCreditLimit
+		}
+		return nil, err
+	}
+
+	details := s.toDetails(*loan)
+	return &details, nil
+}
+
+// RepayLoan pays back part of a loan early, all of it when amount is 0.
+// Paying early lowers the interest of the remaining installments.
+func (s *loanService) RepayLoan(ctx context.Context, companyID, loanID, amount int64) (*LoanDetails, error) {
+	if amount < 0 {
+		return nil, ErrInvalidLoanAmount
+	}
+
+	now := time.Now()
+	if err := s.settleCompany(ctx, companyID, now); err != nil {
+		return nil, err
+	}
+
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		if err == repository.ErrLoanNotFound {
+			return nil, ErrLoanNotFound
+		}
+		return nil, err
+	}
+	if loan.CompanyID != companyID {
+		return nil, ErrLoanNotFound
+	}
+	if loan.Status != db.LoanActive {
+		return nil, ErrLoanClosed
+	}
+
+	if amount == 0 || amount > loan.Balance {
+		amount = loan.Balance
+	}
+
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		description := fmt.Sprintf("Repaid loan #%d early", loan.ID)
+		if _, err := s.ledgerService.Apply(ctx, companyID, -amount, db.LedgerLoanRepayment, description, &loan.ID); err != nil {
+			return err
+		}
+
+		// Fails if an installment or another repayment changed the balance
+		balance := loan.Balance - amount
+		if err := s.loanRepo.Repay(ctx, loan.ID, loan.Balance, balance); err != nil {
+			return err
+		}
+		if balance == 0 {
+			return s.loanRepo.Close(ctx, loan.ID, db.LoanRepaid, now)
+		}
+		return nil
+	})
+	if err != nil {
+		if err == repository.ErrLoanChanged {
+			return nil, ErrRepayConflict
+		}
+		return nil, err
+	}
+
+	loan, err = s.loanRepo.GetByID(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := s.toDetails(*loan)
+	return &details, nil
+}
+
+// SettleAll settles the installments due of every active loan. It's run by
+// the loans job so that payments happen even for companies nobody is
+// looking at.
+func (s *loanService) SettleAll(ctx context.Context) error {
+	loans, err := s.loanRepo.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range loans {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.settle(ctx, &loans[i], now); err != nil {
+			return fmt.Errorf("settle loan %d: %w", loans[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *loanService) settleCompany(ctx context.Context, companyID int64, now time.Time) error {
+	loans, err := s.loanRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return err
+	}
+
+	for i := range loans {
+		if loans[i].Status != db.LoanActive {
+			continue
+		}
+		if err := s.settle(ctx, &loans[i], now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// settle charges every installment of the loan due by now, missing the ones
+// the company can't afford, and closes the loan once repaid or defaulted.
+func (s *loanService) settle(ctx context.Context, loan *db.Loan, now time.Time) error {
+	due := int64(now.Sub(loan.TakenAt)/s.interval) - loan.PeriodsPaid
+	for ; due > 0 && loan.Status == db.LoanActive; due-- {
+		principal, interest := s.installment(loan)
+
+		// The payment and the settled period commit together
+		var balance, missed int64
+		err := s.transactor.Transact(ctx, func(ctx context.Context) error {
+			description := fmt.Sprintf("Loan #%d installment %d of %d", loan.ID, loan.PeriodsPaid+1, loan.Periods)
+			_, err := s.ledgerService.Apply(ctx, loan.CompanyID, -(principal + interest), db.LedgerLoanPayment, description, &loan.ID)
+			balance, missed = loan.Balance-principal, 0
+			if err == ErrInsufficientFunds {
+				balance, missed = loan.Balance+interest, loan.MissedPayments+1
+			} else if err != nil {
+				return err
+			}
+
+			if err := s.loanRepo.SettlePeriod(ctx, loan.ID, loan.PeriodsPaid, balance, missed); err != nil {
+				return err
+			}
+			if balance <= 0 {
+				return s.loanRepo.Close(ctx, loan.ID, db.LoanRepaid, now)
+			}
+			return nil
+		})
+		if err != nil {
+			if err == repository.ErrLoanChanged {
+				// Settled concurrently
+				return nil
+			}
+			return err
+		}
+		loan.PeriodsPaid++
+		loan.Balance = balance
+		loan.MissedPayments = missed
+
+		switch {
+		case loan.Balance <= 0:
+			loan.Status = db.LoanRepaid
+		case loan.MissedPayments >= MissedPaymentsToDefault:
+			if err := s.defaultLoan(ctx, loan, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// defaultLoan closes the loan as defaulted and seizes buildings until the
+// company's money covers the balance, which is then paid. Whatever is still
+// owed after every building is gone is written off and stays as the balance
+// of the loan.
+func (s *loanService) defaultLoan(ctx context.Context, loan *db.Loan, now time.Time) error {
+	// The default, the seizures and the payment commit together
+	var payment int64
+	err := s.transactor.Transact(ctx, func(ctx context.Context) error {
+		if err := s.loanRepo.Close(ctx, loan.ID, db.LoanDefaulted, now); err != nil {
+			return err
+		}
+
+		// Charge the upkeep owed by the buildings before they are seized
+		company, err := s.upkeepService.Settle(ctx, loan.CompanyID)
+		if err != nil {
+			return err
+		}
+		money := company.Money
+
+		owned, err := s.companyBuildingRepo.GetAllByCompany(ctx, loan.CompanyID)
+		if err != nil {
+			return err
+		}
+
+		for i := range owned {
+			if money >= loan.Balance {
+				break
+			}
+
+			building, err := s.buildingRepo.GetByID(ctx, owned[i].BuildingID)
+			if err != nil {
+				return err
+			}
+			levels, err := s.levelRepo.GetAllByBuilding(ctx, owned[i].BuildingID)
+			if err != nil {
+				return err
+			}
+
+			if err := s.companyBuildingRepo.Delete(ctx, owned[i].ID); err != nil {
+				if err == repository.ErrCompanyBuildingNotFound {
+					continue
+				}
+				return err
+			}
+
+			value := saleValue(building, levels, &owned[i], now)
+			description := fmt.Sprintf("%s seized by the bank", building.Name)
+			entry, err := s.ledgerService.Apply(ctx, loan.CompanyID, value, db.LedgerLoanSeizure, description, &owned[i].ID)
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				money = entry.Balance
+			}
+		}
+
+		payment = min(loan.Balance, money)
+		if payment <= 0 {
+			payment = 0
+			return nil
+		}
+
+		description := fmt.Sprintf("Loan #%d defaulted", loan.ID)
+		if _, err := s.ledgerService.Apply(ctx, loan.CompanyID, -payment, db.LedgerLoanPayment, description, &loan.ID); err != nil {
+			return err
+		}
+		return s.loanRepo.SettleDefault(ctx, loan.ID, loan.Balance, loan.Balance-payment)
+	})
+	if err != nil {
+		if err == repository.ErrLoanChanged {
+			// Defaulted concurrently
+			return nil
+		}
+		return err
+	}
+	loan.Status = db.LoanDefaulted
+	loan.Balance -= payment
+	return nil
+}
+
+// installment returns the principal and interest due next: an equal share
+// of the balance over the periods left, the whole balance once the schedule
+// is over.
+func (s *loanService) installment(loan *db.Loan) (int64, int64) {
+	remaining := max(loan.Periods-loan.PeriodsPaid, 1)
+	principal := (loan.Balance + remaining - 1) / remaining
+	interest := int64(math.Ceil(float64(loan.Balance) * loan.InterestPercent / 100))
+	return principal, interest
+}
+
+// credit returns the net worth and credit limit of a company. Active loans
+// are part of the valuation liabilities, so the limit covers them too.
+func (s *loanService) credit(ctx context.Context, companyID int64, loans []db.Loan) (*LoanOverview, error) {
+	valuation, err := s.valuationService.GetValuation(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var outstanding int64
+	blocked := false
+	for _, loan := range loans {
+		switch loan.Status {
+		case db.LoanActive:
+			outstanding += loan.Balance
+		case db.LoanDefaulted:
+			blocked = true
+		}
+	}
+
+	creditLimit := max(int64(float64(valuation.NetWorth)*s.terms.CreditLimitPercent/100), 0)
+	if blocked {
+		creditLimit = 0
+	}
+
+	return &LoanOverview{
+		NetWorth:        valuation.NetWorth,
+		CreditLimit:     creditLimit,
+		Available:       max(creditLimit-outstanding, 0),
+		InterestPercent: s.terms.InterestPercent,
+		Blocked:         blocked,
+	}, nil
+}
+
+func (s *loanService) toDetails(loan db.Loan) LoanDetails {
+	details := LoanDetails{
+		ID:              loan.ID,
+		Principal:       loan.Principal,
+		Balance:         loan.Balance,
+		InterestPercent: loan.InterestPercent,
+		Periods:         loan.Periods,
+		PeriodsPaid:     loan.PeriodsPaid,
+		MissedPayments:  loan.MissedPayments,
+		Status:          loan.Status,
+		TakenAt:         loan.TakenAt,
+		ClosedAt:        loan.ClosedAt,
+	}
+	if loan.Status == db.LoanActive {
+		principal, interest := s.installment(&loan)
+		nextPaymentAt := loan.TakenAt.Add(time.Duration(loan.PeriodsPaid+1) * s.interval)
+		details.NextPayment = principal + interest
+		details.NextPaymentAt = &nextPaymentAt
+	}
+	return details
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+func TestLoanInstallment(t *testing.T) {
+	tests := []struct {
+		name          string
+		loan          db.Loan
+		wantPrincipal int64
+		wantInterest  int64
+	}{
+		{
+			name:          "equal share",
+			loan:          db.Loan{Balance: 1000, InterestPercent: 1, Periods: 4},
+			wantPrincipal: 250,
+			wantInterest:  10,
+		},
+		{
+			name:          "rounded up",
+			loan:          db.Loan{Balance: 1001, InterestPercent: 1, Periods: 4},
+			wantPrincipal: 251,
+			wantInterest:  11,
+		},
+		{
+			name:          "last period",
+			loan:          db.Loan{Balance: 300, InterestPercent: 1, Periods: 4, PeriodsPaid: 3},
+			wantPrincipal: 300,
+			wantInterest:  3,
+		},
+		{
+			name:          "past the schedule",
+			loan:          db.Loan{Balance: 300, InterestPercent: 1, Periods: 4, PeriodsPaid: 6},
+			wantPrincipal: 300,
+			wantInterest:  3,
+		},
+		{
+			name:          "no interest",
+			loan:          db.Loan{Balance: 1000, Periods: 3},
+			wantPrincipal: 334,
+			wantInterest:  0,
+		},
+	}
+
+	s := &loanService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, interest := s.installment(&tt.loan)
+			if principal != tt.wantPrincipal || interest != tt.wantInterest {
+				t.Fatalf("installment = %d + %d, want %d + %d", principal, interest, tt.wantPrincipal, tt.wantInterest)
+			}
+		})
+	}
+}
+
+func TestLoanSettle(t *testing.T) {
+	tests := []struct {
+		name        string
+		money       int64
+		periodsDue  int
+		wantStatus  string
+		wantBalance int64
+		wantPaid    int64
+		wantMissed  int64
+		wantMoney   int64
+	}{
+		{
+			name:        "installments paid",
+			money:       10000,
+			periodsDue:  2,
+			wantStatus:  db.LoanActive,
+			wantBalance: 500,
+			wantPaid:    2,
+			wantMoney:   10000 - 260 - 258,
+		},
+		{
+			name:        "repaid",
+			money:       10000,
+			periodsDue:  6,
+			wantStatus:  db.LoanRepaid,
+			wantBalance: 0,
+			wantPaid:    4,
+			wantMoney:   10000 - 260 - 258 - 255 - 253,
+		},
+		{
+			name:        "installments missed",
+			money:       0,
+			periodsDue:  2,
+			wantStatus:  db.LoanActive,
+			wantBalance: 1021,
+			wantPaid:    2,
+			wantMissed:  2,
+		},
+		{
+			name:        "defaulted",
+			money:       0,
+			periodsDue:  3,
+			wantStatus:  db.LoanDefaulted,
+			wantBalance: 1032,
+			wantPaid:    3,
+			wantMissed:  3,
+		},
+		{
+			name:        "defaulted with some money",
+			money:       100,
+			periodsDue:  5,
+			wantStatus:  db.LoanDefaulted,
+			wantBalance: 932,
+			wantPaid:    3,
+			wantMissed:  3,
+		},
+	}
+
+	takenAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := db.Loan{
+				ID:              1,
+				CompanyID:       1,
+				Principal:       1000,
+				Balance:         1000,
+				InterestPercent: 1,
+				Periods:         4,
+				Status:          db.LoanActive,
+				TakenAt:         takenAt,
+			}
+			loanRepo := &fakeLoanRepository{loan: loan}
+			ledger := &fakeLedgerService{money: tt.money}
+			s := &loanService{
+				loanRepo:            loanRepo,
+				companyBuildingRepo: fakeCompanyBuildingRepository{},
+				ledgerService:       ledger,
+				upkeepService:       fakeUpkeepService{ledger: ledger},
+				transactor:          fakeTransactor{},
+				interval:            time.Hour,
+			}
+
+			now := takenAt.Add(time.Duration(tt.periodsDue) * time.Hour)
+			if err := s.settle(context.Background(), &loan, now); err != nil {
+				t.Fatalf("settle: %v", err)
+			}
+
+			stored := loanRepo.loan
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			if stored.Balance != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", stored.Balance, tt.wantBalance)
+			}
+			if stored.PeriodsPaid != tt.wantPaid {
+				t.Errorf("periods paid = %d, want %d", stored.PeriodsPaid, tt.wantPaid)
+			}
+			if stored.MissedPayments != tt.wantMissed {
+				t.Errorf("missed payments = %d, want %d", stored.MissedPayments, tt.wantMissed)
+			}
+			if ledger.money != tt.wantMoney {
+				t.Errorf("money = %d, want %d", ledger.money, tt.wantMoney)
+			}
+			if loan.Balance != stored.Balance || loan.Status != stored.Status {
+				t.Errorf("settled loan has balance %d and status %q, the stored one %d and %q",
+					loan.Balance, loan.Status, stored.Balance, stored.Status)
+			}
+		})
+	}
+}
+
+// fakeLoanRepository stores a single loan, with the conditions of the real
+// updates.
+type fakeLoanRepository struct {
+	repository.LoanRepository
+	loan db.Loan
+}
+
+func (r *fakeLoanRepository) SettlePeriod(ctx context.Context, id, periodsPaid, balance, missedPayments int64) error {
+	if r.loan.Status != db.LoanActive || r.loan.PeriodsPaid != periodsPaid {
+		return repository.ErrLoanChanged
+	}
+	r.loan.PeriodsPaid++
+	r.loan.Balance = balance
+	r.loan.MissedPayments = missedPayments
+	return nil
+}
+
+func (r *fakeLoanRepository) Close(ctx context.Context, id int64, status string, now time.Time) error {
+	if r.loan.Status != db.LoanActive {
+		return repository.ErrLoanChanged
+	}
+	r.loan.Status = status
+	r.loan.ClosedAt = &now
+	return nil
+}
+
+func (r *fakeLoanRepository) SettleDefault(ctx context.Context, id, balance, newBalance int64) error {
+	if r.loan.Status != db.LoanDefaulted || r.loan.Balance != balance {
+		return repository.ErrLoanChanged
+	}
+	r.loan.Balance = newBalance
+	return nil
+}
+
+// fakeLedgerService keeps the money of a single company.
+type fakeLedgerService struct {
+	LedgerService
+	money int64
+}
+
+func (l *fakeLedgerService) Apply(ctx context.Context, companyID, amount int64, kind, description string, referenceID *int64) (*db.LedgerEntry, error) {
+	if amount == 0 {
+		return nil, nil
+	}
+	if l.money+amount < 0 {
+		return nil, ErrInsufficientFunds
+	}
+	l.money += amount
+	return &db.LedgerEntry{CompanyID: companyID, Amount: amount, Balance: l.money, Kind: kind}, nil
+}
+
+type fakeUpkeepService struct {
+	UpkeepService
+	ledger *fakeLedgerService
+}
+
+func (u fakeUpkeepService) Settle(ctx context.Context, companyID int64) (*db.Company, error) {
+	return &db.Company{ID: companyID, Money: u.ledger.money}, nil
+}
+
+// fakeCompanyBuildingRepository has no buildings.
+type fakeCompanyBuildingRepository struct {
+	repository.CompanyBuildingRepository
+}
+
+func (fakeCompanyBuildingRepository) GetAllByCompany(ctx context.Context, companyID int64) ([]db.CompanyBuilding, error) {
+	return nil, nil
+}
+
+// fakeTransactor runs functions without a transaction.
+type fakeTransactor struct{}
+
+func (fakeTransactor) Transact(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/events"
+	"yourownboss/internal/repository"
+)
+
+// DefaultNotificationRetention is how long notifications are kept, read or
+// not.
+const DefaultNotificationRetention = 7 * 24 * time.Hour
+
+// ContractExpiryWarning is how long before the deadline of an accepted
+// contract its company is warned.
+const ContractExpiryWarning = time.Hour
+
+const (
+	DefaultNotificationPageSize = 50
+	MaxNotificationPageSize     = 200
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationService keeps the notification inbox of companies. Trades and
+// breakdowns are notified by the event bus subscribers registered at
+// startup; finished runs and contracts about to expire depend on time
+// passing, so they are found by NotifyDue, run by a job.
+type NotificationService interface {
+	GetNotifications(ctx context.Context, companyID int64, unreadOnly bool, limit, offset int) (*NotificationInbox, error)
+	MarkRead(ctx context.Context, companyID, id int64) error
+	MarkAllRead(ctx context.Context, companyID int64) (int64, error)
+	NotifyOrderFilled(ctx context.Context, companyID int64, side string, resourceID, packs, amount int64) error
+	NotifyBuildingBroken(ctx context.Context, companyID, companyBuildingID int64) error
+	NotifyDue(ctx context.Context) error
+	Prune(ctx context.Context) error
+}
+
+// NotificationInbox is a page of notifications and how many are unread in
+// total.
+type NotificationInbox struct {
+	UnreadCount   int64
+	Notifications []db.Notification
+}
+
+type notificationService struct {
+	notificationRepo    repository.NotificationRepository
+	resourceRepo        repository.ResourceRepository
+	buildingRepo        repository.ProductionBuildingRepository
+	processRepo         repository.ProductionProcessRepository
+	companyBuildingRepo repository.CompanyBuildingRepository
+	runRepo             repository.ProductionRunRepository
+	contractRepo        repository.ContractRepository
+	retention           time.Duration
+
+	mu            sync.Mutex
+	runsCheckedAt time.Time // Runs finished up to here were already notified
+}
+
+// NewNotificationService creates a new notification service.
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	resourceRepo repository.ResourceRepository,
+	buildingRepo repository.ProductionBuildingRepository,
+	processRepo repository.ProductionProcessRepository,
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	runRepo repository.ProductionRunRepository,
+	contractRepo repository.ContractRepository,
+	retention time.Duration,
+) NotificationService {
+	if retention <= 0 {
+		retention = DefaultNotificationRetention
+	}
+	return &notificationService{
+		notificationRepo:    notificationRepo,
+		resourceRepo:        resourceRepo,
+		buildingRepo:        buildingRepo,
+		processRepo:         processRepo,
+		companyBuildingRepo: companyBuildingRepo,
+		runRepo:             runRepo,
+		contractRepo:        contractRepo,
+		retention:           retention,
+	}
+}
+
+func (s *notificationService) GetNotifications(ctx context.Context, companyID int64, unreadOnly bool, limit, offset int) (*NotificationInbox, error) {
+	if limit <= 0 {
+		limit = DefaultNotificationPageSize
+	}
+	if limit > MaxNotificationPageSize {
+		limit = MaxNotificationPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	unread, err := s.notificationRepo.CountUnread(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := s.notificationRepo.GetAllByCompany(ctx, companyID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationInbox{UnreadCount: unread, Notifications: notifications}, nil
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, companyID, id int64) error {
+	if err := s.notificationRepo.MarkRead(ctx, companyID, id, time.Now()); err != nil {
+		if err == repository.ErrNotificationNotFound {
+			return ErrNotificationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// MarkAllRead marks every notification of the company as read and returns
+// how many were unread.
+func (s *notificationService) MarkAllRead(ctx context.Context, companyID int64) (int64, error) {
+	return s.notificationRepo.MarkAllRead(ctx, companyID, time.Now())
+}
+
+// NotifyOrderFilled tells a company its market order went through.
+func (s *notificationService) NotifyOrderFilled(ctx context.Context, companyID int64, side string, resourceID, packs, amount int64) error {
+	resource, err := s.resourceRepo.GetByID(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+
+	var message string
+	if side == events.SideSell {
+		message = fmt.Sprintf("Sold %s of %s for %s", plural(packs, "pack"), resource.Name, formatMoney(amount))
+	} else {
+		message = fmt.Sprintf("Bought %s of %s for %s", plural(packs, "pack"), resource.Name, formatMoney(amount))
+	}
+
+	return s.notify(ctx, companyID, db.NotificationOrderFilled, message, &resourceID, "")
+}
+
+// NotifyBuildingBroken tells a company one of its buildings broke down and
+// needs a repair.
+func (s *notificationService) NotifyBuildingBroken(ctx context.Context, companyID, companyBuildingID int64) error {
+	owned, err := s.companyBuildingRepo.GetByID(ctx, companyBuildingID)
+	if err != nil {
+		if err == repository.ErrCompanyBuildingNotFound {
+			return nil // Sold before it was notified
+		}
+		return err
+	}
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("%s broke down and must be repaired before it can produce again", building.Name)
+	return s.notify(ctx, companyID, db.NotificationBuildingBroken, message, &companyBuildingID, "")
+}
+
+// NotifyDue notifies the runs that finished since the last check and the
+// accepted contracts whose deadline is less than ContractExpiryWarning
+// away. Each run and contract is notified once, even across restarts.
+func (s *notificationService) NotifyDue(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.runsCheckedAt.IsZero() {
+		// Nothing is known after a restart, the dedupe keys skip the runs
+		// already notified
+		s.runsCheckedAt = now.Add(-s.retention)
+	}
+
+	runs, err := s.runRepo.GetFinishedBetween(ctx, s.runsCheckedAt, now)
+	if err != nil {
+		return err
+	}
+	for i := range runs {
+		if err := s.notifyRunFinished(ctx, &runs[i]); err != nil {
+			return fmt.Errorf("notify run %d: %w", runs[i].ID, err)
+		}
+	}
+	s.runsCheckedAt = now
+
+	contracts, err := s.contractRepo.GetDueBetween(ctx, now, now.Add(ContractExpiryWarning))
+	if err != nil {
+		return err
+	}
+	for i := range contracts {
+		if err := s.notifyContractExpiring(ctx, &contracts[i], now); err != nil {
+			return fmt.Errorf("notify contract %d: %w", contracts[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Prune removes the notifications older than the retention period.
+func (s *notificationService) Prune(ctx context.Context) error {
+	deleted, err := s.notificationRepo.DeleteOlderThan(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Old notifications removed: %d", deleted)
+	}
+	return nil
+}
+
+func (s *notificationService) notifyRunFinished(ctx context.Context, run *db.ProductionRun) error {
+	owned, err := s.companyBuildingRepo.GetByID(ctx, run.CompanyBuildingID)
+	if err != nil {
+		if err == repository.ErrCompanyBuildingNotFound {
+			return nil
+		}
+		return err
+	}
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return err
+	}
+	process, err := s.processRepo.GetByID(ctx, run.ProcessID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("%s finished %s of %s, ready to collect", building.Name, plural(run.Batches, "batch"), process.Name)
+	return s.notify(ctx, owned.CompanyID, db.NotificationProductionFinished, message, &run.ID, fmt.Sprintf("run:%d", run.ID))
+}
+
+func (s *notificationService) notifyContractExpiring(ctx context.Context, contract *db.Contract, now time.Time) error {
+	resource, err := s.resourceRepo.GetByID(ctx, contract.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	minutes := int64(contract.Deadline.Sub(now).Round(time.Minute) / time.Minute)
+	message := fmt.Sprintf(
+		"Contract with %s is due in %s, %d of %d %s delivered",
+		contract.Client,
+		plural(minutes, "minute"),
+		contract.Delivered,
+		contract.Quantity,
+		resource.Name,
+	)
+	return s.notify(ctx, contract.CompanyID, db.NotificationContractExpiring, message, &contract.ID, fmt.Sprintf("contract:%d", contract.ID))
+}
+
+// notify stores a notification. A dedupe key, if not empty, makes it be
+// stored only once.
+func (s *notificationService) notify(ctx context.Context, companyID int64, kind, message string, referenceID *int64, dedupeKey string) error {
+	notification := &db.Notification{
+		CompanyID:   companyID,
+		Kind:        kind,
+		Message:     message,
+		ReferenceID: referenceID,
+		CreatedAt:   time.Now(),
+	}
+	if dedupeKey != "" {
+		notification.DedupeKey = &dedupeKey
+	}
+
+	_, err := s.notificationRepo.Create(ctx, notification)
+	return err
+}
+
+// formatMoney formats thousandths as units with three decimals.
+func formatMoney(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%03d", sign, amount/1000, amount%1000)
+}
+
+// plural formats a count followed by a word, adding "s" or "es" unless the
+// count is 1.
+func plural(count int64, word string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, word)
+	}
+	if strings.HasSuffix(word, "ch") {
+		return fmt.Sprintf("%d %ses", count, word)
+	}
+	return fmt.Sprintf("%d %ss", count, word)
+}
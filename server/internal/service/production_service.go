@@ -2,22 +2,117 @@ package service
 
 import (
 	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
 
 	"yourownboss/internal/db"
+	"yourownboss/internal/events"
 	"yourownboss/internal/repository"
 )
 
-// ProductionService handles production building queries.
+// Sort keys accepted by GetProcessAnalytics.
+const (
+	AnalyticsSortProfitPerHour  = "profit_per_hour"
+	AnalyticsSortProfitPerBatch = "profit_per_batch"
+	AnalyticsSortReturnOnCost   = "return_on_cost"
+	AnalyticsSortPayback        = "payback_hours"
+	AnalyticsSortInputCost      = "input_cost"
+	AnalyticsSortOutputValue    = "output_value"
+	AnalyticsSortName           = "name"
+)
+
+// Number of runs returned by GetRuns.
+const recentRunsLimit = 20
+
+var (
+	ErrInvalidAnalyticsSort = errors.New("invalid analytics sort field")
+	ErrProcessNotAvailable  = errors.New("process is not available in this building")
+	ErrInvalidBatches       = errors.New("batches must be positive")
+	ErrTooManyBatches       = errors.New("batches exceed the building capacity")
+	ErrRunNotFound          = errors.New("production run not found")
+	ErrRunNotFinished       = errors.New("production run has not finished yet")
+	ErrRunAlreadyCollected  = errors.New("production run already collected")
+	ErrNotEnoughEnergy      = errors.New("not enough energy to run the process")
+	ErrInvalidQualityOrder  = errors.New("invalid input quality order")
+	ErrInvalidInputChoice   = errors.New("chosen input is not an option of the process")
+	ErrNoFreeSlot           = errors.New("every production slot of the building is busy")
+)
+
+// ProductionService handles production building queries and the production
+// runs of company buildings.
 type ProductionService interface {
-	GetProductionBuildings(ctx context.Context) ([]ProductionBuildingDetails, error)
+	GetProductionBuildings(ctx context.Context, companyID int64) ([]ProductionBuildingDetails, error)
+	GetProcessAnalytics(ctx context.Context, sortBy string, descending bool) ([]ProcessAnalytics, error)
+	StartRun(ctx context.Context, companyID, companyBuildingID, processID, batches int64, options RunOptions) (*db.ProductionRun, error)
+	GetRuns(ctx context.Context, companyID, companyBuildingID int64) ([]db.ProductionRun, error)
+	CollectRun(ctx context.Context, companyID, companyBuildingID, runID int64) ([]CollectedResource, error)
+	GetGrid(ctx context.Context, companyID int64) ([]GridFlow, error)
 }
 
 // ProductionBuildingDetails represents a building with its processes.
 type ProductionBuildingDetails struct {
-	ID        int64
-	Name      string
-	Cost      int64
-	Processes []ProductionProcessDetails
+	ID                    int64
+	Name                  string
+	Cost                  int64
+	Upkeep                int64 // Charged every upkeep period
+	StorageCapacity       int64
+	Slots                 int64 // Processes that can run at once
+	ConstructionTimeMs    int64
+	ConstructionResources []ProductionBuildingLevelResourceDetails
+	Durability            *BuildingDurabilityDetails // Nil for buildings that never wear
+	Locked                bool                       // Not unlocked by the company's research yet
+	Levels                []ProductionBuildingLevelDetails
+	Processes             []ProductionProcessDetails
+}
+
+// BuildingDurabilityDetails describes how a building type wears down.
+type BuildingDurabilityDetails struct {
+	Max                   int64
+	WearPerBatch          int64
+	BreakdownBelowPercent int64
+	RepairCost            int64                                    // Per point
+	RepairResources       []ProductionBuildingLevelResourceDetails // For a full repair
+}
+
+// ProductionBuildingLevelDetails represents the upgrade to a building level.
+type ProductionBuildingLevelDetails struct {
+	Level         int64
+	UpgradeCost   int64
+	UpgradeTimeMs int64
+	SpeedPercent  int64
+	OutputPercent int64
+	MaxBatches    int64
+	Slots         int64 // 0 = the building's slots
+	Resources     []ProductionBuildingLevelResourceDetails
+}
+
+// ProductionBuildingLevelResourceDetails is a resource consumed by an
+// upgrade or a construction.
+type ProductionBuildingLevelResourceDetails struct {
+	ResourceID   int64
+	ResourceName string
+	Quantity     int64
+}
+
+// RunOptions are the choices made when starting a run. InputQuality is
+// db.QualityLowestFirst when empty, and Inputs holds the resources picked
+// for input groups; groups without a pick use their cheapest option in stock.
+type RunOptions struct {
+	InputQuality string
+	Inputs       []int64
+}
+
+// CollectedResource is a resource added to the inventory when a run is collected.
+type CollectedResource struct {
+	ResourceID   int64
+	ResourceName string
+	Quality      int64
+	Quantity     int64
+	RoutedTo     *int64 // Company building whose buffer received it instead
 }
 
 // ProductionProcessDetails represents a process with its resources.
@@ -27,15 +122,56 @@ type ProductionProcessDetails struct {
 	ProcessingTimeMs int64
 	WindowStartHour  *int64
 	WindowEndHour    *int64
+	Workers          int64
+	Locked           bool // Not unlocked by the company's research yet
 	Resources        []ProductionProcessResourceDetails
 }
 
 // ProductionProcessResourceDetails represents an input/output resource for a process.
+// Quantities of flow resources are produced or drawn evenly while a batch runs.
 type ProductionProcessResourceDetails struct {
 	ResourceID   int64
 	ResourceName string
+	ResourceType string
 	Direction    string
 	Quantity     int64
+	Group        int64 // Inputs sharing a non-zero group are alternatives
+	MaxQuantity  int64 // Largest yield per batch, Quantity unless random
+	Chance       int64 // Percent chance of the yield
+}
+
+// GridFlow is the state of a company's grid for a flow resource. Supply and
+// demand come from the runs that haven't finished, in units per hour.
+type GridFlow struct {
+	ResourceID   int64
+	ResourceName string
+	Supply       float64
+	Demand       float64
+	Stored       int64 // Stock used when demand exceeds supply
+}
+
+// ProcessAnalytics describes the economics of a process at current market
+// prices. Money values are in thousandths. Hourly figures are averaged over
+// a full day, so processes limited to a time window earn less per hour, and
+// assume a base level building running the process in all its slots.
+type ProcessAnalytics struct {
+	ProcessID        int64
+	ProcessName      string
+	BuildingID       int64
+	BuildingName     string
+	BuildingCost     int64
+	ProcessingTimeMs int64
+	WindowStartHour  *int64
+	WindowEndHour    *int64
+	ActiveHours      int64
+	InputCost        int64
+	OutputValue      int64 // Item outputs only, flows can't be sold
+	FlowOutputValue  int64 // Market price of the flow outputs, not counted as profit
+	ProfitPerBatch   int64
+	BatchesPerHour   float64
+	ProfitPerHour    int64
+	ReturnOnCost     float64  // Daily profit as a percentage of the building cost
+	PaybackHours     *float64 // Nil when the process never pays the building back
 }
 
 type productionService struct {
@@ -43,6 +179,16 @@ type productionService struct {
 	processRepo         repository.ProductionProcessRepository
 	processResourceRepo repository.ProductionProcessResourceRepository
 	resourceRepo        repository.ResourceRepository
+	levelRepo           repository.ProductionBuildingLevelRepository
+	companyBuildingRepo repository.CompanyBuildingRepository
+	runRepo             repository.ProductionRunRepository
+	inventoryRepo       repository.InventoryRepository
+	linkRepo            repository.SupplyLinkRepository
+	bufferRepo          repository.BuildingBufferRepository
+	upkeepService       UpkeepService
+	researchService     ResearchService
+	publisher           events.Publisher
+	transactor          repository.Transactor
 }
 
 // NewProductionService creates a new production service.
@@ -51,21 +197,49 @@ func NewProductionService(
 	processRepo repository.ProductionProcessRepository,
 	processResourceRepo repository.ProductionProcessResourceRepository,
 	resourceRepo repository.ResourceRepository,
+	levelRepo repository.ProductionBuildingLevelRepository,
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	runRepo repository.ProductionRunRepository,
+	inventoryRepo repository.InventoryRepository,
+	linkRepo repository.SupplyLinkRepository,
+	bufferRepo repository.BuildingBufferRepository,
+	upkeepService UpkeepService,
+	researchService ResearchService,
+	publisher events.Publisher,
+	transactor repository.Transactor,
 ) ProductionService {
 	return &productionService{
 		buildingRepo:        buildingRepo,
 		processRepo:         processRepo,
 		processResourceRepo: processResourceRepo,
 		resourceRepo:        resourceRepo,
+		levelRepo:           levelRepo,
+		companyBuildingRepo: companyBuildingRepo,
+		runRepo:             runRepo,
+		inventoryRepo:       inventoryRepo,
+		linkRepo:            linkRepo,
+		bufferRepo:          bufferRepo,
+		upkeepService:       upkeepService,
+		researchService:     researchService,
+		publisher:           publisher,
+		transactor:          transactor,
 	}
 }
 
-func (s *productionService) GetProductionBuildings(ctx context.Context) ([]ProductionBuildingDetails, error) {
+// GetProductionBuildings returns the catalog buildings, marking the entries
+// the company hasn't unlocked. A company ID of 0 marks every entry gated by
+// research as locked.
+func (s *productionService) GetProductionBuildings(ctx context.Context, companyID int64) ([]ProductionBuildingDetails, error) {
 	buildings, err := s.buildingRepo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	locks, err := s.researchService.GetLocks(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
 	resources, err := s.resourceRepo.GetAll(ctx)
 	if err != nil {
 		return nil, err
@@ -75,6 +249,7 @@ func (s *productionService) GetProductionBuildings(ctx context.Context) ([]Produ
 	for _, res := range resources {
 		resourceByID[res.ID] = res
 	}
+	names := resourceNames(resources)
 
 	result := make([]ProductionBuildingDetails, 0, len(buildings))
 	for _, building := range buildings {
@@ -83,6 +258,16 @@ func (s *productionService) GetProductionBuildings(ctx context.Context) ([]Produ
 			return nil, err
 		}
 
+		levels, err := s.levelRepo.GetAllByBuilding(ctx, building.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		levelDetails := make([]ProductionBuildingLevelDetails, 0, len(levels))
+		for _, level := range levels {
+			levelDetails = append(levelDetails, toLevelDetails(level, names))
+		}
+
 		processDetails := make([]ProductionProcessDetails, 0, len(processes))
 		for _, process := range processes {
 			processResources, err := s.processResourceRepo.GetAllByProcess(ctx, process.ID)
@@ -92,15 +277,16 @@ func (s *productionService) GetProductionBuildings(ctx context.Context) ([]Produ
 
 			resourcesDetails := make([]ProductionProcessResourceDetails, 0, len(processResources))
 			for _, processResource := range processResources {
-				resourceName := ""
-				if res, ok := resourceByID[processResource.ResourceID]; ok {
-					resourceName = res.Name
-				}
+				res := resourceByID[processResource.ResourceID]
 				resourcesDetails = append(resourcesDetails, ProductionProcessResourceDetails{
 					ResourceID:   processResource.ResourceID,
-					ResourceName: resourceName,
+					ResourceName: res.Name,
+					ResourceType: res.Type,
 					Direction:    processResource.Direction,
 					Quantity:     processResource.Quantity,
+					Group:        processResource.Group,
+					MaxQuantity:  processResource.MaxQuantityOrDefault(),
+					Chance:       processResource.Chance,
 				})
 			}
 
@@ -110,17 +296,1191 @@ func (s *productionService) GetProductionBuildings(ctx context.Context) ([]Produ
 				ProcessingTimeMs: process.ProcessingTimeMs,
 				WindowStartHour:  process.WindowStartHour,
 				WindowEndHour:    process.WindowEndHour,
+				Workers:          process.Workers,
+				Locked:           locks.ProcessLocked(process.ID),
 				Resources:        resourcesDetails,
 			})
 		}
 
+		var durability *BuildingDurabilityDetails
+		if building.MaxDurability > 0 {
+			durability = &BuildingDurabilityDetails{
+				Max:                   building.MaxDurability,
+				WearPerBatch:          building.WearPerBatch,
+				BreakdownBelowPercent: building.BreakdownBelowPercent,
+				RepairCost:            building.RepairCost,
+				RepairResources:       toResourceDetails(building.RepairResources, names),
+			}
+		}
+
 		result = append(result, ProductionBuildingDetails{
-			ID:        building.ID,
-			Name:      building.Name,
-			Cost:      building.Cost,
-			Processes: processDetails,
+			ID:                    building.ID,
+			Name:                  building.Name,
+			Cost:                  building.Cost,
+			Upkeep:                building.Upkeep,
+			StorageCapacity:       building.StorageCapacity,
+			Slots:                 building.Slots,
+			ConstructionTimeMs:    building.ConstructionTimeMs,
+			ConstructionResources: toResourceDetails(building.ConstructionResources, names),
+			Durability:            durability,
+			Locked:                locks.BuildingLocked(building.ID),
+			Levels:                levelDetails,
+			Processes:             processDetails,
 		})
 	}
 
 	return result, nil
 }
+
+func (s *productionService) GetProcessAnalytics(ctx context.Context, sortBy string, descending bool) ([]ProcessAnalytics, error) {
+	if sortBy == "" {
+		sortBy = AnalyticsSortProfitPerHour
+	}
+	less, ok := analyticsSorters[sortBy]
+	if !ok {
+		return nil, ErrInvalidAnalyticsSort
+	}
+
+	// Analytics are the same for every company, so locks are ignored
+	buildings, err := s.GetProductionBuildings(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceByID := make(map[int64]db.Resource, len(resources))
+	for _, res := range resources {
+		resourceByID[res.ID] = res
+	}
+
+	result := make([]ProcessAnalytics, 0)
+	for _, building := range buildings {
+		for _, process := range building.Processes {
+			result = append(result, analyzeProcess(building, process, resourceByID))
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if descending {
+			return less(result[j], result[i])
+		}
+		return less(result[i], result[j])
+	})
+
+	return result, nil
+}
+
+var analyticsSorters = map[string]func(a, b ProcessAnalytics) bool{
+	AnalyticsSortProfitPerHour:  func(a, b ProcessAnalytics) bool { return a.ProfitPerHour < b.ProfitPerHour },
+	AnalyticsSortProfitPerBatch: func(a, b ProcessAnalytics) bool { return a.ProfitPerBatch < b.ProfitPerBatch },
+	AnalyticsSortReturnOnCost:   func(a, b ProcessAnalytics) bool { return a.ReturnOnCost < b.ReturnOnCost },
+	AnalyticsSortInputCost:      func(a, b ProcessAnalytics) bool { return a.InputCost < b.InputCost },
+	AnalyticsSortOutputValue:    func(a, b ProcessAnalytics) bool { return a.OutputValue < b.OutputValue },
+	AnalyticsSortName: func(a, b ProcessAnalytics) bool {
+		return strings.ToLower(a.ProcessName) < strings.ToLower(b.ProcessName)
+	},
+	AnalyticsSortPayback: func(a, b ProcessAnalytics) bool {
+		// Processes that never pay back sort after every finite payback
+		if a.PaybackHours == nil || b.PaybackHours == nil {
+			return a.PaybackHours != nil && b.PaybackHours == nil
+		}
+		return *a.PaybackHours < *b.PaybackHours
+	},
+}
+
+func analyzeProcess(building ProductionBuildingDetails, process ProductionProcessDetails, resourceByID map[int64]db.Resource) ProcessAnalytics {
+	var inputCost float64
+	var outputValue float64
+	var flowOutputValue float64
+	// Input groups cost as much as their cheapest option
+	groupCost := make(map[int64]float64)
+	for _, processResource := range process.Resources {
+		quantity := float64(processResource.Quantity+processResource.MaxQuantity) / 2 * float64(processResource.Chance) / 100
+		resource := resourceByID[processResource.ResourceID]
+		value := unitPrice(resource) * quantity
+		switch {
+		case processResource.Direction != "input" && resource.IsFlow():
+			flowOutputValue += value
+		case processResource.Direction != "input":
+			outputValue += value
+		case processResource.Group > 0:
+			if current, ok := groupCost[processResource.Group]; !ok || value < current {
+				groupCost[processResource.Group] = value
+			}
+		default:
+			inputCost += value
+		}
+	}
+	for _, value := range groupCost {
+		inputCost += value
+	}
+
+	activeHours := int64(24)
+	if process.WindowStartHour != nil && process.WindowEndHour != nil {
+		activeHours = *process.WindowEndHour - *process.WindowStartHour
+	}
+
+	var batchesPerHour float64
+	if process.ProcessingTimeMs > 0 {
+		batchesPerHour = float64(3600000) / float64(process.ProcessingTimeMs) * float64(activeHours) / 24 * float64(max(building.Slots, 1))
+	}
+
+	profitPerBatch := outputValue - inputCost
+	profitPerHour := profitPerBatch * batchesPerHour
+
+	analytics := ProcessAnalytics{
+		ProcessID:        process.ID,
+		ProcessName:      process.Name,
+		BuildingID:       building.ID,
+		BuildingName:     building.Name,
+		BuildingCost:     building.Cost,
+		ProcessingTimeMs: process.ProcessingTimeMs,
+		WindowStartHour:  process.WindowStartHour,
+		WindowEndHour:    process.WindowEndHour,
+		ActiveHours:      activeHours,
+		InputCost:        int64(math.Round(inputCost)),
+		OutputValue:      int64(math.Round(outputValue)),
+		FlowOutputValue:  int64(math.Round(flowOutputValue)),
+		ProfitPerBatch:   int64(math.Round(profitPerBatch)),
+		BatchesPerHour:   batchesPerHour,
+		ProfitPerHour:    int64(math.Round(profitPerHour)),
+	}
+
+	if building.Cost > 0 {
+		analytics.ReturnOnCost = profitPerHour * 24 / float64(building.Cost) * 100
+	}
+	if profitPerHour > 0 {
+		payback := float64(building.Cost) / profitPerHour
+		analytics.PaybackHours = &payback
+	}
+
+	return analytics
+}
+
+// unitPrice returns the market price of a single unit in thousandths.
+func unitPrice(resource db.Resource) float64 {
+	if resource.PackSize <= 0 {
+		return float64(resource.Price)
+	}
+	return float64(resource.Price) / float64(resource.PackSize)
+}
+
+// StartRun consumes the inputs for the requested batches and schedules the
+// run in a free slot of the building. Duration, batch capacity and slots
+// depend on the building level, and runs with fewer workers than the
+// process needs take proportionally longer.
+// Flow inputs are drawn from the grid, then from stored stock, and the run
+// slows down to the rate the grid can supply when both fall short. Item
+// inputs are taken lowest quality first unless the order is
+// QualityHighestFirst.
+func (s *productionService) StartRun(
+	ctx context.Context,
+	companyID, companyBuildingID, processID, batches int64,
+	options RunOptions,
+) (*db.ProductionRun, error) {
+	if batches <= 0 {
+		return nil, ErrInvalidBatches
+	}
+	qualityOrder := options.InputQuality
+	if qualityOrder == "" {
+		qualityOrder = db.QualityLowestFirst
+	}
+	if qualityOrder != db.QualityLowestFirst && qualityOrder != db.QualityHighestFirst {
+		return nil, ErrInvalidQualityOrder
+	}
+
+	now := time.Now()
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired stock can't be used as input
+	if _, err := s.inventoryRepo.ExpireLots(ctx, companyID, now); err != nil {
+		return nil, err
+	}
+
+	stock := s.inventoryStock(companyID, qualityOrder)
+	return s.startRun(ctx, companyID, owned, processID, batches, options.Inputs, stock, now)
+}
+
+// startRun starts a run taking its item inputs from stock. Stored stock of
+// flow resources always comes from the inventory. The quality of the outputs
+// is set from the inputs taken and the building level.
+func (s *productionService) startRun(
+	ctx context.Context,
+	companyID int64,
+	owned *db.CompanyBuilding,
+	processID, batches int64,
+	choices []int64,
+	stock inputStock,
+	now time.Time,
+) (*db.ProductionRun, error) {
+	if owned.UnderConstruction(now) {
+		return nil, ErrBuildingUnderConstruction
+	}
+	if owned.UpgradeFinishesAt != nil {
+		return nil, ErrBuildingUpgrading
+	}
+	if owned.BrokenAt != nil {
+		return nil, ErrBuildingBroken
+	}
+
+	company, err := s.upkeepService.Settle(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if company.UpkeepDebt > 0 {
+		return nil, ErrBuildingsIdle
+	}
+
+	process, err := s.processRepo.GetByID(ctx, processID)
+	if err != nil {
+		if err == repository.ErrProductionProcessNotFound {
+			return nil, ErrProcessNotAvailable
+		}
+		return nil, err
+	}
+	if process.BuildingID != owned.BuildingID {
+		return nil, ErrProcessNotAvailable
+	}
+
+	locks, err := s.researchService.GetLocks(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if locks.ProcessLocked(process.ID) {
+		return nil, ErrProcessLocked
+	}
+
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.levelRepo.GetAllByBuilding(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+	stats := levelStats(levels, owned.Level)
+	if stats.MaxBatches > 0 && batches > stats.MaxBatches {
+		return nil, ErrTooManyBatches
+	}
+
+	activeRuns, err := s.runRepo.GetActiveByCompanyBuilding(ctx, owned.ID)
+	if err != nil {
+		return nil, err
+	}
+	free := freeSlots(activeRuns, levelSlots(building, stats))
+	if len(free) == 0 {
+		return nil, ErrNoFreeSlot
+	}
+
+	workers := process.Workers
+	if process.Workers > 0 {
+		busy, err := busyWorkers(ctx, s.runRepo, companyID, now)
+		if err != nil {
+			return nil, err
+		}
+		workers = min(process.Workers, company.Workers-busy)
+		if workers <= 0 {
+			return nil, ErrNoWorkersAvailable
+		}
+	}
+
+	processResources, err := s.processResourceRepo.GetAllByProcess(ctx, process.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resourceByID := make(map[int64]db.Resource, len(resources))
+	for _, res := range resources {
+		resourceByID[res.ID] = res
+	}
+
+	chosen, err := chooseInputs(ctx, processResources, choices, batches, stock, resourceByID)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []db.ProductionProcessResource
+	var flowInputs []db.ProductionProcessResource
+	for _, processResource := range chosen {
+		if res := resourceByID[processResource.ResourceID]; res.IsFlow() {
+			flowInputs = append(flowInputs, processResource)
+		} else {
+			inputs = append(inputs, processResource)
+		}
+	}
+
+	// Check every input before removing any of them
+	for _, input := range inputs {
+		quantity, err := stock.quantity(ctx, input.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		if quantity < input.Quantity*batches {
+			return nil, repository.ErrInsufficientStock
+		}
+	}
+
+	workMs := process.ProcessingTimeMs * batches * 100 / stats.SpeedPercent
+	if workers < process.Workers {
+		// Understaffed
+		workMs = workMs * process.Workers / workers
+	}
+
+	consumed := make([]db.ResourceQuantity, 0, len(inputs))
+	for _, input := range inputs {
+		consumed = append(consumed, db.ResourceQuantity{ResourceID: input.ResourceID, Quantity: input.Quantity * batches})
+	}
+	durationMs := workMs
+	var stored []db.ResourceQuantity
+	var delays []runDelay
+	if len(flowInputs) > 0 {
+		durationMs, stored, delays, err = s.planEnergy(ctx, companyID, flowInputs, batches, workMs, now, resourceByID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pending := &db.ProductionRun{
+		CompanyBuildingID: owned.ID,
+		ProcessID:         process.ID,
+		Batches:           batches,
+		Workers:           workers,
+		Seed:              rand.Int64N(maxRunSeed),
+		WorkMs:            workMs,
+		OutputPercent:     stats.OutputPercent,
+		StartedAt:         now,
+		FinishesAt:        productionFinishTime(now, time.Duration(durationMs)*time.Millisecond, process.WindowStartHour, process.WindowEndHour),
+	}
+
+	// The inputs are only taken if the run is created
+	var run *db.ProductionRun
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		taken, err := stock.consume(ctx, consumed)
+		if err != nil {
+			return err
+		}
+		if _, err := s.inventoryStock(companyID, db.QualityLowestFirst).consume(ctx, stored); err != nil {
+			return err
+		}
+		pending.Quality = outputQuality(taken, owned.Level)
+
+		// A run collected or rushed since the plan no longer draws energy
+		for _, delay := range delays {
+			err := s.runRepo.Reschedule(ctx, delay.run.ID, delay.run.FinishesAt, delay.finishesAt)
+			if err != nil && err != repository.ErrRunChanged {
+				return err
+			}
+		}
+
+		// A concurrent start may take a slot first, the next free one is tried
+		for _, slot := range free {
+			pending.Slot = slot
+			run, err = s.runRepo.Create(ctx, pending)
+			if err != repository.ErrSlotTaken {
+				return err
+			}
+		}
+		return ErrNoFreeSlot
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publisher.Publish(ctx, events.ProductionStarted{
+		CompanyID:         companyID,
+		CompanyBuildingID: owned.ID,
+		RunID:             run.ID,
+		ProcessID:         process.ID,
+		Batches:           run.Batches,
+		FinishesAt:        run.FinishesAt,
+	})
+
+	return run, nil
+}
+
+// freeSlots returns the slots, numbered from 1, not held by an active run.
+func freeSlots(activeRuns []db.ProductionRun, slots int64) []int64 {
+	taken := make(map[int64]bool, len(activeRuns))
+	for _, run := range activeRuns {
+		taken[run.Slot] = true
+	}
+
+	var free []int64
+	for slot := int64(1); slot <= slots; slot++ {
+		if !taken[slot] {
+			free = append(free, slot)
+		}
+	}
+	return free
+}
+
+// chooseInputs returns the inputs a run takes: the required ones and one
+// option of each input group. Groups use the option picked in choices, or
+// else the cheapest option with enough stock for the batches.
+func chooseInputs(
+	ctx context.Context,
+	processResources []db.ProductionProcessResource,
+	choices []int64,
+	batches int64,
+	stock inputStock,
+	resourceByID map[int64]db.Resource,
+) ([]db.ProductionProcessResource, error) {
+	options := make(map[int64][]db.ProductionProcessResource)
+	for _, processResource := range processResources {
+		if processResource.Direction == "input" && processResource.Group > 0 {
+			options[processResource.Group] = append(options[processResource.Group], processResource)
+		}
+	}
+
+	picked := make(map[int64]db.ProductionProcessResource, len(options))
+	for _, resourceID := range choices {
+		found := false
+		for group, groupOptions := range options {
+			for _, option := range groupOptions {
+				if option.ResourceID != resourceID {
+					continue
+				}
+				if current, ok := picked[group]; ok && current.ResourceID != resourceID {
+					return nil, ErrInvalidInputChoice
+				}
+				picked[group] = option
+				found = true
+			}
+		}
+		if !found {
+			return nil, ErrInvalidInputChoice
+		}
+	}
+
+	for group, groupOptions := range options {
+		if _, ok := picked[group]; ok {
+			continue
+		}
+		sort.SliceStable(groupOptions, func(i, j int) bool {
+			return unitPrice(resourceByID[groupOptions[i].ResourceID])*float64(groupOptions[i].Quantity) <
+				unitPrice(resourceByID[groupOptions[j].ResourceID])*float64(groupOptions[j].Quantity)
+		})
+		// With no option in stock the cheapest one reports the shortage
+		picked[group] = groupOptions[0]
+		for _, option := range groupOptions {
+			quantity, err := stock.quantity(ctx, option.ResourceID)
+			if err != nil {
+				return nil, err
+			}
+			if quantity >= option.Quantity*batches {
+				picked[group] = option
+				break
+			}
+		}
+	}
+
+	var inputs []db.ProductionProcessResource
+	for _, processResource := range processResources {
+		if processResource.Direction != "input" {
+			continue
+		}
+		if processResource.Group > 0 && picked[processResource.Group].ResourceID != processResource.ResourceID {
+			continue
+		}
+		inputs = append(inputs, processResource)
+	}
+	return inputs, nil
+}
+
+// maxRunSeed keeps run seeds exact when read as JSON numbers.
+const maxRunSeed = 1 << 53
+
+// rollOutputs returns the units of each output a run yields before the
+// level bonus. Random outputs are rolled batch by batch from the run seed,
+// so a run always yields the same and can be checked afterwards.
+func rollOutputs(seed, batches int64, processResources []db.ProductionProcessResource) map[int64]int64 {
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	yields := make(map[int64]int64, len(processResources))
+	for _, processResource := range processResources {
+		if processResource.Direction != "output" {
+			continue
+		}
+		if !processResource.Random() {
+			yields[processResource.ResourceID] = processResource.Quantity * batches
+			continue
+		}
+
+		spread := processResource.MaxQuantityOrDefault() - processResource.Quantity + 1
+		for range batches {
+			if rng.Int64N(100) >= processResource.Chance {
+				continue
+			}
+			yields[processResource.ResourceID] += processResource.Quantity + rng.Int64N(spread)
+		}
+	}
+	return yields
+}
+
+// outputQuality returns the quality of a run's outputs: the average quality
+// of its item inputs rounded down, but never below the building level, so
+// upgraded buildings turn standard inputs into better goods without letting
+// a chain raise quality past what its buildings can reach.
+func outputQuality(inputs []db.ResourceQuantity, level int64) int64 {
+	var units, total int64
+	for _, input := range inputs {
+		units += input.Quantity
+		total += input.QualityOrDefault() * input.Quantity
+	}
+	quality := int64(db.DefaultQuality)
+	if units > 0 {
+		quality = total / units
+	}
+	return min(max(quality, level), db.MaxQuality)
+}
+
+// inputStock is where a run takes its inputs from: the company inventory,
+// or the buffer of a building fed by supply links. Removing returns the
+// quantity taken of each quality.
+type inputStock struct {
+	quantity func(ctx context.Context, resourceID int64) (int64, error)
+	remove   func(ctx context.Context, resourceID, quantity int64) ([]db.ResourceQuantity, error)
+}
+
+func (s *productionService) inventoryStock(companyID int64, qualityOrder string) inputStock {
+	return inputStock{
+		quantity: func(ctx context.Context, resourceID int64) (int64, error) {
+			return s.storedQuantity(ctx, companyID, resourceID)
+		},
+		remove: func(ctx context.Context, resourceID, quantity int64) ([]db.ResourceQuantity, error) {
+			return s.inventoryRepo.ConsumeItem(ctx, companyID, resourceID, quantity, qualityOrder)
+		},
+	}
+}
+
+func (s *productionService) bufferStock(companyBuildingID int64, qualityOrder string) inputStock {
+	return inputStock{
+		quantity: func(ctx context.Context, resourceID int64) (int64, error) {
+			buffer, err := s.bufferRepo.GetByBuilding(ctx, companyBuildingID)
+			if err != nil {
+				return 0, err
+			}
+			var quantity int64
+			for _, item := range buffer {
+				if item.ResourceID == resourceID {
+					quantity += item.Quantity
+				}
+			}
+			return quantity, nil
+		},
+		remove: func(ctx context.Context, resourceID, quantity int64) ([]db.ResourceQuantity, error) {
+			taken, err := s.bufferRepo.Consume(ctx, companyBuildingID, resourceID, quantity, qualityOrder)
+			if err == repository.ErrInsufficientBuffer {
+				return nil, repository.ErrInsufficientStock
+			}
+			return taken, err
+		},
+	}
+}
+
+// consume removes every item and returns what it took of each quality. It
+// stops at the first failure, so it runs in a transaction to take all the
+// items or none.
+func (stock inputStock) consume(ctx context.Context, items []db.ResourceQuantity) ([]db.ResourceQuantity, error) {
+	var taken []db.ResourceQuantity
+	for _, item := range items {
+		removed, err := stock.remove(ctx, item.ResourceID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		taken = append(taken, removed...)
+	}
+	return taken, nil
+}
+
+// runDelay is a new finish time for an active run sharing a short flow.
+type runDelay struct {
+	run        db.ProductionRun
+	finishesAt time.Time
+}
+
+// planEnergy returns how long a run drawing the given flow inputs takes and
+// the stored stock it uses. Each input is covered first by the spare supply
+// of the grid and then by stored stock. If both fall short, the stock is used
+// up and every run drawing the flow goes at the same share of its full speed,
+// so that together they draw what the grid supplies. The active runs are
+// rescheduled to finish what they have left at that speed.
+func (s *productionService) planEnergy(
+	ctx context.Context,
+	companyID int64,
+	flowInputs []db.ProductionProcessResource,
+	batches, workMs int64,
+	now time.Time,
+	resourceByID map[int64]db.Resource,
+) (int64, []db.ResourceQuantity, []runDelay, error) {
+	grid, err := s.flowRates(ctx, companyID, now, resourceByID)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	durationMs := workMs
+	var stored []db.ResourceQuantity
+	speeds := make(map[int64]float64)
+	for _, input := range flowInputs {
+		needed := float64(input.Quantity * batches)
+		supply := grid.supply[input.ResourceID]
+		demand := grid.demand[input.ResourceID]
+		fromGrid := max(supply-demand, 0) * float64(workMs)
+		if fromGrid >= needed {
+			continue
+		}
+
+		stock, err := s.storedQuantity(ctx, companyID, input.ResourceID)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		missing := int64(math.Ceil(needed - fromGrid))
+		if stock >= missing {
+			stored = append(stored, db.ResourceQuantity{ResourceID: input.ResourceID, Quantity: missing})
+			continue
+		}
+		if supply <= 0 {
+			return 0, nil, nil, ErrNotEnoughEnergy
+		}
+
+		if stock > 0 {
+			stored = append(stored, db.ResourceQuantity{ResourceID: input.ResourceID, Quantity: stock})
+		}
+		// Supply over the demand at full speed
+		speed := supply / (grid.fullDemand[input.ResourceID] + (needed-float64(stock))/float64(workMs))
+		speeds[input.ResourceID] = speed
+		durationMs = max(durationMs, int64(math.Ceil(float64(workMs)/speed)))
+	}
+
+	// A run drawing several short flows goes at the speed of the shortest
+	runSpeeds := make(map[int64]float64)
+	runsByID := make(map[int64]db.ProductionRun)
+	for resourceID, speed := range speeds {
+		for _, run := range grid.drawing[resourceID] {
+			if current, ok := runSpeeds[run.ID]; !ok || speed < current {
+				runSpeeds[run.ID] = speed
+			}
+			runsByID[run.ID] = run
+		}
+	}
+
+	delays := make([]runDelay, 0, len(runSpeeds))
+	for id, speed := range runSpeeds {
+		run := runsByID[id]
+		left := float64(run.FinishesAt.Sub(now).Milliseconds()) * runSpeed(run) / speed
+		delays = append(delays, runDelay{
+			run:        run,
+			finishesAt: now.Add(time.Duration(math.Ceil(left)) * time.Millisecond),
+		})
+	}
+
+	return durationMs, stored, delays, nil
+}
+
+// runSpeed returns the share of its full speed a run goes at.
+func runSpeed(run db.ProductionRun) float64 {
+	durationMs := run.FinishesAt.Sub(run.StartedAt).Milliseconds()
+	if run.WorkMs <= 0 || durationMs <= 0 {
+		return 1
+	}
+	return min(float64(run.WorkMs)/float64(durationMs), 1)
+}
+
+// flowGrid is the state of a company's grid: the supply and demand of each
+// flow resource, in units per millisecond, and the runs drawing it. Full
+// demand is what the runs would draw at full speed.
+type flowGrid struct {
+	supply     map[int64]float64
+	demand     map[int64]float64
+	fullDemand map[int64]float64
+	drawing    map[int64][]db.ProductionRun
+}
+
+// flowRates returns the grid of the company from its runs that haven't
+// finished. Stored stock a run used is spread over its duration too, so
+// demand errs high.
+func (s *productionService) flowRates(
+	ctx context.Context,
+	companyID int64,
+	now time.Time,
+	resourceByID map[int64]db.Resource,
+) (*flowGrid, error) {
+	runs, err := s.runRepo.GetUncollectedByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	grid := &flowGrid{
+		supply:     make(map[int64]float64),
+		demand:     make(map[int64]float64),
+		fullDemand: make(map[int64]float64),
+		drawing:    make(map[int64][]db.ProductionRun),
+	}
+	processResources := make(map[int64][]db.ProductionProcessResource)
+	for _, run := range runs {
+		durationMs := run.FinishesAt.Sub(run.StartedAt).Milliseconds()
+		if run.Finished(now) || durationMs <= 0 {
+			continue
+		}
+
+		resources, ok := processResources[run.ProcessID]
+		if !ok {
+			resources, err = s.processResourceRepo.GetAllByProcess(ctx, run.ProcessID)
+			if err != nil {
+				return nil, err
+			}
+			processResources[run.ProcessID] = resources
+		}
+
+		for _, processResource := range resources {
+			if res := resourceByID[processResource.ResourceID]; !res.IsFlow() {
+				continue
+			}
+
+			rate := float64(processResource.Quantity*run.Batches) / float64(durationMs)
+			if processResource.Direction == "output" {
+				grid.supply[processResource.ResourceID] += rate
+			} else {
+				grid.demand[processResource.ResourceID] += rate
+				grid.fullDemand[processResource.ResourceID] += rate / runSpeed(run)
+				grid.drawing[processResource.ResourceID] = append(grid.drawing[processResource.ResourceID], run)
+			}
+		}
+	}
+
+	return grid, nil
+}
+
+func (s *productionService) storedQuantity(ctx context.Context, companyID, resourceID int64) (int64, error) {
+	return s.inventoryRepo.GetTotalQuantity(ctx, companyID, resourceID)
+}
+
+// GetGrid returns the supply, demand and stored stock of every flow resource
+// for the company.
+func (s *productionService) GetGrid(ctx context.Context, companyID int64) ([]GridFlow, error) {
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resourceByID := make(map[int64]db.Resource, len(resources))
+	for _, res := range resources {
+		resourceByID[res.ID] = res
+	}
+
+	rates, err := s.flowRates(ctx, companyID, time.Now(), resourceByID)
+	if err != nil {
+		return nil, err
+	}
+
+	grid := make([]GridFlow, 0)
+	for _, res := range resources {
+		if !res.IsFlow() {
+			continue
+		}
+
+		stored, err := s.storedQuantity(ctx, companyID, res.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		grid = append(grid, GridFlow{
+			ResourceID:   res.ID,
+			ResourceName: res.Name,
+			Supply:       rates.supply[res.ID] * float64(time.Hour/time.Millisecond),
+			Demand:       rates.demand[res.ID] * float64(time.Hour/time.Millisecond),
+			Stored:       stored,
+		})
+	}
+
+	return grid, nil
+}
+
+func (s *productionService) GetRuns(ctx context.Context, companyID, companyBuildingID int64) ([]db.ProductionRun, error) {
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.runRepo.GetAllByCompanyBuilding(ctx, owned.ID, recentRunsLimit)
+}
+
+// CollectRun adds the outputs of a finished run to the inventory at the
+// run's quality, scaled by the output bonus of the building level. Outputs with a supply link go
+// to the buffer of the linked building instead, and buildings whose buffer
+// covers a batch start their next run. Collecting wears the building down
+// and may break it, keeping the outputs.
+func (s *productionService) CollectRun(ctx context.Context, companyID, companyBuildingID, runID int64) ([]CollectedResource, error) {
+	now := time.Now()
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.runRepo.GetByID(ctx, runID)
+	if err != nil {
+		if err == repository.ErrProductionRunNotFound {
+			return nil, ErrRunNotFound
+		}
+		return nil, err
+	}
+	if run.CompanyBuildingID != owned.ID {
+		return nil, ErrRunNotFound
+	}
+	if run.CollectedAt != nil {
+		return nil, ErrRunAlreadyCollected
+	}
+	if !run.Finished(now) {
+		return nil, ErrRunNotFinished
+	}
+
+	// Upgrades finished while the run was active don't change its output.
+	// Runs started before the output was recorded use the current level
+	outputPercent := run.OutputPercent
+	if outputPercent <= 0 {
+		levels, err := s.levelRepo.GetAllByBuilding(ctx, owned.BuildingID)
+		if err != nil {
+			return nil, err
+		}
+		outputPercent = levelStats(levels, owned.Level).OutputPercent
+	}
+
+	processResources, err := s.processResourceRepo.GetAllByProcess(ctx, run.ProcessID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := resourceNames(resources)
+	flows := make(map[int64]bool)
+	for _, res := range resources {
+		flows[res.ID] = res.IsFlow()
+	}
+
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.linkRepo.GetAllBySource(ctx, owned.ID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[int64]int64, len(links))
+	for _, link := range links {
+		targets[link.ResourceID] = link.TargetBuildingID
+	}
+
+	wear := building.WearPerBatch * run.Batches
+	var brokenAt *time.Time
+	brokeDown := false
+	if building.MaxDurability > 0 && wear > 0 {
+		if breaksDown(run.Seed, building, owned.Wear+wear) {
+			brokenAt = &now
+			brokeDown = owned.BrokenAt == nil
+		}
+	} else {
+		wear = 0
+	}
+
+	yields := rollOutputs(run.Seed, run.Batches, processResources)
+	collected := make([]CollectedResource, 0)
+	var outputs []db.ResourceQuantity
+	var routed []CollectedResource
+	for _, processResource := range processResources {
+		// Flow outputs were supplied to the grid while the run was active
+		if processResource.Direction != "output" || flows[processResource.ResourceID] {
+			continue
+		}
+
+		quantity := yields[processResource.ResourceID] * outputPercent / 100
+		if quantity <= 0 {
+			continue
+		}
+
+		resource := CollectedResource{
+			ResourceID:   processResource.ResourceID,
+			ResourceName: names[processResource.ResourceID],
+			Quality:      run.Quality,
+			Quantity:     quantity,
+		}
+		if target, ok := targets[processResource.ResourceID]; ok {
+			resource.RoutedTo = &target
+			routed = append(routed, resource)
+		} else {
+			outputs = append(outputs, db.ResourceQuantity{
+				ResourceID: processResource.ResourceID,
+				Quality:    run.Quality,
+				Quantity:   quantity,
+			})
+		}
+		collected = append(collected, resource)
+	}
+
+	// If the outputs can't be stored (like with a full storage), nothing is
+	// kept: the run can be collected again once there is room, without the
+	// wear or breakdown counting twice
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		// Marking first makes concurrent collects of the same run fail
+		// instead of adding the outputs twice
+		if err := s.runRepo.MarkCollected(ctx, run.ID, now); err != nil {
+			return err
+		}
+		if wear > 0 {
+			if err := s.companyBuildingRepo.AddWear(ctx, owned.ID, wear, brokenAt); err != nil {
+				return err
+			}
+		}
+		for _, resource := range routed {
+			if err := s.bufferRepo.Add(ctx, *resource.RoutedTo, resource.item()); err != nil {
+				return err
+			}
+		}
+		// Outputs age from the moment they were produced, not collected
+		return s.inventoryRepo.AddItemsAt(ctx, companyID, outputs, run.FinishesAt)
+	})
+	if err != nil {
+		if err == repository.ErrRunAlreadyCollected {
+			return nil, ErrRunAlreadyCollected
+		}
+		return nil, err
+	}
+
+	collectedOutputs := make([]events.Output, 0, len(collected))
+	for _, resource := range collected {
+		collectedOutputs = append(collectedOutputs, events.Output{
+			ResourceID: resource.ResourceID,
+			Quality:    resource.Quality,
+			Quantity:   resource.Quantity,
+			RoutedTo:   resource.RoutedTo,
+		})
+	}
+	s.publisher.Publish(ctx, events.RunCollected{
+		CompanyID:         companyID,
+		CompanyBuildingID: owned.ID,
+		RunID:             run.ID,
+		Outputs:           collectedOutputs,
+		FinishedAt:        run.FinishesAt,
+		BrokeDown:         brokeDown,
+	})
+
+	// The collected building is idle now, and linked ones may have enough
+	// buffered to run
+	s.autoStart(ctx, companyID, owned.ID, flows, now)
+	started := map[int64]bool{owned.ID: true}
+	for _, resource := range routed {
+		if !started[*resource.RoutedTo] {
+			started[*resource.RoutedTo] = true
+			s.autoStart(ctx, companyID, *resource.RoutedTo, flows, now)
+		}
+	}
+
+	return collected, nil
+}
+
+// breaksDown rolls whether a run breaks the building down once it has lost
+// wear points. Below the breakdown threshold the chance grows linearly, up
+// to certain at zero durability. The roll is drawn from the run seed, on a
+// separate stream from the output yields.
+func breaksDown(seed int64, building *db.ProductionBuilding, wear int64) bool {
+	threshold := building.BreakdownBelowPercent
+	if building.MaxDurability <= 0 || threshold <= 0 {
+		return false
+	}
+
+	percent := durabilityPercent(building.MaxDurability, wear)
+	if percent >= threshold {
+		return false
+	}
+	chance := (threshold - percent) * 100 / threshold
+	rng := rand.New(rand.NewPCG(uint64(seed), 1))
+	return rng.Int64N(100) < chance
+}
+
+// durabilityPercent returns the durability left after losing wear points.
+func durabilityPercent(maxDurability, wear int64) int64 {
+	return max(maxDurability-wear, 0) * 100 / maxDurability
+}
+
+func (resource CollectedResource) item() db.ResourceQuantity {
+	return db.ResourceQuantity{ResourceID: resource.ResourceID, Quality: resource.Quality, Quantity: resource.Quantity}
+}
+
+// autoStart starts the linked process of a building with as many batches as
+// its buffer covers. Buildings that can't start yet (busy, short of workers
+// or energy, ...) wait for the next delivery.
+func (s *productionService) autoStart(ctx context.Context, companyID, companyBuildingID int64, flows map[int64]bool, now time.Time) {
+	links, err := s.linkRepo.GetAllByTarget(ctx, companyBuildingID)
+	if err != nil || len(links) == 0 {
+		return
+	}
+	processID := links[0].TargetProcessID
+
+	processResources, err := s.processResourceRepo.GetAllByProcess(ctx, processID)
+	if err != nil {
+		return
+	}
+	buffer, err := s.bufferRepo.GetByBuilding(ctx, companyBuildingID)
+	if err != nil {
+		return
+	}
+	buffered := make(map[int64]int64, len(buffer))
+	for _, item := range buffer {
+		buffered[item.ResourceID] += item.Quantity
+	}
+
+	// Input groups cover as many batches as their best stocked option
+	covers := make(map[int64]int64)
+	var required []int64
+	for _, processResource := range processResources {
+		if processResource.Direction != "input" || flows[processResource.ResourceID] {
+			continue
+		}
+		covered := buffered[processResource.ResourceID] / processResource.Quantity
+		if processResource.Group > 0 {
+			covers[processResource.Group] = max(covers[processResource.Group], covered)
+		} else {
+			required = append(required, covered)
+		}
+	}
+	for _, covered := range covers {
+		required = append(required, covered)
+	}
+	batches := int64(-1)
+	for _, covered := range required {
+		if batches < 0 || covered < batches {
+			batches = covered
+		}
+	}
+	// Processes without item inputs would run forever
+	if batches <= 0 {
+		return
+	}
+
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return
+	}
+	levels, err := s.levelRepo.GetAllByBuilding(ctx, owned.BuildingID)
+	if err != nil {
+		return
+	}
+	if stats := levelStats(levels, owned.Level); stats.MaxBatches > 0 {
+		batches = min(batches, stats.MaxBatches)
+	}
+
+	_, _ = s.startRun(ctx, companyID, owned, processID, batches, nil, s.bufferStock(owned.ID, db.QualityLowestFirst), now)
+}
+
+// productionFinishTime returns when a run started at start finishes after
+// work of the given duration. Processes with a time window only make
+// progress between the start and end hours (server local time).
+func productionFinishTime(start time.Time, work time.Duration, windowStartHour, windowEndHour *int64) time.Time {
+	if windowStartHour == nil || windowEndHour == nil {
+		return start.Add(work)
+	}
+
+	current := start.Local()
+	remaining := work
+	for {
+		opensAt := time.Date(current.Year(), current.Month(), current.Day(), int(*windowStartHour), 0, 0, 0, current.Location())
+		closesAt := time.Date(current.Year(), current.Month(), current.Day(), int(*windowEndHour), 0, 0, 0, current.Location())
+		nextDay := time.Date(current.Year(), current.Month(), current.Day()+1, 0, 0, 0, 0, current.Location())
+
+		if current.Before(opensAt) {
+			current = opensAt
+		}
+		if !current.Before(closesAt) {
+			current = nextDay
+			continue
+		}
+
+		available := closesAt.Sub(current)
+		if remaining <= available {
+			return current.Add(remaining)
+		}
+		remaining -= available
+		current = nextDay
+	}
+}
+
+// productionWorkLeft returns the work a run still has to do before
+// finishesAt, leaving out the hours outside the process time window.
+func productionWorkLeft(now, finishesAt time.Time, windowStartHour, windowEndHour *int64) time.Duration {
+	if !now.Before(finishesAt) {
+		return 0
+	}
+	if windowStartHour == nil || windowEndHour == nil {
+		return finishesAt.Sub(now)
+	}
+
+	var work time.Duration
+	current := now.Local()
+	for current.Before(finishesAt) {
+		opensAt := time.Date(current.Year(), current.Month(), current.Day(), int(*windowStartHour), 0, 0, 0, current.Location())
+		closesAt := time.Date(current.Year(), current.Month(), current.Day(), int(*windowEndHour), 0, 0, 0, current.Location())
+		nextDay := time.Date(current.Year(), current.Month(), current.Day()+1, 0, 0, 0, 0, current.Location())
+
+		if current.Before(opensAt) {
+			current = opensAt
+		}
+		end := closesAt
+		if finishesAt.Before(end) {
+			end = finishesAt
+		}
+		if current.Before(end) {
+			work += end.Sub(current)
+		}
+		current = nextDay
+	}
+	return work
+}
+
+func toLevelDetails(level db.ProductionBuildingLevel, names map[int64]string) ProductionBuildingLevelDetails {
+	stats := levelStats([]db.ProductionBuildingLevel{level}, level.Level)
+	resources := make([]ProductionBuildingLevelResourceDetails, 0, len(level.Resources))
+	for _, resource := range level.Resources {
+		resources = append(resources, ProductionBuildingLevelResourceDetails{
+			ResourceID:   resource.ResourceID,
+			ResourceName: names[resource.ResourceID],
+			Quantity:     resource.Quantity,
+		})
+	}
+
+	return ProductionBuildingLevelDetails{
+		Level:         level.Level,
+		UpgradeCost:   level.UpgradeCost,
+		UpgradeTimeMs: level.UpgradeTimeMs,
+		SpeedPercent:  stats.SpeedPercent,
+		OutputPercent: stats.OutputPercent,
+		MaxBatches:    level.MaxBatches,
+		Slots:         level.Slots,
+		Resources:     resources,
+	}
+}
+
+// toResourceDetails names the construction or repair resources of a building.
+func toResourceDetails(resources []db.ResourceQuantity, names map[int64]string) []ProductionBuildingLevelResourceDetails {
+	details := make([]ProductionBuildingLevelResourceDetails, 0, len(resources))
+	for _, resource := range resources {
+		details = append(details, ProductionBuildingLevelResourceDetails{
+			ResourceID:   resource.ResourceID,
+			ResourceName: names[resource.ResourceID],
+			Quantity:     resource.Quantity,
+		})
+	}
+	return details
+}
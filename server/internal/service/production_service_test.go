@@ -0,0 +1,164 @@
+package service
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"yourownboss/internal/db"
+)
+
+func TestAnalyzeProcessExcludesFlowOutputs(t *testing.T) {
+	resourceByID := map[int64]db.Resource{
+		1: {ID: 1, Name: "Seeds", Type: db.ResourceTypeItem, Price: 1000, PackSize: 10},
+		2: {ID: 2, Name: "Electricity", Type: db.ResourceTypeFlow, Price: 500, PackSize: 1},
+	}
+	building := ProductionBuildingDetails{ID: 1, Name: "Biogas plant", Cost: 100000, Slots: 1}
+	process := ProductionProcessDetails{
+		ID:               10,
+		Name:             "Burn seeds",
+		ProcessingTimeMs: 3600000,
+		Resources: []ProductionProcessResourceDetails{
+			{ResourceID: 1, Direction: "input", Quantity: 10, MaxQuantity: 10, Chance: 100},
+			{ResourceID: 2, Direction: "output", Quantity: 4, MaxQuantity: 4, Chance: 100},
+		},
+	}
+
+	analytics := analyzeProcess(building, process, resourceByID)
+
+	if analytics.InputCost != 1000 {
+		t.Errorf("InputCost = %d, want 1000", analytics.InputCost)
+	}
+	if analytics.OutputValue != 0 {
+		t.Errorf("OutputValue = %d, want 0 (flows can't be sold)", analytics.OutputValue)
+	}
+	if analytics.FlowOutputValue != 2000 {
+		t.Errorf("FlowOutputValue = %d, want 2000", analytics.FlowOutputValue)
+	}
+	if analytics.ProfitPerBatch != -1000 {
+		t.Errorf("ProfitPerBatch = %d, want -1000", analytics.ProfitPerBatch)
+	}
+	if analytics.PaybackHours != nil {
+		t.Errorf("PaybackHours = %v, want nil", *analytics.PaybackHours)
+	}
+}
+
+func TestRollOutputsIsSeeded(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []db.ProductionProcessResource
+		batches   int64
+		min, max  int64 // Units of resource 2 the batches can yield
+	}{
+		{
+			name: "fixed",
+			resources: []db.ProductionProcessResource{
+				{ResourceID: 1, Direction: "input", Quantity: 3, Chance: 100},
+				{ResourceID: 2, Direction: "output", Quantity: 2, Chance: 100},
+			},
+			batches: 5,
+			min:     10,
+			max:     10,
+		},
+		{
+			name: "random quantity",
+			resources: []db.ProductionProcessResource{
+				{ResourceID: 2, Direction: "output", Quantity: 1, MaxQuantity: 3, Chance: 100},
+			},
+			batches: 20,
+			min:     20,
+			max:     60,
+		},
+		{
+			name: "chance",
+			resources: []db.ProductionProcessResource{
+				{ResourceID: 2, Direction: "output", Quantity: 4, Chance: 25},
+			},
+			batches: 20,
+			min:     0,
+			max:     80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(0); seed < 50; seed++ {
+				yields := rollOutputs(seed, tt.batches, tt.resources)
+				if again := rollOutputs(seed, tt.batches, tt.resources); !reflect.DeepEqual(yields, again) {
+					t.Fatalf("seed %d yielded %v, then %v", seed, yields, again)
+				}
+				if _, ok := yields[1]; ok {
+					t.Fatalf("seed %d yielded the input: %v", seed, yields)
+				}
+				if got := yields[2]; got < tt.min || got > tt.max {
+					t.Fatalf("seed %d yielded %d, want between %d and %d", seed, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestBreaksDownIsSeeded(t *testing.T) {
+	building := &db.ProductionBuilding{MaxDurability: 100, BreakdownBelowPercent: 50}
+	tests := []struct {
+		name     string
+		building *db.ProductionBuilding
+		wear     int64
+		min, max int // Breakdowns out of 100 seeds
+	}{
+		{name: "no durability", building: &db.ProductionBuilding{BreakdownBelowPercent: 50}, wear: 1000, min: 0, max: 0},
+		{name: "no threshold", building: &db.ProductionBuilding{MaxDurability: 100}, wear: 100, min: 0, max: 0},
+		{name: "above threshold", building: building, wear: 50, min: 0, max: 0},
+		{name: "worn out", building: building, wear: 100, min: 100, max: 100},
+		{name: "half the chance", building: building, wear: 75, min: 30, max: 70},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdowns := 0
+			for seed := int64(0); seed < 100; seed++ {
+				brokeDown := breaksDown(seed, tt.building, tt.wear)
+				if again := breaksDown(seed, tt.building, tt.wear); again != brokeDown {
+					t.Fatalf("seed %d rolled %v, then %v", seed, brokeDown, again)
+				}
+				if brokeDown {
+					breakdowns++
+				}
+			}
+			if breakdowns < tt.min || breakdowns > tt.max {
+				t.Fatalf("%d breakdowns, want between %d and %d", breakdowns, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+// TestSeededRollsUseSeparateStreams checks that outputs are rolled on the
+// stream (seed, 0) and breakdowns on (seed, 1), so one roll doesn't decide
+// the other: at even odds, they agree for about half of the seeds.
+func TestSeededRollsUseSeparateStreams(t *testing.T) {
+	output := []db.ProductionProcessResource{{ResourceID: 1, Direction: "output", Quantity: 1, Chance: 50}}
+	// Half the durability lost under a 100% threshold is a 50% chance
+	building := &db.ProductionBuilding{MaxDurability: 100, BreakdownBelowPercent: 100}
+
+	agreements := 0
+	const seeds = 1000
+	for seed := int64(0); seed < seeds; seed++ {
+		produced := rollOutputs(seed, 1, output)[1] > 0
+		if want := rand.New(rand.NewPCG(uint64(seed), 0)).Int64N(100) < 50; produced != want {
+			t.Fatalf("seed %d: output rolled %v, stream (seed, 0) gives %v", seed, produced, want)
+		}
+
+		brokeDown := breaksDown(seed, building, 50)
+		if want := rand.New(rand.NewPCG(uint64(seed), 1)).Int64N(100) < 50; brokeDown != want {
+			t.Fatalf("seed %d: breakdown rolled %v, stream (seed, 1) gives %v", seed, brokeDown, want)
+		}
+
+		if produced == brokeDown {
+			agreements++
+		}
+	}
+
+	if agreements < seeds*4/10 || agreements > seeds*6/10 {
+		t.Fatalf("output and breakdown rolls agree for %d of %d seeds, want about half", agreements, seeds)
+	}
+}
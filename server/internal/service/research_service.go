@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+// Research node statuses for a company.
+const (
+	ResearchLocked      = "locked"      // Prerequisites not completed
+	ResearchAvailable   = "available"   // Can be started
+	ResearchResearching = "researching" // Started, not finished yet
+	ResearchCompleted   = "completed"
+)
+
+var (
+	ErrResearchNotFound      = errors.New("research node not found")
+	ErrResearchPrerequisites = errors.New("research prerequisites are not completed")
+	ErrResearchStarted       = errors.New("research already started")
+	ErrResearchBusy          = errors.New("another research is in progress")
+	ErrBuildingLocked        = errors.New("building is locked by research")
+	ErrProcessLocked         = errors.New("process is locked by research")
+)
+
+// ResearchService handles the tech tree. Buildings and processes unlocked by
+// a research node stay locked for a company until it completes one of the
+// nodes that unlock them; everything else is available from the start.
+type ResearchService interface {
+	GetResearch(ctx context.Context, companyID int64) ([]ResearchNodeDetails, error)
+	StartResearch(ctx context.Context, companyID, nodeID int64) (*ResearchNodeDetails, error)
+	GetLocks(ctx context.Context, companyID int64) (*ResearchLocks, error)
+}
+
+// ResearchNodeDetails represents a research node and its status for a company.
+type ResearchNodeDetails struct {
+	ID              int64
+	Name            string
+	Cost            int64
+	DurationMs      int64
+	Prerequisites   []int64
+	Resources       []ResearchResourceDetails
+	UnlockBuildings []int64
+	UnlockProcesses []int64
+	Status          string
+	StartedAt       *time.Time
+	FinishesAt      *time.Time
+}
+
+// ResearchResourceDetails is a resource consumed when starting a node.
+type ResearchResourceDetails struct {
+	ResourceID   int64
+	ResourceName string
+	Quantity     int64
+}
+
+// ResearchLocks holds the buildings and processes a company hasn't unlocked.
+type ResearchLocks struct {
+	buildings map[int64]bool
+	processes map[int64]bool
+}
+
+// BuildingLocked reports whether the production building is locked.
+func (l *ResearchLocks) BuildingLocked(buildingID int64) bool {
+	return l.buildings[buildingID]
+}
+
+// ProcessLocked reports whether the production process is locked.
+func (l *ResearchLocks) ProcessLocked(processID int64) bool {
+	return l.processes[processID]
+}
+
+type researchService struct {
+	researchRepo        repository.ResearchRepository
+	companyResearchRepo repository.CompanyResearchRepository
+	resourceRepo        repository.ResourceRepository
+	inventoryRepo       repository.InventoryRepository
+	ledgerService       LedgerService
+	transactor          repository.Transactor
+}
+
+// NewResearchService creates a new research service.
+func NewResearchService(
+	researchRepo repository.ResearchRepository,
+	companyResearchRepo repository.CompanyResearchRepository,
+	resourceRepo repository.ResourceRepository,
+	inventoryRepo repository.InventoryRepository,
+	ledgerService LedgerService,
+	transactor repository.Transactor,
+) ResearchService {
+	return &researchService{
+		researchRepo:        researchRepo,
+		companyResearchRepo: companyResearchRepo,
+		resourceRepo:        resourceRepo,
+		inventoryRepo:       inventoryRepo,
+		ledgerService:       ledgerService,
+		transactor:          transactor,
+	}
+}
+
+func (s *researchService) GetResearch(ctx context.Context, companyID int64) ([]ResearchNodeDetails, error) {
+	nodes, err := s.researchRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	started, err := s.startedByNode(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := resourceNames(resources)
+
+	now := time.Now()
+	result := make([]ResearchNodeDetails, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, toResearchDetails(node, started, names, now))
+	}
+
+	return result, nil
+}
+
+// StartResearch pays the cost and resources of a node and starts
+// researching it. Companies research one node at a time.
+func (s *researchService) StartResearch(ctx context.Context, companyID, nodeID int64) (*ResearchNodeDetails, error) {
+	node, err := s.researchRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		if err == repository.ErrResearchNodeNotFound {
+			return nil, ErrResearchNotFound
+		}
+		return nil, err
+	}
+
+	started, err := s.startedByNode(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if _, ok := started[node.ID]; ok {
+		return nil, ErrResearchStarted
+	}
+	if !prerequisitesCompleted(node, started, now) {
+		return nil, ErrResearchPrerequisites
+	}
+
+	if _, err := s.inventoryRepo.ExpireLots(ctx, companyID, now); err != nil {
+		return nil, err
+	}
+
+	// Check every resource before removing any of them
+	for _, resource := range node.Resources {
+		stored, err := s.inventoryRepo.GetTotalQuantity(ctx, companyID, resource.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		if stored < resource.Quantity {
+			return nil, repository.ErrInsufficientStock
+		}
+	}
+
+	// A failure takes nothing: the stock keeps its lots as they were
+	finishesAt := now.Add(time.Duration(node.DurationMs) * time.Millisecond)
+	err = s.transactor.Transact(ctx, func(ctx context.Context) error {
+		if err := s.companyResearchRepo.Start(ctx, companyID, node.ID, now, finishesAt); err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("Researched %s", node.Name)
+		if _, err := s.ledgerService.Apply(ctx, companyID, -node.Cost, db.LedgerResearch, description, &node.ID); err != nil {
+			return err
+		}
+
+		// Lowest quality stock is spent first
+		for _, resource := range node.Resources {
+			if _, err := s.inventoryRepo.ConsumeItem(ctx, companyID, resource.ResourceID, resource.Quantity, db.QualityLowestFirst); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		switch err {
+		case repository.ErrResearchAlreadyStarted:
+			return nil, ErrResearchStarted
+		case repository.ErrResearchInProgress:
+			return nil, ErrResearchBusy
+		}
+		return nil, err
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	started[node.ID] = db.CompanyResearch{CompanyID: companyID, NodeID: node.ID, StartedAt: now, FinishesAt: finishesAt}
+	details := toResearchDetails(*node, started, resourceNames(resources), now)
+	return &details, nil
+}
+
+// GetLocks returns what the company hasn't unlocked yet. A company ID of 0
+// stands for a visitor without a company, for whom nothing is unlocked.
+func (s *researchService) GetLocks(ctx context.Context, companyID int64) (*ResearchLocks, error) {
+	nodes, err := s.researchRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	started := map[int64]db.CompanyResearch{}
+	if companyID != 0 {
+		if started, err = s.startedByNode(ctx, companyID); err != nil {
+			return nil, err
+		}
+	}
+
+	locks := &ResearchLocks{buildings: map[int64]bool{}, processes: map[int64]bool{}}
+	unlocked := &ResearchLocks{buildings: map[int64]bool{}, processes: map[int64]bool{}}
+	now := time.Now()
+	for _, node := range nodes {
+		target := locks
+		if research, ok := started[node.ID]; ok && research.Completed(now) {
+			target = unlocked
+		}
+		for _, buildingID := range node.UnlockBuildings {
+			target.buildings[buildingID] = true
+		}
+		for _, processID := range node.UnlockProcesses {
+			target.processes[processID] = true
+		}
+	}
+
+	// Completing any of the nodes that unlock an entry is enough
+	for buildingID := range unlocked.buildings {
+		delete(locks.buildings, buildingID)
+	}
+	for processID := range unlocked.processes {
+		delete(locks.processes, processID)
+	}
+
+	return locks, nil
+}
+
+func (s *researchService) startedByNode(ctx context.Context, companyID int64) (map[int64]db.CompanyResearch, error) {
+	research, err := s.companyResearchRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	started := make(map[int64]db.CompanyResearch, len(research))
+	for _, item := range research {
+		started[item.NodeID] = item
+	}
+	return started, nil
+}
+
+func prerequisitesCompleted(node *db.ResearchNode, started map[int64]db.CompanyResearch, now time.Time) bool {
+	for _, prerequisite := range node.Prerequisites {
+		research, ok := started[prerequisite]
+		if !ok || !research.Completed(now) {
+			return false
+		}
+	}
+	return true
+}
+
+func toResearchDetails(node db.ResearchNode, started map[int64]db.CompanyResearch, names map[int64]string, now time.Time) ResearchNodeDetails {
+	resources := make([]ResearchResourceDetails, 0, len(node.Resources))
+	for _, resource := range node.Resources {
+		resources = append(resources, ResearchResourceDetails{
+			ResourceID:   resource.ResourceID,
+			ResourceName: names[resource.ResourceID],
+			Quantity:     resource.Quantity,
+		})
+	}
+
+	details := ResearchNodeDetails{
+		ID:              node.ID,
+		Name:            node.Name,
+		Cost:            node.Cost,
+		DurationMs:      node.DurationMs,
+		Prerequisites:   node.Prerequisites,
+		Resources:       resources,
+		UnlockBuildings: node.UnlockBuildings,
+		UnlockProcesses: node.UnlockProcesses,
+		Status:          ResearchLocked,
+	}
+
+	if research, ok := started[node.ID]; ok {
+		startedAt := research.StartedAt
+		finishesAt := research.FinishesAt
+		details.StartedAt = &startedAt
+		details.FinishesAt = &finishesAt
+		details.Status = ResearchResearching
+		if research.Completed(now) {
+			details.Status = ResearchCompleted
+		}
+	} else if prerequisitesCompleted(&node, started, now) {
+		details.Status = ResearchAvailable
+	}
+
+	return details
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+var (
+	ErrNothingToRush = errors.New("nothing left to rush")
+)
+
+// RushPricing sets the price of finishing runs and constructions early.
+// Each minute left costs CostPerMinute, with the minutes raised to Exponent
+// so long waits cost more (above 1) or less (below 1) per minute.
+type RushPricing struct {
+	CostPerMinute int64 // In thousandths
+	Exponent      float64
+}
+
+// DefaultRushPricing charges 20 per minute left.
+var DefaultRushPricing = RushPricing{
+	CostPerMinute: 20000,
+	Exponent:      1,
+}
+
+// Cost returns the price of skipping the remaining time, rounded up.
+func (p RushPricing) Cost(remaining time.Duration) int64 {
+	if remaining <= 0 {
+		return 0
+	}
+	cost := float64(p.CostPerMinute) * math.Pow(remaining.Minutes(), p.Exponent)
+	return max(int64(math.Ceil(cost)), 1)
+}
+
+// RushService finishes production runs and building constructions
+// immediately in exchange for money.
+type RushService interface {
+	QuoteRun(ctx context.Context, companyID, companyBuildingID, runID int64) (*RushQuote, error)
+	RushRun(ctx context.Context, companyID, companyBuildingID, runID int64) (*RushResult, error)
+	QuoteConstruction(ctx context.Context, companyID, companyBuildingID int64) (*RushQuote, error)
+	RushConstruction(ctx context.Context, companyID, companyBuildingID int64) (*RushResult, error)
+}
+
+// RushQuote is the current price of rushing. Runs of processes with a time
+// window are priced by the work left, not the hours the window is closed.
+type RushQuote struct {
+	RemainingMs int64
+	Cost        int64
+}
+
+// RushResult is the result of a rush.
+type RushResult struct {
+	Cost    int64
+	Balance int64 // Company money after paying
+}
+
+type rushService struct {
+	buildingRepo        repository.ProductionBuildingRepository
+	processRepo         repository.ProductionProcessRepository
+	companyBuildingRepo repository.CompanyBuildingRepository
+	runRepo             repository.ProductionRunRepository
+	ledgerService       LedgerService
+	pricing             RushPricing
+}
+
+// NewRushService creates a new rush service.
+func NewRushService(
+	buildingRepo repository.ProductionBuildingRepository,
+	processRepo repository.ProductionProcessRepository,
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	runRepo repository.ProductionRunRepository,
+	ledgerService LedgerService,
+	pricing RushPricing,
+) RushService {
+	return &rushService{
+		buildingRepo:        buildingRepo,
+		processRepo:         processRepo,
+		companyBuildingRepo: companyBuildingRepo,
+		runRepo:             runRepo,
+		ledgerService:       ledgerService,
+		pricing:             pricing,
+	}
+}
+
+func (s *rushService) QuoteRun(ctx context.Context, companyID, companyBuildingID, runID int64) (*RushQuote, error) {
+	_, _, remaining, err := s.runToRush(ctx, companyID, companyBuildingID, runID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.quote(remaining), nil
+}
+
+// RushRun makes an active run finish now, ready to be collected.
+func (s *rushService) RushRun(ctx context.Context, companyID, companyBuildingID, runID int64) (*RushResult, error) {
+	now := time.Now()
+	run, process, remaining, err := s.runToRush(ctx, companyID, companyBuildingID, runID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Paying first means the run can't be collected before it is paid for
+	cost := s.pricing.Cost(remaining)
+	description := fmt.Sprintf("Rushed %s", process.Name)
+	entry, err := s.ledgerService.Apply(ctx, companyID, -cost, db.LedgerRush, description, &run.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fails if the run was rushed or collected since it was read
+	if err := s.runRepo.Rush(ctx, run.ID, run.FinishesAt, now); err != nil {
+		_ = s.ledgerService.Revert(ctx, entry)
+		if err == repository.ErrRunChanged {
+			return nil, ErrNothingToRush
+		}
+		return nil, err
+	}
+
+	return rushResult(cost, entry), nil
+}
+
+func (s *rushService) QuoteConstruction(ctx context.Context, companyID, companyBuildingID int64) (*RushQuote, error) {
+	now := time.Now()
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !owned.UnderConstruction(now) {
+		return nil, ErrNothingToRush
+	}
+	return s.quote(owned.ConstructionFinishesAt.Sub(now)), nil
+}
+
+// RushConstruction makes the construction of an owned building finish now.
+func (s *rushService) RushConstruction(ctx context.Context, companyID, companyBuildingID int64) (*RushResult, error) {
+	now := time.Now()
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !owned.UnderConstruction(now) {
+		return nil, ErrNothingToRush
+	}
+
+	building, err := s.buildingRepo.GetByID(ctx, owned.BuildingID)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := s.pricing.Cost(owned.ConstructionFinishesAt.Sub(now))
+	description := fmt.Sprintf("Rushed construction of %s", building.Name)
+	entry, err := s.ledgerService.Apply(ctx, companyID, -cost, db.LedgerRush, description, &owned.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fails if the construction was rushed since it was read
+	if err := s.companyBuildingRepo.FinishConstruction(ctx, owned.ID, *owned.ConstructionFinishesAt, now); err != nil {
+		_ = s.ledgerService.Revert(ctx, entry)
+		if err == repository.ErrConstructionChanged {
+			return nil, ErrNothingToRush
+		}
+		return nil, err
+	}
+
+	return rushResult(cost, entry), nil
+}
+
+// runToRush loads an active run of an owned building with its process and
+// the work it has left.
+func (s *rushService) runToRush(
+	ctx context.Context,
+	companyID, companyBuildingID, runID int64,
+	now time.Time,
+) (*db.ProductionRun, *db.ProductionProcess, time.Duration, error) {
+	owned, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, companyBuildingID, now)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	run, err := s.runRepo.GetByID(ctx, runID)
+	if err != nil {
+		if err == repository.ErrProductionRunNotFound {
+			return nil, nil, 0, ErrRunNotFound
+		}
+		return nil, nil, 0, err
+	}
+	if run.CompanyBuildingID != owned.ID {
+		return nil, nil, 0, ErrRunNotFound
+	}
+	if run.CollectedAt != nil {
+		return nil, nil, 0, ErrRunAlreadyCollected
+	}
+	if run.Finished(now) {
+		return nil, nil, 0, ErrNothingToRush
+	}
+
+	process, err := s.processRepo.GetByID(ctx, run.ProcessID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	remaining := productionWorkLeft(now, run.FinishesAt, process.WindowStartHour, process.WindowEndHour)
+	return run, process, remaining, nil
+}
+
+func (s *rushService) quote(remaining time.Duration) *RushQuote {
+	return &RushQuote{
+		RemainingMs: remaining.Milliseconds(),
+		Cost:        s.pricing.Cost(remaining),
+	}
+}
+
+func rushResult(cost int64, entry *db.LedgerEntry) *RushResult {
+	result := &RushResult{Cost: cost}
+	if entry != nil {
+		result.Balance = entry.Balance
+	}
+	return result
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+var (
+	ErrSupplyLinkNotFound  = errors.New("supply link not found")
+	ErrSupplyLinkExists    = errors.New("resource is already linked from this building")
+	ErrInvalidLinkResource = errors.New("resource is not produced by the source or used by the target process")
+	ErrLinkProcessMismatch = errors.New("target building is already linked to a different process")
+	ErrEndlessLoop         = errors.New("link would create a loop with no net consumption")
+)
+
+// SupplyLinkService handles the links that route the collected outputs of a
+// company building into the input buffer of another one.
+type SupplyLinkService interface {
+	CreateLink(ctx context.Context, companyID, sourceBuildingID, targetBuildingID, resourceID, processID int64) (*SupplyLinkDetails, error)
+	GetLinks(ctx context.Context, companyID int64) ([]SupplyLinkDetails, error)
+	DeleteLink(ctx context.Context, companyID, linkID int64) error
+}
+
+// SupplyLinkDetails is a link with the names of what it connects and the
+// quantity of its resource buffered in the target.
+type SupplyLinkDetails struct {
+	ID                int64
+	SourceBuildingID  int64
+	TargetBuildingID  int64
+	ResourceID        int64
+	ResourceName      string
+	TargetProcessID   int64
+	TargetProcessName string
+	Buffered          int64
+	CreatedAt         time.Time
+}
+
+type supplyLinkService struct {
+	companyBuildingRepo repository.CompanyBuildingRepository
+	processRepo         repository.ProductionProcessRepository
+	processResourceRepo repository.ProductionProcessResourceRepository
+	resourceRepo        repository.ResourceRepository
+	inventoryRepo       repository.InventoryRepository
+	linkRepo            repository.SupplyLinkRepository
+	bufferRepo          repository.BuildingBufferRepository
+}
+
+// NewSupplyLinkService creates a new supply link service.
+func NewSupplyLinkService(
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	processRepo repository.ProductionProcessRepository,
+	processResourceRepo repository.ProductionProcessResourceRepository,
+	resourceRepo repository.ResourceRepository,
+	inventoryRepo repository.InventoryRepository,
+	linkRepo repository.SupplyLinkRepository,
+	bufferRepo repository.BuildingBufferRepository,
+) SupplyLinkService {
+	return &supplyLinkService{
+		companyBuildingRepo: companyBuildingRepo,
+		processRepo:         processRepo,
+		processResourceRepo: processResourceRepo,
+		resourceRepo:        resourceRepo,
+		inventoryRepo:       inventoryRepo,
+		linkRepo:            linkRepo,
+		bufferRepo:          bufferRepo,
+	}
+}
+
+// CreateLink routes a resource collected in the source building to the
+// buffer of the target, which runs processID once the buffer covers a batch.
+// A building can be the source of its own input, but links that close a loop
+// producing at least as much as it consumes are rejected.
+func (s *supplyLinkService) CreateLink(ctx context.Context, companyID, sourceBuildingID, targetBuildingID, resourceID, processID int64) (*SupplyLinkDetails, error) {
+	now := time.Now()
+	source, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, sourceBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+	target, err := getOwnedBuilding(ctx, s.companyBuildingRepo, companyID, targetBuildingID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := s.resourceRepo.GetByID(ctx, resourceID)
+	if err != nil {
+		if err == repository.ErrResourceNotFound {
+			return nil, ErrInvalidLinkResource
+		}
+		return nil, err
+	}
+	// Flow resources reach other buildings through the grid
+	if resource.IsFlow() {
+		return nil, ErrInvalidLinkResource
+	}
+
+	process, err := s.processRepo.GetByID(ctx, processID)
+	if err != nil {
+		if err == repository.ErrProductionProcessNotFound {
+			return nil, ErrProcessNotAvailable
+		}
+		return nil, err
+	}
+	if process.BuildingID != target.BuildingID {
+		return nil, ErrProcessNotAvailable
+	}
+
+	resources := make(map[int64][]db.ProductionProcessResource)
+	consumed, err := s.processQuantity(ctx, resources, processID, resourceID, "input")
+	if err != nil {
+		return nil, err
+	}
+	if consumed == 0 {
+		return nil, ErrInvalidLinkResource
+	}
+
+	produced, err := s.buildingProduces(ctx, resources, source.BuildingID, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	if !produced {
+		return nil, ErrInvalidLinkResource
+	}
+
+	links, err := s.linkRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if link.TargetBuildingID == target.ID && link.TargetProcessID != processID {
+			return nil, ErrLinkProcessMismatch
+		}
+	}
+
+	candidate := db.SupplyLink{
+		CompanyID:        companyID,
+		SourceBuildingID: source.ID,
+		TargetBuildingID: target.ID,
+		ResourceID:       resourceID,
+		TargetProcessID:  processID,
+	}
+	endless, err := s.closesEndlessLoop(ctx, resources, candidate, links)
+	if err != nil {
+		return nil, err
+	}
+	if endless {
+		return nil, ErrEndlessLoop
+	}
+
+	link, err := s.linkRepo.Create(ctx, &candidate)
+	if err != nil {
+		if err == repository.ErrSupplyLinkExists {
+			return nil, ErrSupplyLinkExists
+		}
+		return nil, err
+	}
+
+	return &SupplyLinkDetails{
+		ID:                link.ID,
+		SourceBuildingID:  link.SourceBuildingID,
+		TargetBuildingID:  link.TargetBuildingID,
+		ResourceID:        link.ResourceID,
+		ResourceName:      resource.Name,
+		TargetProcessID:   link.TargetProcessID,
+		TargetProcessName: process.Name,
+		CreatedAt:         link.CreatedAt,
+	}, nil
+}
+
+func (s *supplyLinkService) GetLinks(ctx context.Context, companyID int64) ([]SupplyLinkDetails, error) {
+	links, err := s.linkRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := resourceNames(resources)
+
+	processNames := make(map[int64]string)
+	buffers := make(map[int64]map[int64]int64)
+	details := make([]SupplyLinkDetails, 0, len(links))
+	for _, link := range links {
+		if _, ok := processNames[link.TargetProcessID]; !ok {
+			process, err := s.processRepo.GetByID(ctx, link.TargetProcessID)
+			if err != nil {
+				return nil, err
+			}
+			processNames[link.TargetProcessID] = process.Name
+		}
+
+		buffered, ok := buffers[link.TargetBuildingID]
+		if !ok {
+			buffer, err := s.bufferRepo.GetByBuilding(ctx, link.TargetBuildingID)
+			if err != nil {
+				return nil, err
+			}
+			buffered = make(map[int64]int64, len(buffer))
+			for _, item := range buffer {
+				buffered[item.ResourceID] += item.Quantity
+			}
+			buffers[link.TargetBuildingID] = buffered
+		}
+
+		details = append(details, SupplyLinkDetails{
+			ID:                link.ID,
+			SourceBuildingID:  link.SourceBuildingID,
+			TargetBuildingID:  link.TargetBuildingID,
+			ResourceID:        link.ResourceID,
+			ResourceName:      names[link.ResourceID],
+			TargetProcessID:   link.TargetProcessID,
+			TargetProcessName: processNames[link.TargetProcessID],
+			Buffered:          buffered[link.ResourceID],
+			CreatedAt:         link.CreatedAt,
+		})
+	}
+
+	return details, nil
+}
+
+// DeleteLink removes a link. Buffered stock no other link delivers to the
+// target goes back to the inventory.
+func (s *supplyLinkService) DeleteLink(ctx context.Context, companyID, linkID int64) error {
+	link, err := s.linkRepo.GetByID(ctx, linkID)
+	if err != nil {
+		if err == repository.ErrSupplyLinkNotFound {
+			return ErrSupplyLinkNotFound
+		}
+		return err
+	}
+	if link.CompanyID != companyID {
+		return ErrSupplyLinkNotFound
+	}
+
+	others, err := s.linkRepo.GetAllByTarget(ctx, link.TargetBuildingID)
+	if err != nil {
+		return err
+	}
+	shared := false
+	for _, other := range others {
+		if other.ID != link.ID && other.ResourceID == link.ResourceID {
+			shared = true
+		}
+	}
+
+	var returned []db.ResourceQuantity
+	if !shared {
+		buffer, err := s.bufferRepo.GetByBuilding(ctx, link.TargetBuildingID)
+		if err != nil {
+			return err
+		}
+		for _, item := range buffer {
+			if item.ResourceID == link.ResourceID {
+				returned = append(returned, item)
+			}
+		}
+	}
+
+	for i, item := range returned {
+		if err := s.bufferRepo.Remove(ctx, link.TargetBuildingID, item); err != nil {
+			s.rebuffer(ctx, link.TargetBuildingID, returned[:i])
+			return err
+		}
+	}
+	if len(returned) > 0 {
+		if err := s.inventoryRepo.AddItemsAt(ctx, companyID, returned, time.Now()); err != nil {
+			// Rollback: keep the stock buffered
+			s.rebuffer(ctx, link.TargetBuildingID, returned)
+			return err
+		}
+	}
+
+	if err := s.linkRepo.Delete(ctx, link.ID); err != nil {
+		for _, item := range returned {
+			_ = s.inventoryRepo.RemoveItem(ctx, companyID, item.ResourceID, item.Quality, item.Quantity)
+		}
+		s.rebuffer(ctx, link.TargetBuildingID, returned)
+		if err == repository.ErrSupplyLinkNotFound {
+			return ErrSupplyLinkNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *supplyLinkService) rebuffer(ctx context.Context, companyBuildingID int64, items []db.ResourceQuantity) {
+	for _, item := range items {
+		_ = s.bufferRepo.Add(ctx, companyBuildingID, item)
+	}
+}
+
+// closesEndlessLoop reports whether adding the candidate closes a loop of
+// links whose conversion ratios multiply to one or more. Such a loop keeps
+// restarting itself without consuming anything from outside.
+func (s *supplyLinkService) closesEndlessLoop(
+	ctx context.Context,
+	resources map[int64][]db.ProductionProcessResource,
+	candidate db.SupplyLink,
+	links []db.SupplyLink,
+) (bool, error) {
+	outgoing := make(map[int64][]db.SupplyLink)
+	for _, link := range links {
+		outgoing[link.SourceBuildingID] = append(outgoing[link.SourceBuildingID], link)
+	}
+
+	// Every path from the target back to the source closes a loop
+	var loops [][]db.SupplyLink
+	onPath := make(map[int64]bool)
+	var visit func(building int64, path []db.SupplyLink)
+	visit = func(building int64, path []db.SupplyLink) {
+		if building == candidate.SourceBuildingID {
+			loop := append([]db.SupplyLink{candidate}, path...)
+			loops = append(loops, loop)
+			return
+		}
+		if onPath[building] {
+			return
+		}
+		onPath[building] = true
+		for _, link := range outgoing[building] {
+			visit(link.TargetBuildingID, append(path[:len(path):len(path)], link))
+		}
+		onPath[building] = false
+	}
+	visit(candidate.TargetBuildingID, nil)
+
+	for _, loop := range loops {
+		gain := 1.0
+		for i, link := range loop {
+			// Each building turns what the link delivers into what the
+			// next link takes away
+			next := loop[(i+1)%len(loop)]
+			input, err := s.processQuantity(ctx, resources, link.TargetProcessID, link.ResourceID, "input")
+			if err != nil {
+				return false, err
+			}
+			output, err := s.processQuantity(ctx, resources, link.TargetProcessID, next.ResourceID, "output")
+			if err != nil {
+				return false, err
+			}
+			if input == 0 || output == 0 {
+				gain = 0
+				break
+			}
+			gain *= float64(output) / float64(input)
+		}
+		if gain >= 1 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// processQuantity returns the quantity of a resource a process takes or
+// gives in the given direction, caching the process resources. Random
+// outputs count with their largest yield.
+func (s *supplyLinkService) processQuantity(
+	ctx context.Context,
+	resources map[int64][]db.ProductionProcessResource,
+	processID, resourceID int64,
+	direction string,
+) (int64, error) {
+	processResources, ok := resources[processID]
+	if !ok {
+		var err error
+		processResources, err = s.processResourceRepo.GetAllByProcess(ctx, processID)
+		if err != nil {
+			return 0, err
+		}
+		resources[processID] = processResources
+	}
+
+	for _, processResource := range processResources {
+		if processResource.ResourceID == resourceID && processResource.Direction == direction {
+			if direction == "output" {
+				return processResource.MaxQuantityOrDefault(), nil
+			}
+			return processResource.Quantity, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *supplyLinkService) buildingProduces(
+	ctx context.Context,
+	resources map[int64][]db.ProductionProcessResource,
+	buildingID, resourceID int64,
+) (bool, error) {
+	processes, err := s.processRepo.GetAllByBuilding(ctx, buildingID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, process := range processes {
+		quantity, err := s.processQuantity(ctx, resources, process.ID, resourceID, "output")
+		if err != nil {
+			return false, err
+		}
+		if quantity > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+// DefaultUpkeepInterval is how often building upkeep and wages are charged.
+const DefaultUpkeepInterval = time.Hour
+
+// DefaultWorkerWage is the wage of a worker per upkeep period in thousandths.
+const DefaultWorkerWage = 10000
+
+var (
+	ErrBuildingsIdle = errors.New("buildings are idle until the upkeep debt is paid")
+)
+
+// UpkeepService charges the recurring costs of a company: the upkeep of owned
+// buildings and the wages of hired workers. Charges are computed from the age
+// of each building and the date wages were last paid, so a company that
+// hasn't been seen for days is charged for every period it missed the next
+// time it's settled.
+type UpkeepService interface {
+	Settle(ctx context.Context, companyID int64) (*db.Company, error)
+	SettleWages(ctx context.Context, companyID int64) (*db.Company, error)
+	SettleAll(ctx context.Context) error
+	Charge(ctx context.Context, companyID, amount int64, kind, description string, referenceID *int64) error
+	Wage() int64
+}
+
+type upkeepService struct {
+	companyRepo         repository.CompanyRepository
+	buildingRepo        repository.ProductionBuildingRepository
+	companyBuildingRepo repository.CompanyBuildingRepository
+	ledgerService       LedgerService
+	transactor          repository.Transactor
+	interval            time.Duration
+	wage                int64
+}
+
+// NewUpkeepService creates a new upkeep service. A non-positive interval
+// uses DefaultUpkeepInterval and a negative wage uses DefaultWorkerWage.
+func NewUpkeepService(
+	companyRepo repository.CompanyRepository,
+	buildingRepo repository.ProductionBuildingRepository,
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	ledgerService LedgerService,
+	transactor repository.Transactor,
+	interval time.Duration,
+	wage int64,
+) UpkeepService {
+	if interval <= 0 {
+		interval = DefaultUpkeepInterval
+	}
+	if wage < 0 {
+		wage = DefaultWorkerWage
+	}
+
+	return &upkeepService{
+		companyRepo:         companyRepo,
+		buildingRepo:        buildingRepo,
+		companyBuildingRepo: companyBuildingRepo,
+		ledgerService:       ledgerService,
+		transactor:          transactor,
+		interval:            interval,
+		wage:                wage,
+	}
+}
+
+// Wage returns the wage of a worker per upkeep period.
+func (s *upkeepService) Wage() int64 {
+	return s.wage
+}
+
+// Settle pays as much of the upkeep debt as the company can afford and then
+// charges every upkeep period due since the last settlement. Whatever can't
+// be paid is added to the debt. It returns the company after settling.
+func (s *upkeepService) Settle(ctx context.Context, companyID int64) (*db.Company, error) {
+	company, err := s.companyRepo.GetByID(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Older debt is paid before new charges
+	if company.UpkeepDebt > 0 && company.Money > 0 {
+		if err := s.payDebt(ctx, company); err != nil {
+			return nil, err
+		}
+	}
+
+	owned, err := s.companyBuildingRepo.GetAllByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	buildings := make(map[int64]*db.ProductionBuilding)
+	for _, building := range owned {
+		elapsed := int64(now.Sub(building.CreatedAt) / s.interval)
+		due := elapsed - building.UpkeepPeriodsPaid
+		if due <= 0 {
+			continue
+		}
+
+		buildingType, ok := buildings[building.BuildingID]
+		if !ok {
+			buildingType, err = s.buildingRepo.GetByID(ctx, building.BuildingID)
+			if err != nil {
+				return nil, err
+			}
+			buildings[building.BuildingID] = buildingType
+		}
+
+		// The periods are only marked paid together with their charge
+		err := s.transactor.Transact(ctx, func(ctx context.Context) error {
+			if err := s.companyBuildingRepo.MarkUpkeepPaid(ctx, building.ID, building.UpkeepPeriodsPaid, elapsed); err != nil {
+				return err
+			}
+			if buildingType.Upkeep <= 0 {
+				return nil
+			}
+
+			description := fmt.Sprintf("Upkeep for %s (%d periods)", buildingType.Name, due)
+			return s.charge(ctx, companyID, due*buildingType.Upkeep, db.LedgerUpkeep, description, &building.ID)
+		})
+		if err != nil {
+			if err == repository.ErrUpkeepAlreadyCharged {
+				// Settled concurrently
+				continue
+			}
+			return nil, err
+		}
+	}
+
+	// Wages are paid for whole periods here, the remainder is paid when
+	// the workforce changes
+	if company.WagesPaidUntil != nil {
+		periods := int64(now.Sub(*company.WagesPaidUntil) / s.interval)
+		if periods > 0 {
+			paidUntil := company.WagesPaidUntil.Add(time.Duration(periods) * s.interval)
+			err := s.payWages(ctx, company, paidUntil, periods*company.Workers*s.wage)
+			if err != nil && err != repository.ErrWagesAlreadyPaid {
+				return nil, err
+			}
+		}
+	}
+
+	return s.companyRepo.GetByID(ctx, companyID)
+}
+
+// SettleWages settles the company and then pays the wages of its current
+// workers up to now, prorating the unfinished period. It's called before
+// hiring or firing so that each worker is paid exactly for the time it was
+// hired.
+func (s *upkeepService) SettleWages(ctx context.Context, companyID int64) (*db.Company, error) {
+	for {
+		company, err := s.Settle(ctx, companyID)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		var amount int64
+		if company.WagesPaidUntil != nil && now.After(*company.WagesPaidUntil) {
+			elapsed := now.Sub(*company.WagesPaidUntil)
+			amount = company.Workers * s.wage * int64(elapsed) / int64(s.interval)
+		}
+
+		if err := s.payWages(ctx, company, now, amount); err != nil {
+			if err == repository.ErrWagesAlreadyPaid {
+				// Paid concurrently, settle again from the new date
+				continue
+			}
+			return nil, err
+		}
+
+		return s.companyRepo.GetByID(ctx, companyID)
+	}
+}
+
+// SettleAll settles every company. It's run by the upkeep job so that
+// charges happen even for companies nobody is looking at.
+func (s *upkeepService) SettleAll(ctx context.Context) error {
+	companies, err := s.companyRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, company := range companies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := s.Settle(ctx, company.ID); err != nil {
+			return fmt.Errorf("settle company %d: %w", company.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// payWages marks the wages paid until the given time and charges the amount.
+// It returns ErrWagesAlreadyPaid if the wages were paid concurrently.
+func (s *upkeepService) payWages(ctx context.Context, company *db.Company, paidUntil time.Time, amount int64) error {
+	return s.transactor.Transact(ctx, func(ctx context.Context) error {
+		if err := s.companyRepo.MarkWagesPaid(ctx, company.ID, company.WagesPaidUntil, paidUntil); err != nil {
+			return err
+		}
+		if amount <= 0 {
+			return nil
+		}
+
+		description := fmt.Sprintf("Wages for %d workers", company.Workers)
+		return s.charge(ctx, company.ID, amount, db.LedgerWages, description, nil)
+	})
+}
+
+// Charge takes a charge the company can't refuse, like a contract penalty.
+// The part it can't afford is added to the upkeep debt.
+func (s *upkeepService) Charge(ctx context.Context, companyID, amount int64, kind, description string, referenceID *int64) error {
+	return s.charge(ctx, companyID, amount, kind, description, referenceID)
+}
+
+// charge takes a recurring charge from the company's money, moving the part
+// it can't afford to the upkeep debt.
+func (s *upkeepService) charge(ctx context.Context, companyID, amount int64, kind, description string, referenceID *int64) error {
+	company, err := s.companyRepo.GetByID(ctx, companyID)
+	if err != nil {
+		return err
+	}
+
+	paid := min(amount, company.Money)
+	if paid > 0 {
+		if _, err := s.ledgerService.Apply(ctx, companyID, -paid, kind, description, referenceID); err != nil {
+			if err != ErrInsufficientFunds {
+				return err
+			}
+			// Money was spent concurrently, owe everything
+			paid = 0
+		}
+	}
+
+	if unpaid := amount - paid; unpaid > 0 {
+		return s.companyRepo.AdjustUpkeepDebt(ctx, companyID, unpaid)
+	}
+	return nil
+}
+
+func (s *upkeepService) payDebt(ctx context.Context, company *db.Company) error {
+	payment := min(company.UpkeepDebt, company.Money)
+
+	// The debt is reduced and paid together, concurrent settlements can't
+	// both pay it
+	err := s.transactor.Transact(ctx, func(ctx context.Context) error {
+		if err := s.companyRepo.AdjustUpkeepDebt(ctx, company.ID, -payment); err != nil {
+			return err
+		}
+		_, err := s.ledgerService.Apply(ctx, company.ID, -payment, db.LedgerUpkeepDebt, "Paid upkeep debt", nil)
+		return err
+	})
+	if err == repository.ErrDebtAlreadyPaid || err == ErrInsufficientFunds {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yourownboss/internal/db"
+	"yourownboss/internal/repository"
+)
+
+// DefaultValuationInterval is how often net worth snapshots are taken.
+const DefaultValuationInterval = time.Hour
+
+const (
+	DefaultValuationPageSize = 50
+	MaxValuationPageSize     = 500
+)
+
+// ValuationService values companies: their money, stock at market sell
+// prices and buildings at their sale value, minus the upkeep debt and the
+// balance of active loans. Snapshots are stored periodically to chart net
+// worth over time.
+type ValuationService interface {
+	GetValuation(ctx context.Context, companyID int64) (*db.Valuation, error)
+	GetHistory(ctx context.Context, companyID int64, limit, offset int) ([]db.Valuation, error)
+	ValueAll(ctx context.Context) ([]db.Valuation, error)
+	SnapshotAll(ctx context.Context) error
+}
+
+type valuationService struct {
+	valuationRepo       repository.ValuationRepository
+	companyRepo         repository.CompanyRepository
+	resourceRepo        repository.ResourceRepository
+	inventoryRepo       repository.InventoryRepository
+	buildingRepo        repository.ProductionBuildingRepository
+	levelRepo           repository.ProductionBuildingLevelRepository
+	companyBuildingRepo repository.CompanyBuildingRepository
+	loanRepo            repository.LoanRepository
+}
+
+// NewValuationService creates a new valuation service.
+func NewValuationService(
+	valuationRepo repository.ValuationRepository,
+	companyRepo repository.CompanyRepository,
+	resourceRepo repository.ResourceRepository,
+	inventoryRepo repository.InventoryRepository,
+	buildingRepo repository.ProductionBuildingRepository,
+	levelRepo repository.ProductionBuildingLevelRepository,
+	companyBuildingRepo repository.CompanyBuildingRepository,
+	loanRepo repository.LoanRepository,
+) ValuationService {
+	return &valuationService{
+		valuationRepo:       valuationRepo,
+		companyRepo:         companyRepo,
+		resourceRepo:        resourceRepo,
+		inventoryRepo:       inventoryRepo,
+		buildingRepo:        buildingRepo,
+		levelRepo:           levelRepo,
+		companyBuildingRepo: companyBuildingRepo,
+		loanRepo:            loanRepo,
+	}
+}
+
+// GetValuation values a company now.
+func (s *valuationService) GetValuation(ctx context.Context, companyID int64) (*db.Valuation, error) {
+	company, err := s.companyRepo.GetByID(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.value(ctx, company, resources, time.Now())
+}
+
+func (s *valuationService) GetHistory(ctx context.Context, companyID int64, limit, offset int) ([]db.Valuation, error) {
+	if limit <= 0 {
+		limit = DefaultValuationPageSize
+	}
+	if limit > MaxValuationPageSize {
+		limit = MaxValuationPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.valuationRepo.GetAllByCompany(ctx, companyID, limit, offset)
+}
+
+// ValueAll values every company now.
+func (s *valuationService) ValueAll(ctx context.Context) ([]db.Valuation, error) {
+	companies, err := s.companyRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.resourceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	valuations := make([]db.Valuation, 0, len(companies))
+	for i := range companies {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		valuation, err := s.value(ctx, &companies[i], resources, now)
+		if err != nil {
+			return nil, fmt.Errorf("value company %d: %w", companies[i].ID, err)
+		}
+		valuations = append(valuations, *valuation)
+	}
+
+	return valuations, nil
+}
+
+// SnapshotAll stores the valuation of every company. It's run by the
+// valuation job.
+func (s *valuationService) SnapshotAll(ctx context.Context) error {
+	valuations, err := s.ValueAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range valuations {
+		if err := s.valuationRepo.Create(ctx, &valuations[i]); err != nil {
+			return fmt.Errorf("snapshot company %d: %w", valuations[i].CompanyID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *valuationService) value(ctx context.Context, company *db.Company, resources []db.Resource, now time.Time) (*db.Valuation, error) {
+	valuation := &db.Valuation{
+		CompanyID:   company.ID,
+		Cash:        company.Money,
+		Liabilities: company.UpkeepDebt,
+		CreatedAt:   now,
+	}
+
+	resourceByID := make(map[int64]db.Resource, len(resources))
+	for _, resource := range resources {
+		resourceByID[resource.ID] = resource
+	}
+
+	stock, err := s.inventoryRepo.GetAllByCompany(ctx, company.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range stock {
+		resource, ok := resourceByID[item.ResourceID]
+		if !ok || resource.PackSize <= 0 {
+			continue
+		}
+		valuation.Inventory += item.Quantity * qualityPrice(resource.Price, item.Quality) / resource.PackSize
+	}
+
+	owned, err := s.companyBuildingRepo.GetAllByCompany(ctx, company.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range owned {
+		building, err := s.buildingRepo.GetByID(ctx, owned[i].BuildingID)
+		if err != nil {
+			return nil, err
+		}
+		levels, err := s.levelRepo.GetAllByBuilding(ctx, owned[i].BuildingID)
+		if err != nil {
+			return nil, err
+		}
+		valuation.Buildings += saleValue(building, levels, &owned[i], now)
+	}
+
+	loans, err := s.loanRepo.GetAllByCompany(ctx, company.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, loan := range loans {
+		if loan.Status == db.LoanActive {
+			valuation.Liabilities += loan.Balance
+		}
+	}
+
+	valuation.NetWorth = valuation.Cash + valuation.Inventory + valuation.Buildings - valuation.Liabilities
+	return valuation, nil
+}
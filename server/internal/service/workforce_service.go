@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"yourownboss/internal/repository"
+)
+
+var (
+	ErrInvalidWorkerCount = errors.New("worker count must be positive")
+	ErrWorkersBusy        = errors.New("workers are busy in production runs")
+	ErrNoWorkersAvailable = errors.New("no workers available")
+)
+
+// WorkforceService handles hiring and firing the workers that run
+// production processes. Wages are charged by the upkeep service.
+type WorkforceService interface {
+	GetWorkforce(ctx context.Context, companyID int64) (*Workforce, error)
+	Hire(ctx context.Context, companyID, count int64) (*Workforce, error)
+	Fire(ctx context.Context, companyID, count int64) (*Workforce, error)
+}
+
+// Workforce summarizes the workers of a company. Busy workers are assigned
+// to runs that haven't finished yet.
+type Workforce struct {
+	Hired     int64
+	Busy      int64
+	Available int64
+	Wage      int64 // Per worker and upkeep period
+}
+
+type workforceService struct {
+	companyRepo   repository.CompanyRepository
+	runRepo       repository.ProductionRunRepository
+	upkeepService UpkeepService
+}
+
+// NewWorkforceService creates a new workforce service.
+func NewWorkforceService(
+	companyRepo repository.CompanyRepository,
+	runRepo repository.ProductionRunRepository,
+	upkeepService UpkeepService,
+) WorkforceService {
+	return &workforceService{
+		companyRepo:   companyRepo,
+		runRepo:       runRepo,
+		upkeepService: upkeepService,
+	}
+}
+
+func (s *workforceService) GetWorkforce(ctx context.Context, companyID int64) (*Workforce, error) {
+	company, err := s.upkeepService.Settle(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.workforce(ctx, companyID, company.Workers)
+}
+
+// Hire pays the wages owed so far and adds workers to the company. New
+// workers are paid from the moment they are hired.
+func (s *workforceService) Hire(ctx context.Context, companyID, count int64) (*Workforce, error) {
+	if count <= 0 {
+		return nil, ErrInvalidWorkerCount
+	}
+
+	if _, err := s.upkeepService.SettleWages(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	if err := s.companyRepo.AdjustWorkers(ctx, companyID, count, 0); err != nil {
+		return nil, err
+	}
+
+	return s.GetWorkforce(ctx, companyID)
+}
+
+// Fire pays the wages owed so far and removes workers from the company.
+// Workers assigned to unfinished runs can't be fired.
+func (s *workforceService) Fire(ctx context.Context, companyID, count int64) (*Workforce, error) {
+	if count <= 0 {
+		return nil, ErrInvalidWorkerCount
+	}
+
+	busy, err := busyWorkers(ctx, s.runRepo, companyID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.upkeepService.SettleWages(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	if err := s.companyRepo.AdjustWorkers(ctx, companyID, -count, busy); err != nil {
+		if err == repository.ErrNotEnoughWorkers {
+			return nil, ErrWorkersBusy
+		}
+		return nil, err
+	}
+
+	return s.GetWorkforce(ctx, companyID)
+}
+
+func (s *workforceService) workforce(ctx context.Context, companyID, hired int64) (*Workforce, error) {
+	busy, err := busyWorkers(ctx, s.runRepo, companyID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Workforce{
+		Hired:     hired,
+		Busy:      busy,
+		Available: max(hired-busy, 0),
+		Wage:      s.upkeepService.Wage(),
+	}, nil
+}
+
+// busyWorkers returns how many workers are assigned to the company's runs
+// that haven't finished at the given time.
+func busyWorkers(ctx context.Context, runRepo repository.ProductionRunRepository, companyID int64, now time.Time) (int64, error) {
+	runs, err := runRepo.GetUncollectedByCompany(ctx, companyID)
+	if err != nil {
+		return 0, err
+	}
+
+	var busy int64
+	for _, run := range runs {
+		if !run.Finished(now) {
+			busy += run.Workers
+		}
+	}
+	return busy, nil
+}